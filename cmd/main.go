@@ -1,17 +1,35 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	// ADDED: Imports for Swagger documentation
 	_ "github.com/RezaBG/Inventory-management-api/docs" // This links to the generated docs.
+	"github.com/RezaBG/Inventory-management-api/internal/attachment"
+	"github.com/RezaBG/Inventory-management-api/internal/audit"
+	"github.com/RezaBG/Inventory-management-api/internal/category"
+	"github.com/RezaBG/Inventory-management-api/internal/currency"
+	"github.com/RezaBG/Inventory-management-api/internal/customer"
 	"github.com/RezaBG/Inventory-management-api/internal/inventory"
 	"github.com/RezaBG/Inventory-management-api/internal/middleware"
+	"github.com/RezaBG/Inventory-management-api/internal/oauth"
+	"github.com/RezaBG/Inventory-management-api/internal/organization"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/blob"
 	"github.com/RezaBG/Inventory-management-api/internal/platform/db"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/mail"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/tenant"
+	"github.com/RezaBG/Inventory-management-api/internal/pricelist"
 	"github.com/RezaBG/Inventory-management-api/internal/product"
+	"github.com/RezaBG/Inventory-management-api/internal/sso"
 	"github.com/RezaBG/Inventory-management-api/internal/supplier"
+	"github.com/RezaBG/Inventory-management-api/internal/uom"
 	"github.com/RezaBG/Inventory-management-api/internal/user"
 
 	"github.com/gin-gonic/gin"
@@ -38,6 +56,11 @@ import (
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and a valid JWT token.
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description A scoped API key for machine clients, e.g. "ima_<prefix>_<secret>".
 func main() {
 	// ... (godotenv, db connection, migrations, and DI are unchanged) ...
 	err := godotenv.Load(".env")
@@ -45,42 +68,160 @@ func main() {
 		log.Println("Warning: .env file not found, using environment variables")
 	}
 
+	if err := money.ValidateDefaultCurrency(); err != nil {
+		log.Fatalf("Fatal error: invalid DEFAULT_CURRENCY: %v", err)
+	}
+
 	database, err := db.ConnectDatabase()
 	if err != nil {
 		log.Fatalf("Fatal error: could not connect to database: %v", err)
 	}
 
+	// Products, suppliers and inventory transactions are only ever accessed within the
+	// organization in the request context.
+	if err := database.Use(tenant.Plugin{}); err != nil {
+		log.Fatalf("Fatal error: could not register tenant scoping: %v", err)
+	}
+
+	if err := organization.Migrate(database, "products", "suppliers", "inventory_transactions"); err != nil {
+		log.Fatalf("Fatal error: could not run organization migrations: %v", err)
+	}
+
+	if err := user.Migrate(database); err != nil {
+		log.Fatalf("Fatal error: could not run user migrations: %v", err)
+	}
+
+	if err := supplier.Migrate(database); err != nil {
+		log.Fatalf("Fatal error: could not run supplier migrations: %v", err)
+	}
+
+	if err := category.Migrate(database); err != nil {
+		log.Fatalf("Fatal error: could not run category migrations: %v", err)
+	}
+
+	if err := uom.Migrate(database); err != nil {
+		log.Fatalf("Fatal error: could not run unit of measure migrations: %v", err)
+	}
+
+	if err := currency.Migrate(database); err != nil {
+		log.Fatalf("Fatal error: could not run exchange rate migrations: %v", err)
+	}
+
+	if err := product.Migrate(database); err != nil {
+		log.Fatalf("Fatal error: could not run product migrations: %v", err)
+	}
+
+	if err := customer.Migrate(database); err != nil {
+		log.Fatalf("Fatal error: could not run customer migrations: %v", err)
+	}
+
+	if err := pricelist.Migrate(database); err != nil {
+		log.Fatalf("Fatal error: could not run price list migrations: %v", err)
+	}
+
+	if err := attachment.Migrate(database); err != nil {
+		log.Fatalf("Fatal error: could not run attachment migrations: %v", err)
+	}
+
 	err = database.AutoMigrate(
-		&product.Product{},
-		&user.User{},
-		&user.RefreshToken{},
-		&supplier.Supplier{},
 		&inventory.InventoryTransaction{},
+		&inventory.Assembly{},
+		&oauth.Client{},
+		&sso.LoginState{},
+		&audit.Entry{},
 	)
 	if err != nil {
 		log.Fatalf("Fatal error: could not run migrations: %v", err)
 	}
 	log.Println("Database migrations completed successfully.")
 
+	// Registered after the migrations, so the data they seed isn't audited.
+	if err := database.Use(audit.Plugin{}); err != nil {
+		log.Fatalf("Fatal error: could not register audit logging: %v", err)
+	}
+
+	mailer, err := mail.NewSender()
+	if err != nil {
+		log.Fatalf("Fatal error: could not configure mail sender: %v", err)
+	}
+
+	blobStore, err := blob.NewStore()
+	if err != nil {
+		log.Fatalf("Fatal error: could not configure blob storage: %v", err)
+	}
+
 	// --- Dependency Injection ---
 	// 1. Initialize all Repositories
 	userRepo := user.NewRepository(database)
 	refreshTokenRepo := user.NewRefreshTokenRepository(database)
+	oneTimeTokenRepo := user.NewOneTimeTokenRepository(database)
+	roleRepo := user.NewRoleRepository(database)
+	recoveryCodeRepo := user.NewRecoveryCodeRepository(database)
+	loginThrottleRepo := user.NewLoginThrottleRepository(database)
+	lockoutEventRepo := user.NewLockoutEventRepository(database)
+	apiKeyRepo := user.NewAPIKeyRepository(database)
+	identityRepo := user.NewIdentityRepository(database)
+	organizationRepo := organization.NewRepository(database)
+	oauthClientRepo := oauth.NewRepository(database)
+	auditRepo := audit.NewRepository(database)
+	categoryRepo := category.NewRepository(database)
+	unitRepo := uom.NewRepository(database)
+	exchangeRateRepo := currency.NewRepository(database)
 	productRepo := product.NewRepository(database)
 	supplierRepo := supplier.NewRepository(database)
+	customerRepo := customer.NewRepository(database)
+	priceListRepo := pricelist.NewRepository(database)
+	attachmentRepo := attachment.NewRepository(database)
 	inventoryRepo := inventory.NewRepository(database)
 
 	// 2. Initialize all Services
-	userSvc := user.NewService(userRepo, refreshTokenRepo)
+	organizationSvc := organization.NewService(organizationRepo, userRepo)
+	userSvc := user.NewService(
+		userRepo,
+		refreshTokenRepo,
+		oneTimeTokenRepo,
+		roleRepo,
+		recoveryCodeRepo,
+		loginThrottleRepo,
+		lockoutEventRepo,
+		apiKeyRepo,
+		identityRepo,
+		organizationSvc,
+		mailer,
+	)
+	oauthSvc := oauth.NewService(oauthClientRepo, userRepo, organizationSvc)
+	auditSvc := audit.NewService(auditRepo)
 	supplierSvc := supplier.NewService(supplierRepo)
-	productSvc := product.NewService(productRepo, inventoryRepo)
-	inventorySvc := inventory.NewService(inventoryRepo, productRepo)
+	exchangeRateSvc := currency.NewService(exchangeRateRepo)
+	categorySvc := category.NewService(categoryRepo, exchangeRateSvc)
+	unitSvc := uom.NewService(unitRepo)
+	attachmentSvc := attachment.NewService(attachmentRepo, blobStore, productRepo, supplierRepo)
+	productSvc := product.NewService(productRepo, inventoryRepo, categoryRepo, unitRepo, exchangeRateSvc, attachmentSvc)
+	inventorySvc := inventory.NewService(inventoryRepo, productRepo, unitRepo)
+	customerSvc := customer.NewService(customerRepo)
+	priceListSvc := pricelist.NewService(priceListRepo, productRepo, customerRepo, exchangeRateSvc)
 
 	// 3. Initialize all Handlers
 	userHandler := user.NewHandler(userSvc)
+	oauthHandler := oauth.NewHandler(oauthSvc)
+	organizationHandler := organization.NewHandler(organizationSvc)
+	auditHandler := audit.NewHandler(auditSvc)
+	categoryHandler := category.NewHandler(categorySvc)
+	unitHandler := uom.NewHandler(unitSvc)
+	exchangeRateHandler := currency.NewHandler(exchangeRateSvc)
 	productHandler := product.NewHandler(productSvc)
 	supplierHandler := supplier.NewHandler(supplierSvc)
 	inventoryHandler := inventory.NewHandler(inventorySvc)
+	customerHandler := customer.NewHandler(customerSvc)
+	priceListHandler := pricelist.NewHandler(priceListSvc)
+	attachmentHandler := attachment.NewHandler(attachmentSvc)
+
+	// --- Background Jobs ---
+	priceSchedulerSeconds, _ := strconv.Atoi(os.Getenv("PRICE_SCHEDULER_INTERVAL_SECONDS"))
+	if priceSchedulerSeconds <= 0 {
+		priceSchedulerSeconds = 60
+	}
+	go product.RunPriceScheduler(context.Background(), productSvc, time.Duration(priceSchedulerSeconds)*time.Second)
 
 	// --- Middleware ---
 	authMiddleware := middleware.AuthMiddleware(userSvc)
@@ -91,23 +232,86 @@ func main() {
 		port = "8080"
 	}
 	router := gin.Default()
+	// Client IPs, which the login throttle and the audit log rely on, are only taken
+	// from X-Forwarded-For when the request comes through one of TRUSTED_PROXIES.
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Fatal error: invalid TRUSTED_PROXIES: %v", err)
+	}
+	router.Use(middleware.RequestID())
 
 	// --- Register Routes ---
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	user.RegisterAuthRoutes(router, userHandler)
+	oauth.RegisterTokenRoutes(router, oauthHandler)
+
+	// Single sign-on is only enabled when an OIDC provider is configured.
+	if ssoConfig, ok := sso.ConfigFromEnv(); ok {
+		ssoSvc := sso.NewService(ssoConfig, sso.NewStateRepository(database), userSvc)
+		sso.RegisterRoutes(router, sso.NewHandler(ssoSvc))
+	}
 	router.GET("/hello", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "Hello, World!"})
 	})
 
-	// Protected Routes (Requires a valid JWT)
-	protectedRoutes := router.Group("/")
-	protectedRoutes.Use(authMiddleware)
+	// Authenticated Routes (Requires a valid JWT or API key)
+	authenticatedRoutes := router.Group("/")
+	authenticatedRoutes.Use(authMiddleware)
+	user.RegisterMFARoutes(authenticatedRoutes, userHandler)
+
+	// Protected Routes (Also requires 2FA when the user's role demands it)
+	protectedRoutes := authenticatedRoutes.Group("/")
+	protectedRoutes.Use(middleware.RequireMFAEnrollment())
+	{
+		user.RegisterProfileRoutes(protectedRoutes, userHandler)
+		organization.RegisterRoutes(protectedRoutes, organizationHandler)
+	}
+
+	// Tenant Routes (Data of the credential's active organization only)
+	tenantRoutes := protectedRoutes.Group("/")
+	tenantRoutes.Use(middleware.RequireOrganization(organizationSvc))
+	{
+		category.RegisterRoutes(tenantRoutes, categoryHandler)
+		uom.RegisterRoutes(tenantRoutes, unitHandler)
+		currency.RegisterRoutes(tenantRoutes, exchangeRateHandler)
+		product.RegisterRoutes(tenantRoutes, productHandler)
+		supplier.RegisterRoutes(tenantRoutes, supplierHandler)
+		inventory.RegisterRoutes(tenantRoutes, inventoryHandler)
+		customer.RegisterRoutes(tenantRoutes, customerHandler)
+		pricelist.RegisterRoutes(tenantRoutes, priceListHandler)
+		attachment.RegisterRoutes(tenantRoutes, attachmentHandler)
+	}
+
+	// Tenant Manager Routes (Changes to products and suppliers)
+	tenantManagerRoutes := tenantRoutes.Group("/")
+	tenantManagerRoutes.Use(middleware.RequireRole(user.ManagerRoles...))
+	{
+		product.RegisterManagerRoutes(tenantManagerRoutes, productHandler)
+		supplier.RegisterManagerRoutes(tenantManagerRoutes, supplierHandler)
+	}
+
+	// Tenant Admin Routes (Organization settings only administrators may change)
+	tenantAdminRoutes := tenantRoutes.Group("/")
+	tenantAdminRoutes.Use(middleware.RequireRole(user.RoleAdmin))
+	{
+		category.RegisterAdminRoutes(tenantAdminRoutes, categoryHandler)
+	}
+
+	// Admin Routes
+	adminRoutes := protectedRoutes.Group("/")
+	adminRoutes.Use(middleware.RequireRole(user.RoleAdmin))
 	{
-		product.RegisterRoutes(protectedRoutes, productHandler)
-		supplier.RegisterRoutes(protectedRoutes, supplierHandler)
-		inventory.RegisterRoutes(protectedRoutes, inventoryHandler)
+		user.RegisterAdminRoutes(adminRoutes, userHandler)
+		oauth.RegisterAdminRoutes(adminRoutes, oauthHandler)
+		organization.RegisterAdminRoutes(adminRoutes, organizationHandler)
+		audit.RegisterAdminRoutes(adminRoutes, auditHandler)
 	}
 
 	// --- Start Server ---
@@ -0,0 +1,21 @@
+package attachment
+
+import "time"
+
+// AttachmentResponse is an attached file with the URLs it can be downloaded from.
+// ThumbnailURL, Width and Height are only set for images.
+type AttachmentResponse struct {
+	ID           uint      `json:"id"`
+	OwnerType    string    `json:"ownerType"`
+	OwnerID      uint      `json:"ownerId"`
+	FileName     string    `json:"fileName"`
+	ContentType  string    `json:"contentType"`
+	Size         int64     `json:"size"`
+	Checksum     string    `json:"checksum"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnailUrl,omitempty"`
+	UserID       *uint     `json:"userId"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
@@ -0,0 +1,120 @@
+package attachment
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	// Decoders for the image formats that can be uploaded.
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	defaultMaxSizeMB = 10
+	// maxPixels bounds the images that are decoded for thumbnails, since a small
+	// compressed file can expand to gigabytes in memory.
+	maxPixels = 40_000_000
+	// thumbnailSize is the longest side of a thumbnail in pixels.
+	thumbnailSize = 256
+)
+
+// allowedTypes are the content types that can be uploaded, as sniffed from the file
+// itself rather than taken from the client.
+var allowedTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+// maxSize is the largest file that can be uploaded, in bytes. It defaults to 10 MB
+// and is set with ATTACHMENT_MAX_SIZE_MB.
+func maxSize() int64 {
+	sizeMB, _ := strconv.Atoi(os.Getenv("ATTACHMENT_MAX_SIZE_MB"))
+	if sizeMB <= 0 {
+		sizeMB = defaultMaxSizeMB
+	}
+	return int64(sizeMB) << 20
+}
+
+// sniff returns the content type of a file from its first bytes.
+func sniff(data []byte) string {
+	contentType := http.DetectContentType(data)
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return contentType
+}
+
+func isImage(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/")
+}
+
+// cleanFileName keeps the base name of an uploaded file so client paths never end
+// up in responses or headers.
+func cleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
+
+// thumbnail decodes an image and scales it down to fit within thumbnailSize,
+// returning the JPEG-encoded thumbnail and the image's dimensions. Transparent
+// areas become white.
+func thumbnail(data []byte) (thumb []byte, width, height int, err error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, ErrInvalidImage
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, 0, 0, ErrInvalidImage
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, ErrInvalidImage
+	}
+
+	w, h := config.Width, config.Height
+	if w > thumbnailSize || h > thumbnailSize {
+		if w >= h {
+			w, h = thumbnailSize, max(1, h*thumbnailSize/w)
+		} else {
+			w, h = max(1, w*thumbnailSize/h), thumbnailSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, 0, 0, err
+	}
+	return buf.Bytes(), config.Width, config.Height, nil
+}
@@ -0,0 +1,259 @@
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/blob"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// multipartOverhead is how far an upload request may exceed the largest file, to
+// leave room for the multipart framing around it.
+const multipartOverhead = 1 << 20
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// UploadProductAttachment attaches a file to a product.
+// @Summary      Attach a file to a product
+// @Description  Uploads a file as "file". JPEG, PNG, GIF and WebP images and PDF documents can be attached; the type is detected from the file's content, not its name. Files may be at most ATTACHMENT_MAX_SIZE_MB (10 MB by default). Images get a thumbnail of at most 256 pixels on the longer side.
+// @Tags         Attachments
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id    path      int   true  "Product ID"
+// @Param        file  formData  file  true  "Image or PDF"
+// @Success      201  {object}  AttachmentResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      413  {object}  map[string]interface{}
+// @Failure      415  {object}  map[string]interface{}
+// @Router       /products/{id}/attachments [post]
+func (h *Handler) UploadProductAttachment(c *gin.Context) {
+	h.upload(c, OwnerProduct)
+}
+
+// GetProductAttachments lists the files attached to a product.
+// @Summary      List a product's attachments
+// @Tags         Attachments
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Product ID"
+// @Success      200  {array}   AttachmentResponse
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /products/{id}/attachments [get]
+func (h *Handler) GetProductAttachments(c *gin.Context) {
+	h.list(c, OwnerProduct)
+}
+
+// UploadSupplierAttachment attaches a file to a supplier.
+// @Summary      Attach a file to a supplier
+// @Description  Uploads a file as "file". JPEG, PNG, GIF and WebP images and PDF documents can be attached; the type is detected from the file's content, not its name. Files may be at most ATTACHMENT_MAX_SIZE_MB (10 MB by default). Images get a thumbnail of at most 256 pixels on the longer side.
+// @Tags         Attachments
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id    path      int   true  "Supplier ID"
+// @Param        file  formData  file  true  "Image or PDF"
+// @Success      201  {object}  AttachmentResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      413  {object}  map[string]interface{}
+// @Failure      415  {object}  map[string]interface{}
+// @Router       /suppliers/{id}/attachments [post]
+func (h *Handler) UploadSupplierAttachment(c *gin.Context) {
+	h.upload(c, OwnerSupplier)
+}
+
+// GetSupplierAttachments lists the files attached to a supplier.
+// @Summary      List a supplier's attachments
+// @Tags         Attachments
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Supplier ID"
+// @Success      200  {array}   AttachmentResponse
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /suppliers/{id}/attachments [get]
+func (h *Handler) GetSupplierAttachments(c *gin.Context) {
+	h.list(c, OwnerSupplier)
+}
+
+// GetAttachment retrieves a single attachment.
+// @Summary      Get an attachment
+// @Tags         Attachments
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Attachment ID"
+// @Success      200  {object}  AttachmentResponse
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /attachments/{id} [get]
+func (h *Handler) GetAttachment(c *gin.Context) {
+	attachment, err := h.svc.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err, "")
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+// GetAttachmentContent downloads an attached file.
+// @Summary      Download an attachment
+// @Description  Sends the file as uploaded, to be shown inline; with download=true, browsers save it instead.
+// @Tags         Attachments
+// @Produce      octet-stream
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id        path   int   true   "Attachment ID"
+// @Param        download  query  bool  false  "Send as a download"
+// @Success      200  {file}    file
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /attachments/{id}/content [get]
+func (h *Handler) GetAttachmentContent(c *gin.Context) {
+	h.send(c, false)
+}
+
+// GetAttachmentThumbnail downloads the thumbnail of an attached image.
+// @Summary      Download an attachment's thumbnail
+// @Description  Sends a JPEG of at most 256 pixels on the longer side. Only images have thumbnails.
+// @Tags         Attachments
+// @Produce      jpeg
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Attachment ID"
+// @Success      200  {file}    file
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /attachments/{id}/thumbnail [get]
+func (h *Handler) GetAttachmentThumbnail(c *gin.Context) {
+	h.send(c, true)
+}
+
+// DeleteAttachment deletes an attachment and its file.
+// @Summary      Delete an attachment
+// @Tags         Attachments
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Attachment ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /attachments/{id} [delete]
+func (h *Handler) DeleteAttachment(c *gin.Context) {
+	if err := h.svc.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		h.respondError(c, err, "")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) upload(c *gin.Context, ownerType string) {
+	limit := h.svc.MaxSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+multipartOverhead)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.respondError(c, ErrFileTooLarge, ownerType)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required as \"file\""})
+		return
+	}
+	if header.Size > limit {
+		h.respondError(c, ErrFileTooLarge, ownerType)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attachment, err := h.svc.Upload(c.Request.Context(), ownerType, c.Param("id"), header.Filename, data)
+	if err != nil {
+		h.respondError(c, err, ownerType)
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+func (h *Handler) list(c *gin.Context, ownerType string) {
+	attachments, err := h.svc.List(c.Request.Context(), ownerType, c.Param("id"))
+	if err != nil {
+		h.respondError(c, err, ownerType)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+func (h *Handler) send(c *gin.Context, thumbnail bool) {
+	content, err := h.svc.Open(c.Request.Context(), c.Param("id"), thumbnail)
+	if err != nil {
+		h.respondError(c, err, "")
+		return
+	}
+	defer content.Body.Close()
+
+	disposition := "inline"
+	if download, _ := strconv.ParseBool(c.Query("download")); download {
+		disposition = "attachment"
+	}
+	if header := mime.FormatMediaType(disposition, map[string]string{"filename": content.FileName}); header != "" {
+		disposition = header
+	}
+
+	// Files are served with the type they were sniffed as, never one a browser guesses.
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, content.Size, content.ContentType, content.Body, map[string]string{
+		"Content-Disposition": disposition,
+	})
+}
+
+func (h *Handler) respondError(c *gin.Context, err error, ownerType string) {
+	switch {
+	case errors.Is(err, ErrOwnerNotFound):
+		if ownerType == OwnerSupplier {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
+		} else {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, blob.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+	case errors.Is(err, ErrNoThumbnail):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrFileTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("files may be at most %d bytes", h.svc.MaxSize())})
+	case errors.Is(err, ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidImage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,8 @@
+package attachment
+
+import "gorm.io/gorm"
+
+// Migrate creates or updates the attachments table.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Attachment{})
+}
@@ -0,0 +1,35 @@
+package attachment
+
+import "gorm.io/gorm"
+
+// Owners files can be attached to.
+const (
+	OwnerProduct  = "product"
+	OwnerSupplier = "supplier"
+)
+
+// Attachment is an image or document attached to a product or supplier. The file
+// itself, and the thumbnail of an image, live in the blob store under Key and
+// ThumbnailKey.
+type Attachment struct {
+	gorm.Model
+	OrgID       uint   `json:"-" gorm:"not null;index:idx_attachments_owner"`
+	OwnerType   string `json:"ownerType" gorm:"not null;size:20;index:idx_attachments_owner"`
+	OwnerID     uint   `json:"ownerId" gorm:"not null;index:idx_attachments_owner"`
+	FileName    string `json:"fileName" gorm:"not null"`
+	ContentType string `json:"contentType" gorm:"not null"`
+	Size        int64  `json:"size" gorm:"not null"`
+	// Checksum is the hex-encoded SHA-256 of the file.
+	Checksum      string `json:"checksum" gorm:"not null;size:64"`
+	Key           string `json:"-" gorm:"not null"`
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
+	ThumbnailKey  string `json:"-"`
+	ThumbnailSize int64  `json:"-"`
+	// UserID is who uploaded the file, if it was a user.
+	UserID *uint `json:"userId"`
+}
+
+func (Attachment) AuditEntityType() string {
+	return "attachment"
+}
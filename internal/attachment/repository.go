@@ -0,0 +1,68 @@
+package attachment
+
+import (
+	"context"
+
+	"github.com/RezaBG/Inventory-management-api/internal/product"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	List(ctx context.Context, ownerType string, ownerID uint) ([]Attachment, error)
+	FindByID(ctx context.Context, id string) (*Attachment, error)
+	Save(ctx context.Context, attachment *Attachment) (*Attachment, error)
+	Delete(ctx context.Context, attachment *Attachment) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// List lists the attachments of an owner in the order they were uploaded.
+func (r *repository) List(ctx context.Context, ownerType string, ownerID uint) ([]Attachment, error) {
+	var attachments []Attachment
+	err := r.db.WithContext(ctx).
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Order("id").
+		Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *repository) FindByID(ctx context.Context, id string) (*Attachment, error) {
+	var attachment Attachment
+	err := r.db.WithContext(ctx).First(&attachment, id).Error
+	return &attachment, err
+}
+
+// Save saves a new attachment. Attachments are part of product responses, so the
+// version of an owning product is bumped in the same transaction.
+func (r *repository) Save(ctx context.Context, attachment *Attachment) (*Attachment, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attachment).Error; err != nil {
+			return err
+		}
+		return bumpOwnerVersion(tx, attachment)
+	})
+	return attachment, err
+}
+
+// Delete deletes an attachment and, like Save, bumps the version of an owning product.
+func (r *repository) Delete(ctx context.Context, attachment *Attachment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(attachment).Error; err != nil {
+			return err
+		}
+		return bumpOwnerVersion(tx, attachment)
+	})
+}
+
+func bumpOwnerVersion(tx *gorm.DB, attachment *Attachment) error {
+	if attachment.OwnerType != OwnerProduct {
+		return nil
+	}
+	return product.BumpVersion(tx, attachment.OwnerID)
+}
@@ -0,0 +1,18 @@
+package attachment
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, h *Handler) {
+	router.POST("/products/:id/attachments", h.UploadProductAttachment)
+	router.GET("/products/:id/attachments", h.GetProductAttachments)
+	router.POST("/suppliers/:id/attachments", h.UploadSupplierAttachment)
+	router.GET("/suppliers/:id/attachments", h.GetSupplierAttachments)
+
+	attachmentRoutes := router.Group("/attachments")
+	{
+		attachmentRoutes.GET("/:id", h.GetAttachment)
+		attachmentRoutes.GET("/:id/content", h.GetAttachmentContent)
+		attachmentRoutes.GET("/:id/thumbnail", h.GetAttachmentThumbnail)
+		attachmentRoutes.DELETE("/:id", h.DeleteAttachment)
+	}
+}
@@ -0,0 +1,301 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	"github.com/RezaBG/Inventory-management-api/internal/audit"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/blob"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/tenant"
+	"github.com/RezaBG/Inventory-management-api/internal/product"
+	"github.com/RezaBG/Inventory-management-api/internal/supplier"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOwnerNotFound   = errors.New("owner not found")
+	ErrFileTooLarge    = errors.New("file is too large")
+	ErrUnsupportedType = errors.New("only JPEG, PNG, GIF and WebP images and PDF documents can be attached")
+	ErrInvalidImage    = errors.New("image could not be decoded or is larger than 40 megapixels")
+	ErrNoThumbnail     = errors.New("attachment has no thumbnail")
+)
+
+// ProductCatalog looks up the products files are attached to.
+type ProductCatalog interface {
+	FindByID(ctx context.Context, id string) (*product.Product, error)
+}
+
+// SupplierDirectory looks up the suppliers files are attached to.
+type SupplierDirectory interface {
+	FindByID(ctx context.Context, id string) (*supplier.Supplier, error)
+}
+
+// Content is an attachment's file, or its thumbnail, ready to be sent. The caller
+// closes Body.
+type Content struct {
+	Body        io.ReadCloser
+	FileName    string
+	ContentType string
+	Size        int64
+}
+
+type Service interface {
+	Upload(ctx context.Context, ownerType, ownerID, fileName string, data []byte) (*AttachmentResponse, error)
+	List(ctx context.Context, ownerType, ownerID string) ([]AttachmentResponse, error)
+	Get(ctx context.Context, id string) (*AttachmentResponse, error)
+	Open(ctx context.Context, id string, thumbnail bool) (*Content, error)
+	Delete(ctx context.Context, id string) error
+	ProductAttachments(ctx context.Context, productID uint) ([]product.AttachmentLink, error)
+	MaxSize() int64
+}
+
+type service struct {
+	repo      Repository
+	store     blob.Store
+	products  ProductCatalog
+	suppliers SupplierDirectory
+	maxSize   int64
+}
+
+func NewService(repo Repository, store blob.Store, products ProductCatalog, suppliers SupplierDirectory) Service {
+	return &service{
+		repo:      repo,
+		store:     store,
+		products:  products,
+		suppliers: suppliers,
+		maxSize:   maxSize(),
+	}
+}
+
+// MaxSize is the largest file that can be uploaded, in bytes.
+func (s *service) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Upload attaches a file to a product or supplier. The content type is sniffed from
+// the file; images get a thumbnail.
+func (s *service) Upload(ctx context.Context, ownerType, ownerID, fileName string, data []byte) (*AttachmentResponse, error) {
+	owner, err := s.findOwner(ctx, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, ErrFileTooLarge
+	}
+	contentType := sniff(data)
+	if !allowedTypes[contentType] {
+		return nil, ErrUnsupportedType
+	}
+
+	checksum := sha256.Sum256(data)
+	attachment := Attachment{
+		OwnerType:   ownerType,
+		OwnerID:     owner,
+		FileName:    cleanFileName(fileName),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Checksum:    hex.EncodeToString(checksum[:]),
+	}
+	if actor, ok := audit.ActorFrom(ctx); ok {
+		attachment.UserID = &actor.UserID
+	}
+
+	var thumb []byte
+	if isImage(contentType) {
+		thumb, attachment.Width, attachment.Height, err = thumbnail(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	attachment.Key, err = newKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, attachment.Key, bytes.NewReader(data), attachment.Size, contentType); err != nil {
+		return nil, err
+	}
+	if thumb != nil {
+		attachment.ThumbnailKey = attachment.Key + "-thumbnail"
+		attachment.ThumbnailSize = int64(len(thumb))
+		if err := s.store.Put(ctx, attachment.ThumbnailKey, bytes.NewReader(thumb), attachment.ThumbnailSize, "image/jpeg"); err != nil {
+			s.removeBlobs(ctx, &attachment)
+			return nil, err
+		}
+	}
+
+	if _, err := s.repo.Save(ctx, &attachment); err != nil {
+		s.removeBlobs(ctx, &attachment)
+		return nil, err
+	}
+
+	response := toAttachmentResponse(attachment)
+	return &response, nil
+}
+
+func (s *service) List(ctx context.Context, ownerType, ownerID string) ([]AttachmentResponse, error) {
+	owner, err := s.findOwner(ctx, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := s.repo.List(ctx, ownerType, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]AttachmentResponse, 0, len(attachments))
+	for _, attachment := range attachments {
+		responses = append(responses, toAttachmentResponse(attachment))
+	}
+	return responses, nil
+}
+
+func (s *service) Get(ctx context.Context, id string) (*AttachmentResponse, error) {
+	attachment, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	response := toAttachmentResponse(*attachment)
+	return &response, nil
+}
+
+// Open opens an attachment's file or, with thumbnail, the thumbnail of an image.
+func (s *service) Open(ctx context.Context, id string, thumbnail bool) (*Content, error) {
+	attachment, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	content := Content{
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+	}
+	key := attachment.Key
+	if thumbnail {
+		if attachment.ThumbnailKey == "" {
+			return nil, ErrNoThumbnail
+		}
+		key = attachment.ThumbnailKey
+		content.ContentType = "image/jpeg"
+		content.Size = attachment.ThumbnailSize
+	}
+
+	content.Body, err = s.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
+// Delete deletes an attachment together with its file and thumbnail.
+func (s *service) Delete(ctx context.Context, id string) error {
+	attachment, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, attachment); err != nil {
+		return err
+	}
+	s.removeBlobs(ctx, attachment)
+	return nil
+}
+
+// ProductAttachments lists the files attached to a product for product responses.
+func (s *service) ProductAttachments(ctx context.Context, productID uint) ([]product.AttachmentLink, error) {
+	attachments, err := s.repo.List(ctx, OwnerProduct, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]product.AttachmentLink, 0, len(attachments))
+	for _, attachment := range attachments {
+		response := toAttachmentResponse(attachment)
+		links = append(links, product.AttachmentLink{
+			ID:           response.ID,
+			FileName:     response.FileName,
+			ContentType:  response.ContentType,
+			Size:         response.Size,
+			URL:          response.URL,
+			ThumbnailURL: response.ThumbnailURL,
+		})
+	}
+	return links, nil
+}
+
+// findOwner returns the ID of the product or supplier files are attached to.
+func (s *service) findOwner(ctx context.Context, ownerType, id string) (uint, error) {
+	var ownerID uint
+	var err error
+	switch ownerType {
+	case OwnerProduct:
+		var p *product.Product
+		if p, err = s.products.FindByID(ctx, id); err == nil {
+			ownerID = p.ID
+		}
+	case OwnerSupplier:
+		var sup *supplier.Supplier
+		if sup, err = s.suppliers.FindByID(ctx, id); err == nil {
+			ownerID = sup.ID
+		}
+	default:
+		return 0, fmt.Errorf("unknown attachment owner %q", ownerType)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, ErrOwnerNotFound
+	}
+	return ownerID, err
+}
+
+// removeBlobs deletes an attachment's file and thumbnail. Failures only leave
+// unreferenced blobs behind, so they are logged rather than returned.
+func (s *service) removeBlobs(ctx context.Context, attachment *Attachment) {
+	for _, key := range []string{attachment.Key, attachment.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("could not delete blob %s: %v", key, err)
+		}
+	}
+}
+
+// newKey returns a random blob key within the organization's prefix.
+func newKey(ctx context.Context) (string, error) {
+	orgID, _ := tenant.OrganizationID(ctx)
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("could not generate attachment key: %w", err)
+	}
+	return "attachments/" + strconv.FormatUint(uint64(orgID), 10) + "/" + hex.EncodeToString(random), nil
+}
+
+func toAttachmentResponse(attachment Attachment) AttachmentResponse {
+	url := "/attachments/" + strconv.FormatUint(uint64(attachment.ID), 10)
+	response := AttachmentResponse{
+		ID:          attachment.ID,
+		OwnerType:   attachment.OwnerType,
+		OwnerID:     attachment.OwnerID,
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Checksum:    attachment.Checksum,
+		Width:       attachment.Width,
+		Height:      attachment.Height,
+		URL:         url + "/content",
+		UserID:      attachment.UserID,
+		CreatedAt:   attachment.CreatedAt,
+	}
+	if attachment.ThumbnailKey != "" {
+		response.ThumbnailURL = url + "/thumbnail"
+	}
+	return response
+}
@@ -0,0 +1,23 @@
+package audit
+
+import "context"
+
+// Actor describes who made the changes in a request.
+type Actor struct {
+	UserID    uint
+	RequestID string
+	IPAddress string
+}
+
+type contextKey struct{}
+
+// WithActor returns a context whose changes are attributed to actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, contextKey{}, actor)
+}
+
+// ActorFrom returns the actor stored in the context, if any.
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(contextKey{}).(Actor)
+	return actor, ok
+}
@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/db"
+)
+
+type ListEntriesInput struct {
+	OrganizationID uint   `form:"organizationId"`
+	ActorID        uint   `form:"actorId"`
+	Action         Action `form:"action" binding:"omitempty,oneof=create update delete"`
+	EntityType     string `form:"entityType"`
+	EntityID       string `form:"entityId"`
+	RequestID      string `form:"requestId"`
+	// From and To bound the entry's timestamp (RFC 3339), inclusive and exclusive.
+	From     *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page     int        `form:"page,default=1" binding:"min=1"`
+	PageSize int        `form:"pageSize,default=20" binding:"min=1,max=100"`
+}
+
+type EntryResponse struct {
+	ID             uint      `json:"id"`
+	CreatedAt      time.Time `json:"createdAt"`
+	OrganizationID *uint     `json:"organizationId"`
+	ActorID        *uint     `json:"actorId"`
+	Action         Action    `json:"action"`
+	EntityType     string    `json:"entityType"`
+	EntityID       string    `json:"entityId"`
+	Before         db.JSON   `json:"before" swaggertype:"object"`
+	After          db.JSON   `json:"after" swaggertype:"object"`
+	Changes        db.JSON   `json:"changes" swaggertype:"object"`
+	RequestID      string    `json:"requestId"`
+	IPAddress      string    `json:"ipAddress"`
+}
+
+type EntryListResponse struct {
+	Data     []EntryResponse `json:"data"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"pageSize"`
+	Total    int64           `json:"total"`
+}
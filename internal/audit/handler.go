@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// ListEntries searches the audit log, newest entries first.
+// @Summary      Search the audit log
+// @Description  Lists creates, updates and deletes of audited records with the acting user, request ID, client IP and a before/after snapshot.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        organizationId  query     int     false  "Organization ID"
+// @Param        actorId         query     int     false  "Acting user ID"
+// @Param        action          query     string  false  "Action"  Enums(create, update, delete)
+// @Param        entityType      query     string  false  "Entity type, e.g. product"
+// @Param        entityId        query     string  false  "Entity ID"
+// @Param        requestId       query     string  false  "Request ID (X-Request-ID)"
+// @Param        from            query     string  false  "Earliest timestamp (RFC 3339)"
+// @Param        to              query     string  false  "Latest timestamp, exclusive (RFC 3339)"
+// @Param        page            query     int     false  "Page number (default 1)"
+// @Param        pageSize        query     int     false  "Page size (default 20, max 100)"
+// @Success      200  {object}  EntryListResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /audit [get]
+func (h *Handler) ListEntries(c *gin.Context) {
+	var input ListEntriesInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, err := h.svc.ListEntries(input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/db"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Entry records one change to one auditable record. Before and After are snapshots of
+// the record's columns; Changes holds only the columns an update modified, as
+// {"column": {"from": ..., "to": ...}}.
+type Entry struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	// OrganizationID is the organization the change was made in, if any. It is not named
+	// OrgID so entries stay readable to administrators across organizations.
+	OrganizationID *uint  `gorm:"index"`
+	ActorID        *uint  `gorm:"index"`
+	Action         Action `gorm:"not null"`
+	EntityType     string `gorm:"not null;index:idx_audit_log_entity"`
+	EntityID       string `gorm:"not null;index:idx_audit_log_entity"`
+	Before         db.JSON
+	After          db.JSON
+	Changes        db.JSON
+	RequestID      string `gorm:"index"`
+	IPAddress      string
+}
+
+func (Entry) TableName() string {
+	return "audit_log"
+}
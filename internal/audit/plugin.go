@@ -0,0 +1,322 @@
+// Package audit records who created, updated or deleted which record, and how.
+// Entries are written by GORM callbacks in the same transaction as the change itself,
+// so a change and its audit entry are committed or rolled back together.
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/db"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Auditable is implemented by models whose changes are recorded in the audit log.
+// Columns of fields tagged json:"-" are left out of the snapshots.
+type Auditable interface {
+	AuditEntityType() string
+}
+
+// ignoredColumns change on every update, or every time a credential is used, and are
+// left out of Changes. An update that changes nothing else isn't recorded.
+var ignoredColumns = map[string]bool{"updated_at": true, "version": true, "last_used_at": true}
+
+const beforeKey = "audit:before"
+
+// Plugin registers the audit callbacks; install it with db.Use(audit.Plugin{}).
+type Plugin struct{}
+
+func (Plugin) Name() string {
+	return "audit"
+}
+
+func (Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:create", recordCreate); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:begin_transaction").Before("gorm:update").
+		Register("audit:before_update", captureBefore); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:update", recordUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:begin_transaction").Before("gorm:delete").
+		Register("audit:before_delete", captureBefore); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:delete", recordDelete)
+}
+
+// record is a snapshot of one row, keyed by column name.
+type record struct {
+	id     string
+	key    []interface{}
+	values map[string]interface{}
+}
+
+type change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+func entityType(db *gorm.DB) (string, bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return "", false
+	}
+	model, ok := reflect.New(db.Statement.Schema.ModelType).Interface().(Auditable)
+	if !ok {
+		return "", false
+	}
+	return model.AuditEntityType(), true
+}
+
+func recordCreate(db *gorm.DB) {
+	entity, ok := entityType(db)
+	if !ok || db.RowsAffected == 0 {
+		return
+	}
+
+	var entries []Entry
+	for _, after := range snapshots(db, db.Statement.ReflectValue) {
+		entries = append(entries, newEntry(db, ActionCreate, entity, after.id, nil, after.values, nil))
+	}
+	save(db, entries)
+}
+
+// captureBefore loads the rows an update or delete is about to change, using the
+// statement's conditions plus the primary key of the model it was given.
+func captureBefore(db *gorm.DB) {
+	if _, ok := entityType(db); !ok {
+		return
+	}
+
+	stmt := db.Statement
+	tx := db.Session(&gorm.Session{NewDB: true})
+	conditions := 0
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			tx = tx.Clauses(where)
+			conditions++
+		}
+	}
+	_, keys := schema.GetIdentityFieldValuesMap(stmt.Context, stmt.ReflectValue, stmt.Schema.PrimaryFields)
+	if len(keys) > 0 {
+		column, values := schema.ToQueryValues(clause.CurrentTable, stmt.Schema.PrimaryFieldDBNames, keys)
+		tx = tx.Where(clause.IN{Column: column, Values: values})
+		conditions++
+	}
+	// GORM refuses updates and deletes without conditions unless explicitly allowed.
+	if conditions == 0 && !db.AllowGlobalUpdate {
+		return
+	}
+	if stmt.Unscoped {
+		tx = tx.Unscoped()
+	}
+
+	rows, err := find(tx, stmt.Schema)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+	db.InstanceSet(beforeKey, snapshots(db, rows))
+}
+
+func recordUpdate(db *gorm.DB) {
+	entity, ok := entityType(db)
+	if !ok || db.RowsAffected == 0 {
+		return
+	}
+	before := capturedRecords(db)
+	if len(before) == 0 {
+		return
+	}
+
+	// Reload by primary key: the update's own conditions may no longer match.
+	stmt := db.Statement
+	keys := make([][]interface{}, 0, len(before))
+	for _, r := range before {
+		keys = append(keys, r.key)
+	}
+	column, values := schema.ToQueryValues(clause.CurrentTable, stmt.Schema.PrimaryFieldDBNames, keys)
+	tx := db.Session(&gorm.Session{NewDB: true}).Unscoped().
+		Where(clause.IN{Column: column, Values: values})
+	rows, err := find(tx, stmt.Schema)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+	after := make(map[string]record, len(before))
+	for _, r := range snapshots(db, rows) {
+		after[r.id] = r
+	}
+
+	var entries []Entry
+	for _, b := range before {
+		a, ok := after[b.id]
+		if !ok {
+			continue
+		}
+		changes := diff(b.values, a.values)
+		if len(changes) == 0 {
+			continue
+		}
+		entries = append(entries, newEntry(db, ActionUpdate, entity, b.id, b.values, a.values, changes))
+	}
+	save(db, entries)
+}
+
+func recordDelete(db *gorm.DB) {
+	entity, ok := entityType(db)
+	if !ok || db.RowsAffected == 0 {
+		return
+	}
+
+	var entries []Entry
+	for _, before := range capturedRecords(db) {
+		entries = append(entries, newEntry(db, ActionDelete, entity, before.id, before.values, nil, nil))
+	}
+	save(db, entries)
+}
+
+func capturedRecords(db *gorm.DB) []record {
+	value, _ := db.InstanceGet(beforeKey)
+	records, _ := value.([]record)
+	return records
+}
+
+func find(tx *gorm.DB, s *schema.Schema) (reflect.Value, error) {
+	rows := reflect.New(reflect.SliceOf(s.ModelType))
+	err := tx.Find(rows.Interface()).Error
+	return rows.Elem(), err
+}
+
+func snapshots(db *gorm.DB, value reflect.Value) []record {
+	var records []record
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			records = append(records, snapshot(db, reflect.Indirect(value.Index(i))))
+		}
+	case reflect.Struct:
+		records = append(records, snapshot(db, value))
+	}
+	return records
+}
+
+func snapshot(db *gorm.DB, value reflect.Value) record {
+	ctx, s := db.Statement.Context, db.Statement.Schema
+	r := record{values: map[string]interface{}{}}
+	for _, field := range s.Fields {
+		if field.DBName == "" || field.Tag.Get("json") == "-" {
+			continue
+		}
+		r.values[field.DBName], _ = field.ValueOf(ctx, value)
+	}
+
+	ids := make([]string, 0, len(s.PrimaryFields))
+	for _, field := range s.PrimaryFields {
+		key, _ := field.ValueOf(ctx, value)
+		r.key = append(r.key, key)
+		ids = append(ids, fmt.Sprint(key))
+	}
+	r.id = strings.Join(ids, ":")
+	return r
+}
+
+func diff(before, after map[string]interface{}) map[string]interface{} {
+	changes := map[string]interface{}{}
+	for column, to := range after {
+		if ignoredColumns[column] {
+			continue
+		}
+		from := before[column]
+		if sameJSON(from, to) {
+			continue
+		}
+		changes[column] = change{From: from, To: to}
+	}
+	return changes
+}
+
+func sameJSON(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+func newEntry(
+	tx *gorm.DB,
+	action Action,
+	entity, entityID string,
+	before, after, changes map[string]interface{},
+) Entry {
+	entry := Entry{
+		Action:     action,
+		EntityType: entity,
+		EntityID:   entityID,
+		Before:     encode(tx, before),
+		After:      encode(tx, after),
+		Changes:    encode(tx, changes),
+	}
+
+	ctx := tx.Statement.Context
+	if orgID, ok := tenant.OrganizationID(ctx); ok {
+		entry.OrganizationID = &orgID
+	}
+	if actor, ok := ActorFrom(ctx); ok {
+		if actor.UserID != 0 {
+			entry.ActorID = &actor.UserID
+		}
+		entry.RequestID = actor.RequestID
+		entry.IPAddress = actor.IPAddress
+	}
+	return entry
+}
+
+func encode(tx *gorm.DB, values map[string]interface{}) db.JSON {
+	if values == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		_ = tx.AddError(fmt.Errorf("audit: %w", err))
+		return nil
+	}
+	return db.JSON(encoded)
+}
+
+// save writes the entries through the statement's connection, i.e. inside the
+// transaction of the change. A failure rolls the change back.
+func save(tx *gorm.DB, entries []Entry) {
+	if len(entries) == 0 || tx.Error != nil {
+		return
+	}
+	if err := tx.Session(&gorm.Session{NewDB: true}).Create(&entries).Error; err != nil {
+		_ = tx.AddError(fmt.Errorf("audit: %w", err))
+	}
+}
+
+// RecordUpdate records a change the callbacks don't see, such as one to a join table,
+// as an update of the entity. before and after hold the changed values under names of
+// the caller's choosing; nothing is recorded if they are the same. Pass the
+// transaction of the change as tx.
+func RecordUpdate(tx *gorm.DB, entity, entityID string, before, after map[string]interface{}) error {
+	changes := diff(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	entries := []Entry{newEntry(tx, ActionUpdate, entity, entityID, before, after, changes)}
+	save(tx, entries)
+	return tx.Error
+}
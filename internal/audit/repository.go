@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Filter narrows a search of the audit log; zero values match everything.
+type Filter struct {
+	OrganizationID uint
+	ActorID        uint
+	Action         Action
+	EntityType     string
+	EntityID       string
+	RequestID      string
+	From           *time.Time
+	To             *time.Time
+}
+
+type Repository interface {
+	Search(filter Filter, offset, limit int) ([]Entry, int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Search(filter Filter, offset, limit int) ([]Entry, int64, error) {
+	db := r.db.Model(&Entry{})
+	if filter.OrganizationID != 0 {
+		db = db.Where("organization_id = ?", filter.OrganizationID)
+	}
+	if filter.ActorID != 0 {
+		db = db.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		db = db.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		db = db.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.RequestID != "" {
+		db = db.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		db = db.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		db = db.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []Entry
+	err := db.Order("id DESC").Offset(offset).Limit(limit).Find(&entries).Error
+	return entries, total, err
+}
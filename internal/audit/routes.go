@@ -0,0 +1,9 @@
+package audit
+
+import "github.com/gin-gonic/gin"
+
+// RegisterAdminRoutes registers the audit log search. The router group is expected to
+// be restricted to administrators.
+func RegisterAdminRoutes(router *gin.RouterGroup, h *Handler) {
+	router.GET("/audit", h.ListEntries)
+}
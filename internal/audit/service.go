@@ -0,0 +1,59 @@
+package audit
+
+type Service interface {
+	ListEntries(input ListEntriesInput) (*EntryListResponse, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func toEntryResponse(entry Entry) EntryResponse {
+	return EntryResponse{
+		ID:             entry.ID,
+		CreatedAt:      entry.CreatedAt,
+		OrganizationID: entry.OrganizationID,
+		ActorID:        entry.ActorID,
+		Action:         entry.Action,
+		EntityType:     entry.EntityType,
+		EntityID:       entry.EntityID,
+		Before:         entry.Before,
+		After:          entry.After,
+		Changes:        entry.Changes,
+		RequestID:      entry.RequestID,
+		IPAddress:      entry.IPAddress,
+	}
+}
+
+func (s *service) ListEntries(input ListEntriesInput) (*EntryListResponse, error) {
+	filter := Filter{
+		OrganizationID: input.OrganizationID,
+		ActorID:        input.ActorID,
+		Action:         input.Action,
+		EntityType:     input.EntityType,
+		EntityID:       input.EntityID,
+		RequestID:      input.RequestID,
+		From:           input.From,
+		To:             input.To,
+	}
+	entries, total, err := s.repo.Search(filter, (input.Page-1)*input.PageSize, input.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]EntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		data = append(data, toEntryResponse(entry))
+	}
+
+	return &EntryListResponse{
+		Data:     data,
+		Page:     input.Page,
+		PageSize: input.PageSize,
+		Total:    total,
+	}, nil
+}
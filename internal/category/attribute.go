@@ -0,0 +1,125 @@
+package category
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type AttributeType string
+
+const (
+	AttributeString  AttributeType = "string"
+	AttributeNumber  AttributeType = "number"
+	AttributeBoolean AttributeType = "boolean"
+	AttributeDate    AttributeType = "date"
+)
+
+var (
+	ErrInvalidAttributes = errors.New("invalid attributes")
+
+	attributeKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+)
+
+// AttributeDefinition is a custom attribute of the products in a category and its
+// descendants, e.g. voltage (a number) for electrical goods.
+type AttributeDefinition struct {
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	OrgID      uint          `gorm:"not null;uniqueIndex:idx_category_attributes_org_key"`
+	CategoryID uint          `gorm:"not null;uniqueIndex:idx_category_attributes_org_key"`
+	Key        string        `gorm:"not null;uniqueIndex:idx_category_attributes_org_key"`
+	Type       AttributeType `gorm:"not null"`
+	Required   bool          `gorm:"not null;default:false"`
+	// AllowedValues, if any, are the only values a string attribute can have.
+	AllowedValues []string `gorm:"type:jsonb;serializer:json"`
+}
+
+func (AttributeDefinition) TableName() string {
+	return "category_attributes"
+}
+
+func (AttributeDefinition) AuditEntityType() string {
+	return "category_attribute"
+}
+
+// ValidateAttributes checks a product's attribute values against the definitions of
+// its categories: every attribute must be defined, have the defined type and, for
+// required attributes, be set. A key defined by several categories must satisfy all
+// of their definitions.
+func ValidateAttributes(definitions []AttributeDefinition, values map[string]interface{}) error {
+	byKey := map[string][]AttributeDefinition{}
+	for _, definition := range definitions {
+		byKey[definition.Key] = append(byKey[definition.Key], definition)
+	}
+
+	var problems []string
+	for key, value := range values {
+		if _, ok := byKey[key]; !ok {
+			problems = append(problems, fmt.Sprintf("%s is not an attribute of the product's categories", key))
+		}
+		if value == nil {
+			continue
+		}
+		for _, definition := range byKey[key] {
+			if problem := definition.check(value); problem != "" {
+				problems = append(problems, key+" "+problem)
+			}
+		}
+	}
+	for key, keyDefinitions := range byKey {
+		for _, definition := range keyDefinitions {
+			if definition.Required && values[key] == nil {
+				problems = append(problems, key+" is required")
+				break
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%w: %s", ErrInvalidAttributes, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// check returns what is wrong with a value of the attribute, if anything.
+func (d AttributeDefinition) check(value interface{}) string {
+	switch d.Type {
+	case AttributeNumber:
+		if _, ok := value.(float64); !ok {
+			return "must be a number"
+		}
+	case AttributeBoolean:
+		if _, ok := value.(bool); !ok {
+			return "must be true or false"
+		}
+	case AttributeDate:
+		s, ok := value.(string)
+		if _, err := time.Parse(time.DateOnly, s); !ok || err != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	default:
+		s, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+		if len(d.AllowedValues) > 0 && !contains(d.AllowedValues, s) {
+			return "must be one of " + strings.Join(d.AllowedValues, ", ")
+		}
+	}
+	return ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package category
+
+import "time"
+
+// CreateCategoryInput is a new category; without a parent it becomes a root.
+type CreateCategoryInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	ParentID    *uint  `json:"parentId"`
+}
+
+// UpdateCategoryInput replaces a category's editable fields. Changing the parent moves
+// the category together with its descendants.
+type UpdateCategoryInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	ParentID    *uint  `json:"parentId"`
+}
+
+type CategoryResponse struct {
+	ID          uint      `json:"id"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	ParentID    *uint     `json:"parentId"`
+}
+
+// CategoryNode is a category with its subcategories. The rollups cover the category
+// and all of its descendants; stock value is the stock of each product or variant times
+// its price, converted to the currency at the current exchange rates.
+type CategoryNode struct {
+	CategoryResponse
+	ProductCount  int64          `json:"productCount"`
+	StockQuantity float64        `json:"stockQuantity"`
+	StockValue    float64        `json:"stockValue"`
+	Currency      string         `json:"currency"`
+	Children      []CategoryNode `json:"children"`
+}
+
+type AttributeDefinitionInput struct {
+	Key           string        `json:"key" binding:"required"`
+	Type          AttributeType `json:"type" binding:"required,oneof=string number boolean date"`
+	Required      bool          `json:"required"`
+	AllowedValues []string      `json:"allowedValues"`
+}
+
+// SetAttributesInput replaces the attribute definitions of a category.
+type SetAttributesInput struct {
+	Attributes []AttributeDefinitionInput `json:"attributes" binding:"dive"`
+}
+
+// AttributeDefinitionResponse is an attribute definition; categoryId is the category
+// that defines it, which may be an ancestor of the requested one.
+type AttributeDefinitionResponse struct {
+	CategoryID    uint          `json:"categoryId"`
+	Key           string        `json:"key"`
+	Type          AttributeType `json:"type"`
+	Required      bool          `json:"required"`
+	AllowedValues []string      `json:"allowedValues"`
+}
@@ -0,0 +1,217 @@
+package category
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/RezaBG/Inventory-management-api/internal/currency"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// GetTree returns the category tree.
+// @Summary      Get the category tree
+// @Description  Returns the root categories with their subcategories, at any depth. Each category carries the number of products, the stock and the stock value (stock times price) of itself and all of its subcategories, computed from the inventory ledger. Stock values are converted to the requested currency, by default the default currency, at the current exchange rates.
+// @Tags         Categories
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        currency  query     string  false  "Currency of the stock values"
+// @Success      200  {array}   CategoryNode
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /categories [get]
+func (h *Handler) GetTree(c *gin.Context) {
+	tree, err := h.svc.GetTree(c.Request.Context(), c.Query("currency"))
+	if err != nil {
+		if errors.Is(err, money.ErrUnknownCurrency) || errors.Is(err, currency.ErrNoRate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
+// GetCategory retrieves a single category with its subcategories.
+// @Summary      Get a single category
+// @Description  Returns a category with its subcategories and the rollups of its subtree.
+// @Tags         Categories
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id        path      int     true   "Category ID"
+// @Param        currency  query     string  false  "Currency of the stock values"
+// @Success      200  {object}  CategoryNode
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /categories/{id} [get]
+func (h *Handler) GetCategory(c *gin.Context) {
+	category, err := h.svc.GetCategory(c.Request.Context(), c.Param("id"), c.Query("currency"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// CreateCategory creates a new category.
+// @Summary      Create a new category
+// @Description  Creates a category under parentId, or a root category without one.
+// @Tags         Categories
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        category  body      CreateCategoryInput  true  "Category Information"
+// @Success      201  {object}  CategoryResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /categories [post]
+func (h *Handler) CreateCategory(c *gin.Context) {
+	var input CreateCategoryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.svc.CreateCategory(c.Request.Context(), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory updates a category.
+// @Summary      Update a category
+// @Description  Replaces a category's name, description and parent. Changing the parent moves the category with all of its subcategories; it can't be moved under itself or one of its subcategories.
+// @Tags         Categories
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id        path      int                  true  "Category ID"
+// @Param        category  body      UpdateCategoryInput  true  "Category Information"
+// @Success      200  {object}  CategoryResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /categories/{id} [put]
+func (h *Handler) UpdateCategory(c *gin.Context) {
+	var input UpdateCategoryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.svc.UpdateCategory(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory deletes a category.
+// @Summary      Delete a category
+// @Description  Deletes a category. Categories that still have subcategories or products can't be deleted.
+// @Tags         Categories
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Category ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /categories/{id} [delete]
+func (h *Handler) DeleteCategory(c *gin.Context) {
+	if err := h.svc.DeleteCategory(c.Request.Context(), c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetAttributes returns the attribute definitions of a category.
+// @Summary      Get a category's attributes
+// @Description  Returns the custom attributes of the products in a category: those defined by the category itself and by its ancestors.
+// @Tags         Categories
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Category ID"
+// @Success      200  {array}   AttributeDefinitionResponse
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /categories/{id}/attributes [get]
+func (h *Handler) GetAttributes(c *gin.Context) {
+	attributes, err := h.svc.GetAttributes(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attributes)
+}
+
+// SetAttributes replaces the attribute definitions of a category.
+// @Summary      Set a category's attributes
+// @Description  Replaces the custom attributes the category defines for its products and those of its subcategories. Types are string, number, boolean and date (YYYY-MM-DD); string attributes can be limited to allowed values. Admins only.
+// @Tags         Categories
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id          path  int                 true  "Category ID"
+// @Param        attributes  body  SetAttributesInput  true  "Attribute definitions"
+// @Success      200  {array}   AttributeDefinitionResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /categories/{id}/attributes [put]
+func (h *Handler) SetAttributes(c *gin.Context) {
+	var input SetAttributesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attributes, err := h.svc.SetAttributes(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attributes)
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+	case errors.Is(err, ErrParentNotFound), errors.Is(err, ErrCycle), errors.Is(err, ErrInvalidDefinition),
+		errors.Is(err, money.ErrUnknownCurrency), errors.Is(err, currency.ErrNoRate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNotEmpty):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,9 @@
+package category
+
+import "gorm.io/gorm"
+
+// Migrate creates or updates the categories and category_attributes tables. Run it before product.Migrate,
+// which links products to categories.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Category{}, &AttributeDefinition{})
+}
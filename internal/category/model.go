@@ -0,0 +1,17 @@
+package category
+
+import "gorm.io/gorm"
+
+// Category is a node of an organization's category tree. Categories without a parent
+// are the roots of the tree.
+type Category struct {
+	gorm.Model
+	OrgID       uint   `json:"-" gorm:"not null;index"`
+	ParentID    *uint  `json:"parentId" gorm:"index"`
+	Name        string `json:"name" gorm:"not null"`
+	Description string `json:"description"`
+}
+
+func (Category) AuditEntityType() string {
+	return "category"
+}
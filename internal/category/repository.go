@@ -0,0 +1,247 @@
+package category
+
+import (
+	"context"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/tenant"
+	"gorm.io/gorm"
+)
+
+// treeLock is the class of the advisory lock that serializes changes to the parents of
+// an organization's categories. The organization ID is the other half of the key.
+const treeLock = 1
+
+// subtreeQuery selects the IDs of a category and all of its descendants. The recursive
+// query bypasses the tenant scope, so it is restricted to the organization explicitly.
+// UNION drops rows that were already visited, so the query ends even if the parents
+// form a cycle.
+const subtreeQuery = `WITH RECURSIVE subtree (id) AS (
+		SELECT id FROM categories WHERE id = @id AND org_id = @org AND deleted_at IS NULL
+		UNION
+		SELECT categories.id FROM categories JOIN subtree ON categories.parent_id = subtree.id
+		WHERE categories.org_id = @org AND categories.deleted_at IS NULL
+	)
+	SELECT id FROM subtree`
+
+// rollupQuery sums up the products of every category and its descendants, and their
+// stock and stock value from the inventory ledger. A product counts once per category,
+// even if it is in several of the category's descendants. Stock of a variant is valued
+// at the variant's price. Stock values are in minor units, one row per currency. As in
+// subtreeQuery, UNION keeps the recursion finite if the parents form a cycle.
+const rollupQuery = `WITH RECURSIVE tree (ancestor_id, id) AS (
+		SELECT id, id FROM categories WHERE org_id = @org AND deleted_at IS NULL
+		UNION
+		SELECT tree.ancestor_id, categories.id FROM categories JOIN tree ON categories.parent_id = tree.id
+		WHERE categories.org_id = @org AND categories.deleted_at IS NULL
+	),
+	members AS (
+		SELECT DISTINCT tree.ancestor_id, products.id AS product_id, products.currency
+		FROM tree JOIN products ON products.org_id = @org AND products.deleted_at IS NULL
+			AND (products.primary_category_id = tree.id OR EXISTS (
+				SELECT 1 FROM product_categories
+				WHERE product_categories.product_id = products.id AND product_categories.category_id = tree.id))
+	),
+	stock AS (
+		SELECT inventory_transactions.product_id,
+			SUM(inventory_transactions.quantity_change) AS quantity,
+			SUM(inventory_transactions.quantity_change * COALESCE(product_variants.price_override_minor, products.price_minor)) AS value_minor
+		FROM inventory_transactions
+		JOIN products ON products.id = inventory_transactions.product_id
+		LEFT JOIN product_variants ON product_variants.id = inventory_transactions.variant_id
+		WHERE inventory_transactions.org_id = @org AND inventory_transactions.deleted_at IS NULL
+		GROUP BY inventory_transactions.product_id
+	)
+	SELECT members.ancestor_id AS category_id, members.currency,
+		COUNT(*) AS product_count,
+		COALESCE(SUM(stock.quantity), 0) AS stock_quantity,
+		COALESCE(SUM(stock.value_minor), 0) AS stock_value_minor
+	FROM members LEFT JOIN stock ON stock.product_id = members.product_id
+	GROUP BY members.ancestor_id, members.currency`
+
+// ancestorsQuery selects the IDs of the categories and all of their ancestors.
+const ancestorsQuery = `WITH RECURSIVE ancestors (id, parent_id) AS (
+		SELECT id, parent_id FROM categories WHERE id IN @ids AND org_id = @org AND deleted_at IS NULL
+		UNION
+		SELECT categories.id, categories.parent_id FROM categories JOIN ancestors ON categories.id = ancestors.parent_id
+		WHERE categories.org_id = @org AND categories.deleted_at IS NULL
+	)
+	SELECT id FROM ancestors`
+
+// Rollup is the product count, stock and stock value of the products of a category
+// and its descendants that are priced in a currency.
+type Rollup struct {
+	CategoryID      uint
+	Currency        string
+	ProductCount    int64
+	StockQuantity   float64
+	StockValueMinor float64
+}
+
+type Repository interface {
+	List(ctx context.Context) ([]Category, error)
+	FindByID(ctx context.Context, id string) (*Category, error)
+	Save(ctx context.Context, category *Category) (*Category, error)
+	Update(ctx context.Context, category *Category) (*Category, error)
+	Delete(ctx context.Context, category *Category) error
+	CountExisting(ctx context.Context, ids []uint) (int64, error)
+	SubtreeIDs(ctx context.Context, id uint) ([]uint, error)
+	HasChildren(ctx context.Context, id uint) (bool, error)
+	HasProducts(ctx context.Context, id uint) (bool, error)
+	Rollups(ctx context.Context) ([]Rollup, error)
+	AttributeDefinitions(ctx context.Context, categoryIDs []uint) ([]AttributeDefinition, error)
+	ReplaceAttributeDefinitions(ctx context.Context, categoryID uint, definitions []AttributeDefinition) error
+	WithTreeLock(ctx context.Context, fn func(repo Repository) error) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) List(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	err := r.db.WithContext(ctx).Order("name, id").Find(&categories).Error
+	return categories, err
+}
+
+func (r *repository) FindByID(ctx context.Context, id string) (*Category, error) {
+	var category Category
+	err := r.db.WithContext(ctx).First(&category, id).Error
+	return &category, err
+}
+
+func (r *repository) Save(ctx context.Context, category *Category) (*Category, error) {
+	err := r.db.WithContext(ctx).Create(category).Error
+	return category, err
+}
+
+func (r *repository) Update(ctx context.Context, category *Category) (*Category, error) {
+	err := r.db.WithContext(ctx).Model(category).Select("*").Updates(category).Error
+	return category, err
+}
+
+// Delete deletes the category and its attribute definitions.
+func (r *repository) Delete(ctx context.Context, category *Category) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(category).Error; err != nil {
+			return err
+		}
+		return tx.Where("category_id = ?", category.ID).Delete(&AttributeDefinition{}).Error
+	})
+}
+
+// WithTreeLock runs fn in a transaction that holds the organization's tree lock, with a
+// repository that works in the transaction. Checking a new parent and saving it under
+// the lock keeps two concurrent moves from creating a cycle between them.
+func (r *repository) WithTreeLock(ctx context.Context, fn func(repo Repository) error) error {
+	orgID, ok := tenant.OrganizationID(ctx)
+	if !ok {
+		return tenant.ErrMissingOrganization
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", treeLock, orgID).Error; err != nil {
+			return err
+		}
+		return fn(&repository{db: tx})
+	})
+}
+
+// CountExisting counts how many of the IDs are categories of the organization.
+func (r *repository) CountExisting(ctx context.Context, ids []uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Category{}).Where("id IN ?", ids).Count(&count).Error
+	return count, err
+}
+
+// SubtreeIDs returns the ID of the category and of all its descendants, or nothing if
+// the category doesn't exist.
+func (r *repository) SubtreeIDs(ctx context.Context, id uint) ([]uint, error) {
+	orgID, ok := tenant.OrganizationID(ctx)
+	if !ok {
+		return nil, tenant.ErrMissingOrganization
+	}
+
+	var ids []uint
+	err := r.db.WithContext(ctx).Raw(subtreeQuery, map[string]interface{}{"id": id, "org": orgID}).Scan(&ids).Error
+	return ids, err
+}
+
+func (r *repository) HasChildren(ctx context.Context, id uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Category{}).Where("parent_id = ?", id).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// HasProducts reports whether any product that hasn't been deleted is in the category.
+func (r *repository) HasProducts(ctx context.Context, id uint) (bool, error) {
+	orgID, ok := tenant.OrganizationID(ctx)
+	if !ok {
+		return false, tenant.ErrMissingOrganization
+	}
+
+	var exists bool
+	err := r.db.WithContext(ctx).Raw(`SELECT EXISTS (
+			SELECT 1 FROM products
+			WHERE products.org_id = @org AND products.deleted_at IS NULL
+				AND (products.primary_category_id = @id OR EXISTS (
+					SELECT 1 FROM product_categories
+					WHERE product_categories.product_id = products.id AND product_categories.category_id = @id)))`,
+		map[string]interface{}{"id": id, "org": orgID},
+	).Scan(&exists).Error
+	return exists, err
+}
+
+// Rollups returns the rollups of all categories that have products, per currency.
+func (r *repository) Rollups(ctx context.Context) ([]Rollup, error) {
+	orgID, ok := tenant.OrganizationID(ctx)
+	if !ok {
+		return nil, tenant.ErrMissingOrganization
+	}
+
+	var rollups []Rollup
+	err := r.db.WithContext(ctx).Raw(rollupQuery, map[string]interface{}{"org": orgID}).Scan(&rollups).Error
+	return rollups, err
+}
+
+// AttributeDefinitions returns the attribute definitions of the categories and of all
+// of their ancestors, which apply to the categories too.
+func (r *repository) AttributeDefinitions(ctx context.Context, categoryIDs []uint) ([]AttributeDefinition, error) {
+	if len(categoryIDs) == 0 {
+		return nil, nil
+	}
+	orgID, ok := tenant.OrganizationID(ctx)
+	if !ok {
+		return nil, tenant.ErrMissingOrganization
+	}
+
+	var ids []uint
+	err := r.db.WithContext(ctx).Raw(ancestorsQuery, map[string]interface{}{"ids": categoryIDs, "org": orgID}).Scan(&ids).Error
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	var definitions []AttributeDefinition
+	err = r.db.WithContext(ctx).Where("category_id IN ?", ids).Order("key, category_id").Find(&definitions).Error
+	return definitions, err
+}
+
+// ReplaceAttributeDefinitions makes definitions the category's only attribute
+// definitions.
+func (r *repository) ReplaceAttributeDefinitions(ctx context.Context, categoryID uint, definitions []AttributeDefinition) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("category_id = ?", categoryID).Delete(&AttributeDefinition{}).Error; err != nil {
+			return err
+		}
+		if len(definitions) == 0 {
+			return nil
+		}
+		for i := range definitions {
+			definitions[i].CategoryID = categoryID
+		}
+		return tx.Create(&definitions).Error
+	})
+}
@@ -0,0 +1,21 @@
+package category
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, h *Handler) {
+	categoryRoutes := router.Group("/categories")
+	{
+		categoryRoutes.POST("", h.CreateCategory)
+		categoryRoutes.GET("", h.GetTree)
+		categoryRoutes.GET("/:id", h.GetCategory)
+		categoryRoutes.PUT("/:id", h.UpdateCategory)
+		categoryRoutes.DELETE("/:id", h.DeleteCategory)
+		categoryRoutes.GET("/:id/attributes", h.GetAttributes)
+	}
+}
+
+// RegisterAdminRoutes registers the definition of custom attributes. The router group
+// is expected to be restricted to administrators of the active organization.
+func RegisterAdminRoutes(router *gin.RouterGroup, h *Handler) {
+	router.PUT("/categories/:id/attributes", h.SetAttributes)
+}
@@ -0,0 +1,318 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/currency"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+)
+
+var (
+	ErrParentNotFound = errors.New("parent category not found")
+	ErrCycle          = errors.New("a category can't be moved under itself or one of its subcategories")
+	ErrNotEmpty       = errors.New("category still has subcategories or products; move them first")
+
+	ErrInvalidDefinition = errors.New("attribute keys must be unique and start with a letter, followed by letters, digits or underscores; only string attributes can have allowed values")
+)
+
+// ExchangeRates looks up the exchange rates stock values are converted with.
+type ExchangeRates interface {
+	Table(ctx context.Context, at time.Time) (*currency.Table, error)
+}
+
+type Service interface {
+	GetTree(ctx context.Context, to string) ([]CategoryNode, error)
+	GetCategory(ctx context.Context, id, to string) (*CategoryNode, error)
+	CreateCategory(ctx context.Context, input CreateCategoryInput) (*CategoryResponse, error)
+	UpdateCategory(ctx context.Context, id string, input UpdateCategoryInput) (*CategoryResponse, error)
+	DeleteCategory(ctx context.Context, id string) error
+	GetAttributes(ctx context.Context, id string) ([]AttributeDefinitionResponse, error)
+	SetAttributes(ctx context.Context, id string, input SetAttributesInput) ([]AttributeDefinitionResponse, error)
+}
+
+type service struct {
+	repo  Repository
+	rates ExchangeRates
+}
+
+func NewService(repo Repository, rates ExchangeRates) Service {
+	return &service{repo: repo, rates: rates}
+}
+
+func toCategoryResponse(category Category) CategoryResponse {
+	return CategoryResponse{
+		ID:          category.ID,
+		CreatedAt:   category.CreatedAt,
+		UpdatedAt:   category.UpdatedAt,
+		Name:        category.Name,
+		Description: category.Description,
+		ParentID:    category.ParentID,
+	}
+}
+
+// GetTree returns the root categories with their descendants and rollups, with stock
+// values in a currency, by default DEFAULT_CURRENCY.
+func (s *service) GetTree(ctx context.Context, to string) ([]CategoryNode, error) {
+	tree, err := s.buildTree(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	return tree.nodes(nil), nil
+}
+
+// GetCategory returns a category with its descendants and rollups, with stock values
+// in a currency, by default DEFAULT_CURRENCY.
+func (s *service) GetCategory(ctx context.Context, id, to string) (*CategoryNode, error) {
+	category, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := s.buildTree(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	node := tree.node(*category)
+	return &node, nil
+}
+
+func (s *service) CreateCategory(ctx context.Context, input CreateCategoryInput) (*CategoryResponse, error) {
+	category := Category{
+		Name:        input.Name,
+		Description: input.Description,
+		ParentID:    input.ParentID,
+	}
+	err := s.repo.WithTreeLock(ctx, func(repo Repository) error {
+		if err := checkParent(ctx, repo, 0, input.ParentID); err != nil {
+			return err
+		}
+		_, err := repo.Save(ctx, &category)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := toCategoryResponse(category)
+	return &response, nil
+}
+
+func (s *service) UpdateCategory(ctx context.Context, id string, input UpdateCategoryInput) (*CategoryResponse, error) {
+	category, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	category.Name = input.Name
+	category.Description = input.Description
+	category.ParentID = input.ParentID
+	err = s.repo.WithTreeLock(ctx, func(repo Repository) error {
+		if err := checkParent(ctx, repo, category.ID, input.ParentID); err != nil {
+			return err
+		}
+		_, err := repo.Update(ctx, category)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := toCategoryResponse(*category)
+	return &response, nil
+}
+
+// DeleteCategory deletes a category that has neither subcategories nor products.
+func (s *service) DeleteCategory(ctx context.Context, id string) error {
+	category, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	hasChildren, err := s.repo.HasChildren(ctx, category.ID)
+	if err != nil {
+		return err
+	}
+	hasProducts, err := s.repo.HasProducts(ctx, category.ID)
+	if err != nil {
+		return err
+	}
+	if hasChildren || hasProducts {
+		return ErrNotEmpty
+	}
+
+	return s.repo.Delete(ctx, category)
+}
+
+// GetAttributes returns the attribute definitions that apply to the products of a
+// category: its own and those of its ancestors.
+func (s *service) GetAttributes(ctx context.Context, id string) ([]AttributeDefinitionResponse, error) {
+	category, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, err := s.repo.AttributeDefinitions(ctx, []uint{category.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]AttributeDefinitionResponse, 0, len(definitions))
+	for _, definition := range definitions {
+		responses = append(responses, AttributeDefinitionResponse{
+			CategoryID:    definition.CategoryID,
+			Key:           definition.Key,
+			Type:          definition.Type,
+			Required:      definition.Required,
+			AllowedValues: definition.AllowedValues,
+		})
+	}
+	return responses, nil
+}
+
+// SetAttributes replaces the category's own attribute definitions. Products are
+// validated against the new definitions when they are next created or updated.
+func (s *service) SetAttributes(ctx context.Context, id string, input SetAttributesInput) ([]AttributeDefinitionResponse, error) {
+	category, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]bool{}
+	definitions := make([]AttributeDefinition, 0, len(input.Attributes))
+	for _, attribute := range input.Attributes {
+		if !attributeKeyPattern.MatchString(attribute.Key) || keys[attribute.Key] ||
+			(attribute.Type != AttributeString && len(attribute.AllowedValues) > 0) {
+			return nil, ErrInvalidDefinition
+		}
+		keys[attribute.Key] = true
+
+		definitions = append(definitions, AttributeDefinition{
+			Key:           attribute.Key,
+			Type:          attribute.Type,
+			Required:      attribute.Required,
+			AllowedValues: attribute.AllowedValues,
+		})
+	}
+
+	if err := s.repo.ReplaceAttributeDefinitions(ctx, category.ID, definitions); err != nil {
+		return nil, err
+	}
+	return s.GetAttributes(ctx, id)
+}
+
+// checkParent makes sure that the parent exists and, for an existing category with
+// the given ID, isn't in the category's own subtree. It must run under the tree lock,
+// together with saving the parent.
+func checkParent(ctx context.Context, repo Repository, id uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+
+	count, err := repo.CountExisting(ctx, []uint{*parentID})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrParentNotFound
+	}
+
+	if id == 0 {
+		return nil
+	}
+	subtree, err := repo.SubtreeIDs(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, descendant := range subtree {
+		if descendant == *parentID {
+			return ErrCycle
+		}
+	}
+	return nil
+}
+
+// tree indexes an organization's categories by parent, with the totals of their
+// rollups, whose stock values are converted to one currency.
+type tree struct {
+	children map[uint][]Category
+	totals   map[uint]total
+	currency string
+}
+
+// total adds up the rollups of a category in all currencies. StockValue is in major
+// units of the tree's currency.
+type total struct {
+	ProductCount  int64
+	StockQuantity float64
+	StockValue    float64
+}
+
+func (s *service) buildTree(ctx context.Context, to string) (*tree, error) {
+	to, err := money.Normalize(to)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rollups, err := s.repo.Rollups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	table, err := s.rates.Table(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Roots are stored under 0, which is never a category ID.
+	t := &tree{children: map[uint][]Category{}, totals: map[uint]total{}, currency: to}
+	for _, category := range categories {
+		var parentID uint
+		if category.ParentID != nil {
+			parentID = *category.ParentID
+		}
+		t.children[parentID] = append(t.children[parentID], category)
+	}
+	for _, rollup := range rollups {
+		sum := t.totals[rollup.CategoryID]
+		sum.ProductCount += rollup.ProductCount
+		sum.StockQuantity += rollup.StockQuantity
+		if rollup.StockValueMinor != 0 {
+			value, err := table.Convert(rollup.StockValueMinor, rollup.Currency, to)
+			if err != nil {
+				return nil, err
+			}
+			sum.StockValue += value
+		}
+		t.totals[rollup.CategoryID] = sum
+	}
+	return t, nil
+}
+
+func (t *tree) nodes(parentID *uint) []CategoryNode {
+	var key uint
+	if parentID != nil {
+		key = *parentID
+	}
+
+	nodes := make([]CategoryNode, 0, len(t.children[key]))
+	for _, category := range t.children[key] {
+		nodes = append(nodes, t.node(category))
+	}
+	return nodes
+}
+
+func (t *tree) node(category Category) CategoryNode {
+	sum := t.totals[category.ID]
+	return CategoryNode{
+		CategoryResponse: toCategoryResponse(category),
+		ProductCount:     sum.ProductCount,
+		StockQuantity:    sum.StockQuantity,
+		StockValue:       money.ToMajor(float64(money.ToMinor(sum.StockValue, t.currency)), t.currency),
+		Currency:         t.currency,
+		Children:         t.nodes(&category.ID),
+	}
+}
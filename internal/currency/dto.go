@@ -0,0 +1,23 @@
+package currency
+
+// RateInput is an exchange rate: one base is worth rate quote, from validOn
+// (YYYY-MM-DD, today if empty) on.
+type RateInput struct {
+	Base    string  `json:"base" binding:"required,len=3"`
+	Quote   string  `json:"quote" binding:"required,len=3"`
+	Rate    float64 `json:"rate" binding:"required,gt=0"`
+	ValidOn string  `json:"validOn"`
+}
+
+type RateResponse struct {
+	ID      uint    `json:"id"`
+	Base    string  `json:"base"`
+	Quote   string  `json:"quote"`
+	Rate    float64 `json:"rate"`
+	ValidOn string  `json:"validOn"`
+}
+
+type ImportResponse struct {
+	Imported int            `json:"imported"`
+	Rates    []RateResponse `json:"rates"`
+}
@@ -0,0 +1,130 @@
+package currency
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// GetRates lists the exchange rates.
+// @Summary      List exchange rates
+// @Description  Lists all exchange rates, newest day first.
+// @Tags         Exchange Rates
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {array}   RateResponse
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /exchange-rates [get]
+func (h *Handler) GetRates(c *gin.Context) {
+	rates, err := h.svc.ListRates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// SetRate sets an exchange rate.
+// @Summary      Set an exchange rate
+// @Description  Sets what one base is worth in quote from a day on, e.g. {"base": "EUR", "quote": "USD", "rate": 1.08, "validOn": "2024-05-01"}. A pair's rate for the same day is replaced. The inverse pair and pairs through a third currency are derived.
+// @Tags         Exchange Rates
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        rate  body      RateInput  true  "Exchange rate"
+// @Success      200  {object}  RateResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /exchange-rates [post]
+func (h *Handler) SetRate(c *gin.Context) {
+	var input RateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := h.svc.SetRate(c.Request.Context(), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// ImportRates sets exchange rates from a CSV file.
+// @Summary      Import exchange rates
+// @Description  Sets the exchange rates of a CSV file uploaded as "file". The file starts with the header base,quote,rate,validOn; validOn is optional and defaults to today. If any line is invalid, no rate is set.
+// @Tags         Exchange Rates
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        file  formData  file  true  "CSV file"
+// @Success      200  {object}  ImportResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /exchange-rates/import [post]
+func (h *Handler) ImportRates(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A CSV file is required as \"file\""})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	result, err := h.svc.ImportRates(c.Request.Context(), file)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// DeleteRate deletes an exchange rate.
+// @Summary      Delete an exchange rate
+// @Description  Deletes an exchange rate. The pair's previous rate, if any, applies again.
+// @Tags         Exchange Rates
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Exchange rate ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /exchange-rates/{id} [delete]
+func (h *Handler) DeleteRate(c *gin.Context) {
+	if err := h.svc.DeleteRate(c.Request.Context(), c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exchange rate not found"})
+	case errors.Is(err, ErrInvalidRate), errors.Is(err, ErrInvalidCSV):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,8 @@
+package currency
+
+import "gorm.io/gorm"
+
+// Migrate creates or updates the exchange_rates table.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&ExchangeRate{})
+}
@@ -0,0 +1,23 @@
+package currency
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExchangeRate is the rate of a currency pair from a day on: one Base is worth Rate
+// Quote. It applies until a newer rate of the pair, or of the inverse pair, is valid.
+type ExchangeRate struct {
+	gorm.Model
+	// A pair has at most one rate per day.
+	OrgID   uint      `json:"-" gorm:"not null;uniqueIndex:idx_exchange_rates_org_pair_day,where:deleted_at IS NULL"`
+	Base    string    `json:"base" gorm:"not null;size:3;uniqueIndex:idx_exchange_rates_org_pair_day"`
+	Quote   string    `json:"quote" gorm:"not null;size:3;uniqueIndex:idx_exchange_rates_org_pair_day"`
+	ValidOn time.Time `json:"validOn" gorm:"type:date;not null;uniqueIndex:idx_exchange_rates_org_pair_day"`
+	Rate    float64   `json:"rate" gorm:"type:numeric(20,10);not null"`
+}
+
+func (ExchangeRate) AuditEntityType() string {
+	return "exchange_rate"
+}
@@ -0,0 +1,77 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	List(ctx context.Context) ([]ExchangeRate, error)
+	InEffect(ctx context.Context, at time.Time) ([]ExchangeRate, error)
+	FindByID(ctx context.Context, id string) (*ExchangeRate, error)
+	SaveAll(ctx context.Context, rates []ExchangeRate) error
+	Delete(ctx context.Context, rate *ExchangeRate) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) List(ctx context.Context) ([]ExchangeRate, error) {
+	var rates []ExchangeRate
+	err := r.db.WithContext(ctx).Order("valid_on DESC, base, quote").Find(&rates).Error
+	return rates, err
+}
+
+// InEffect returns the latest rate of every pair valid on the day of at.
+func (r *repository) InEffect(ctx context.Context, at time.Time) ([]ExchangeRate, error) {
+	var rates []ExchangeRate
+	err := r.db.WithContext(ctx).Select("DISTINCT ON (base, quote) *").
+		Where("valid_on <= ?", at.Format(time.DateOnly)).
+		Order("base, quote, valid_on DESC").Find(&rates).Error
+	return rates, err
+}
+
+func (r *repository) FindByID(ctx context.Context, id string) (*ExchangeRate, error) {
+	var rate ExchangeRate
+	err := r.db.WithContext(ctx).First(&rate, id).Error
+	return &rate, err
+}
+
+// SaveAll saves the rates in one transaction. A rate for a pair and day that already
+// has one replaces it.
+func (r *repository) SaveAll(ctx context.Context, rates []ExchangeRate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range rates {
+			var existing ExchangeRate
+			err := tx.Where("base = ? AND quote = ? AND valid_on = ?", rates[i].Base, rates[i].Quote, rates[i].ValidOn.Format(time.DateOnly)).
+				First(&existing).Error
+			switch {
+			case err == nil:
+				existing.Rate = rates[i].Rate
+				if err := tx.Model(&existing).Update("rate", existing.Rate).Error; err != nil {
+					return err
+				}
+				rates[i] = existing
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.Create(&rates[i]).Error; err != nil {
+					return err
+				}
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *repository) Delete(ctx context.Context, rate *ExchangeRate) error {
+	return r.db.WithContext(ctx).Delete(rate).Error
+}
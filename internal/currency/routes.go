@@ -0,0 +1,13 @@
+package currency
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, h *Handler) {
+	rateRoutes := router.Group("/exchange-rates")
+	{
+		rateRoutes.GET("", h.GetRates)
+		rateRoutes.POST("", h.SetRate)
+		rateRoutes.POST("/import", h.ImportRates)
+		rateRoutes.DELETE("/:id", h.DeleteRate)
+	}
+}
@@ -0,0 +1,177 @@
+package currency
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+)
+
+var (
+	ErrInvalidRate = errors.New("exchange rates need two different currencies, a rate above zero and a day as YYYY-MM-DD")
+	ErrInvalidCSV  = errors.New("invalid exchange rate file")
+)
+
+type Service interface {
+	ListRates(ctx context.Context) ([]RateResponse, error)
+	SetRate(ctx context.Context, input RateInput) (*RateResponse, error)
+	ImportRates(ctx context.Context, file io.Reader) (*ImportResponse, error)
+	DeleteRate(ctx context.Context, id string) error
+	Table(ctx context.Context, at time.Time) (*Table, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) ListRates(ctx context.Context) ([]RateResponse, error) {
+	rates, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toRateResponses(rates), nil
+}
+
+// SetRate sets the rate of a currency pair from a day on, replacing the pair's rate
+// for that day if it has one.
+func (s *service) SetRate(ctx context.Context, input RateInput) (*RateResponse, error) {
+	rate, err := newRate(input)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := []ExchangeRate{rate}
+	if err := s.repo.SaveAll(ctx, rates); err != nil {
+		return nil, err
+	}
+	response := toRateResponse(rates[0])
+	return &response, nil
+}
+
+// ImportRates sets the rates of a CSV file with a header and the columns base, quote,
+// rate and, optionally, validOn. Either all rates are set or, if a line is invalid,
+// none.
+func (s *service) ImportRates(ctx context.Context, file io.Reader) (*ImportResponse, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"base", "quote", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: the header has no %s column", ErrInvalidCSV, name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[strings.ToLower(name)]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rates []ExchangeRate
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+		}
+
+		value, err := strconv.ParseFloat(field(record, "rate"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: rate %q is not a number", ErrInvalidCSV, line, field(record, "rate"))
+		}
+		rate, err := newRate(RateInput{
+			Base:    field(record, "base"),
+			Quote:   field(record, "quote"),
+			Rate:    value,
+			ValidOn: field(record, "validOn"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidCSV, line, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	if len(rates) > 0 {
+		if err := s.repo.SaveAll(ctx, rates); err != nil {
+			return nil, err
+		}
+	}
+	return &ImportResponse{Imported: len(rates), Rates: toRateResponses(rates)}, nil
+}
+
+func (s *service) DeleteRate(ctx context.Context, id string) error {
+	rate, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, rate)
+}
+
+// Table returns a table of the exchange rates in effect at a time.
+func (s *service) Table(ctx context.Context, at time.Time) (*Table, error) {
+	rates, err := s.repo.InEffect(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	return NewTable(rates), nil
+}
+
+func newRate(input RateInput) (ExchangeRate, error) {
+	base, err := money.Normalize(input.Base)
+	if err != nil || input.Base == "" {
+		return ExchangeRate{}, ErrInvalidRate
+	}
+	quote, err := money.Normalize(input.Quote)
+	if err != nil || input.Quote == "" || base == quote || input.Rate <= 0 {
+		return ExchangeRate{}, ErrInvalidRate
+	}
+
+	validOn := time.Now().UTC().Truncate(24 * time.Hour)
+	if input.ValidOn != "" {
+		validOn, err = time.Parse(time.DateOnly, input.ValidOn)
+		if err != nil {
+			return ExchangeRate{}, ErrInvalidRate
+		}
+	}
+	return ExchangeRate{Base: base, Quote: quote, Rate: input.Rate, ValidOn: validOn}, nil
+}
+
+func toRateResponse(rate ExchangeRate) RateResponse {
+	return RateResponse{
+		ID:      rate.ID,
+		Base:    rate.Base,
+		Quote:   rate.Quote,
+		Rate:    rate.Rate,
+		ValidOn: rate.ValidOn.Format(time.DateOnly),
+	}
+}
+
+func toRateResponses(rates []ExchangeRate) []RateResponse {
+	responses := make([]RateResponse, 0, len(rates))
+	for _, rate := range rates {
+		responses = append(responses, toRateResponse(rate))
+	}
+	return responses
+}
@@ -0,0 +1,85 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+)
+
+var ErrNoRate = errors.New("no exchange rate")
+
+// Table converts amounts between currencies with a set of exchange rates. A pair
+// without a rate of its own converts through its inverse or, failing that, through
+// one other currency.
+type Table struct {
+	// rates[from][to] is what one from is worth in to.
+	rates map[string]map[string]float64
+}
+
+// NewTable builds a table from the rates in effect, at most one per pair. Of a pair
+// and its inverse, the rate valid from the later day wins.
+func NewTable(rates []ExchangeRate) *Table {
+	t := &Table{rates: map[string]map[string]float64{}}
+	validOn := map[[2]string]int64{}
+	for _, rate := range rates {
+		key := [2]string{rate.Base, rate.Quote}
+		if rate.Quote < rate.Base {
+			key = [2]string{rate.Quote, rate.Base}
+		}
+		if day, ok := validOn[key]; ok && day > rate.ValidOn.Unix() {
+			continue
+		}
+		validOn[key] = rate.ValidOn.Unix()
+		t.set(rate.Base, rate.Quote, rate.Rate)
+		t.set(rate.Quote, rate.Base, 1/rate.Rate)
+	}
+	return t
+}
+
+func (t *Table) set(from, to string, rate float64) {
+	if t.rates[from] == nil {
+		t.rates[from] = map[string]float64{}
+	}
+	t.rates[from][to] = rate
+}
+
+// Rate returns what one from is worth in to.
+func (t *Table) Rate(from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	if rate, ok := t.rates[from][to]; ok {
+		return rate, nil
+	}
+	// Going through the default currency is preferred, then the others in order, so a
+	// pair always converts the same way.
+	vias := make([]string, 0, len(t.rates[from]))
+	for via := range t.rates[from] {
+		vias = append(vias, via)
+	}
+	sort.Slice(vias, func(i, j int) bool {
+		if (vias[i] == money.DefaultCurrency()) != (vias[j] == money.DefaultCurrency()) {
+			return vias[i] == money.DefaultCurrency()
+		}
+		return vias[i] < vias[j]
+	})
+	for _, via := range vias {
+		if second, ok := t.rates[via][to]; ok {
+			return t.rates[from][via] * second, nil
+		}
+	}
+	return 0, fmt.Errorf("%w from %s to %s", ErrNoRate, from, to)
+}
+
+// Convert converts an amount in minor units of one currency, possibly with fractions
+// of a minor unit such as the value of a fractional quantity, into major units of
+// another currency.
+func (t *Table) Convert(minor float64, from, to string) (float64, error) {
+	rate, err := t.Rate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return money.ToMajor(minor, from) * rate, nil
+}
@@ -0,0 +1,102 @@
+package currency
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func rate(base, quote string, value float64, day int) ExchangeRate {
+	return ExchangeRate{Base: base, Quote: quote, Rate: value, ValidOn: time.Date(2025, 3, day, 0, 0, 0, 0, time.UTC)}
+}
+
+func TestTableRate(t *testing.T) {
+	tests := []struct {
+		name            string
+		defaultCurrency string
+		rates           []ExchangeRate
+		from, to        string
+		want            float64
+	}{
+		{"same currency", "EUR", nil, "USD", "USD", 1},
+		{"direct", "EUR", []ExchangeRate{rate("EUR", "USD", 1.08, 1)}, "EUR", "USD", 1.08},
+		{"inverse", "EUR", []ExchangeRate{rate("EUR", "USD", 1.25, 1)}, "USD", "EUR", 0.8},
+		{
+			"newer inverse wins", "EUR",
+			[]ExchangeRate{rate("EUR", "USD", 1.08, 1), rate("USD", "EUR", 0.8, 2)},
+			"EUR", "USD", 1.25,
+		},
+		{
+			"older inverse loses", "EUR",
+			[]ExchangeRate{rate("USD", "EUR", 0.8, 2), rate("EUR", "USD", 1.08, 1)},
+			"EUR", "USD", 1.25,
+		},
+		{
+			"through the default currency", "EUR",
+			[]ExchangeRate{rate("GBP", "EUR", 1.2, 1), rate("EUR", "USD", 1.1, 1), rate("GBP", "CHF", 1.1, 1), rate("CHF", "USD", 1.2, 1)},
+			"GBP", "USD", 1.2 * 1.1,
+		},
+		{
+			"through another default currency", "CHF",
+			[]ExchangeRate{rate("GBP", "EUR", 1.2, 1), rate("EUR", "USD", 1.1, 1), rate("GBP", "CHF", 1.1, 1), rate("CHF", "USD", 1.3, 1)},
+			"GBP", "USD", 1.1 * 1.3,
+		},
+		{
+			"through the first currency in order", "JPY",
+			[]ExchangeRate{rate("GBP", "EUR", 1.2, 1), rate("EUR", "USD", 1.1, 1), rate("GBP", "CHF", 1.1, 1), rate("CHF", "USD", 1.3, 1)},
+			"GBP", "USD", 1.1 * 1.3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DEFAULT_CURRENCY", tt.defaultCurrency)
+			got, err := NewTable(tt.rates).Rate(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Rate: %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Rate(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableRateWithoutPath(t *testing.T) {
+	// Only one other currency may be gone through.
+	table := NewTable([]ExchangeRate{rate("GBP", "EUR", 1.2, 1), rate("EUR", "CHF", 1, 1), rate("CHF", "USD", 1.1, 1)})
+	for _, pair := range [][2]string{{"GBP", "USD"}, {"EUR", "JPY"}} {
+		if _, err := table.Rate(pair[0], pair[1]); !errors.Is(err, ErrNoRate) {
+			t.Errorf("Rate(%s, %s) error = %v, want ErrNoRate", pair[0], pair[1], err)
+		}
+	}
+}
+
+func TestTableConvert(t *testing.T) {
+	table := NewTable([]ExchangeRate{rate("EUR", "USD", 1.08, 1), rate("JPY", "EUR", 0.0062, 1), rate("KWD", "EUR", 3, 1)})
+	tests := []struct {
+		minor    float64
+		from, to string
+		want     float64
+	}{
+		{1999, "EUR", "USD", 19.99 * 1.08},
+		{1999, "EUR", "EUR", 19.99},
+		{1000, "JPY", "EUR", 6.2},   // no minor unit
+		{1500, "KWD", "EUR", 4.5},   // three decimals
+		{0.5, "EUR", "USD", 0.0054}, // half a cent
+		{-250, "EUR", "USD", -2.7},
+	}
+	for _, tt := range tests {
+		got, err := table.Convert(tt.minor, tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("Convert(%v, %s, %s): %v", tt.minor, tt.from, tt.to, err)
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Convert(%v, %s, %s) = %v, want %v", tt.minor, tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	if _, err := table.Convert(100, "USD", "GBP"); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Convert without a rate: err = %v, want ErrNoRate", err)
+	}
+}
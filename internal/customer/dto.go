@@ -0,0 +1,36 @@
+package customer
+
+import "time"
+
+type CustomerInput struct {
+	Name    string `json:"name" binding:"required"`
+	Email   string `json:"email" binding:"omitempty,email"`
+	Phone   string `json:"phone"`
+	GroupID *uint  `json:"groupId"`
+}
+
+type CustomerResponse struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	GroupID   *uint     `json:"groupId"`
+	Group     string    `json:"group,omitempty"`
+}
+
+type CustomerListResponse struct {
+	Data     []CustomerResponse `json:"data"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"pageSize"`
+	Total    int64              `json:"total"`
+}
+
+type GroupInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type GroupResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
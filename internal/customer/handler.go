@@ -0,0 +1,256 @@
+package customer
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/query"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// GetCustomers lists customers a page at a time.
+// @Summary      List customers
+// @Description  Lists customers with their group. Filter and sort like the product list, e.g. groupId=3 or sort=name.
+// @Tags         Customers
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        page      query     int     false  "Page number (default 1)"
+// @Param        pageSize  query     int     false  "Page size (default 20, max 100); limit is an alias"
+// @Param        sort      query     string  false  "Comma-separated fields, prefixed with - for descending: id, name, email, phone, groupId, createdAt, updatedAt"
+// @Success      200  {object}  CustomerListResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /customers [get]
+func (h *Handler) GetCustomers(c *gin.Context) {
+	params, err := query.Parse(c.Request.URL.Query(), listFields, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	customers, err := h.svc.ListCustomers(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch customers"})
+		return
+	}
+
+	c.Header("Link", params.Links(c.Request.URL, customers.Total))
+	c.JSON(http.StatusOK, customers)
+}
+
+// GetCustomer retrieves a single customer.
+// @Summary      Get a single customer
+// @Tags         Customers
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Customer ID"
+// @Success      200  {object}  CustomerResponse
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /customers/{id} [get]
+func (h *Handler) GetCustomer(c *gin.Context) {
+	customer, err := h.svc.GetCustomer(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// CreateCustomer adds a customer.
+// @Summary      Create a customer
+// @Description  Adds a customer, optionally in a customer group.
+// @Tags         Customers
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        customer  body      CustomerInput  true  "Customer"
+// @Success      201  {object}  CustomerResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /customers [post]
+func (h *Handler) CreateCustomer(c *gin.Context) {
+	var input CustomerInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	customer, err := h.svc.CreateCustomer(c.Request.Context(), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, customer)
+}
+
+// UpdateCustomer changes a customer.
+// @Summary      Update a customer
+// @Description  Replaces a customer's details, including its group.
+// @Tags         Customers
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id        path      int            true  "Customer ID"
+// @Param        customer  body      CustomerInput  true  "Customer"
+// @Success      200  {object}  CustomerResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /customers/{id} [put]
+func (h *Handler) UpdateCustomer(c *gin.Context) {
+	var input CustomerInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	customer, err := h.svc.UpdateCustomer(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// DeleteCustomer deletes a customer.
+// @Summary      Delete a customer
+// @Tags         Customers
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Customer ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /customers/{id} [delete]
+func (h *Handler) DeleteCustomer(c *gin.Context) {
+	if err := h.svc.DeleteCustomer(c.Request.Context(), c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetGroups lists the customer groups.
+// @Summary      List customer groups
+// @Tags         Customers
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {array}   GroupResponse
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /customer-groups [get]
+func (h *Handler) GetGroups(c *gin.Context) {
+	groups, err := h.svc.ListGroups(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch customer groups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+// CreateGroup adds a customer group.
+// @Summary      Create a customer group
+// @Tags         Customers
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        group  body      GroupInput  true  "Customer group"
+// @Success      201  {object}  GroupResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /customer-groups [post]
+func (h *Handler) CreateGroup(c *gin.Context) {
+	var input GroupInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.svc.CreateGroup(c.Request.Context(), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// UpdateGroup renames a customer group.
+// @Summary      Update a customer group
+// @Tags         Customers
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id     path      int         true  "Customer group ID"
+// @Param        group  body      GroupInput  true  "Customer group"
+// @Success      200  {object}  GroupResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /customer-groups/{id} [put]
+func (h *Handler) UpdateGroup(c *gin.Context) {
+	var input GroupInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.svc.UpdateGroup(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteGroup deletes a customer group.
+// @Summary      Delete a customer group
+// @Description  Deletes a customer group that has no customers.
+// @Tags         Customers
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Customer group ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /customer-groups/{id} [delete]
+func (h *Handler) DeleteGroup(c *gin.Context) {
+	if err := h.svc.DeleteGroup(c.Request.Context(), c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+	case errors.Is(err, ErrGroupNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrUnknownGroup):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrGroupNameInUse), errors.Is(err, ErrGroupNotEmpty):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
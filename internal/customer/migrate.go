@@ -0,0 +1,8 @@
+package customer
+
+import "gorm.io/gorm"
+
+// Migrate creates or updates the customers and customer_groups tables.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Group{}, &Customer{})
+}
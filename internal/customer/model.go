@@ -0,0 +1,36 @@
+package customer
+
+import "gorm.io/gorm"
+
+// Group is a group of customers, e.g. wholesale or key accounts, that price lists can
+// be assigned to.
+type Group struct {
+	gorm.Model
+	// Name is unique among an organization's groups that haven't been deleted.
+	OrgID uint   `json:"-" gorm:"not null;uniqueIndex:idx_customer_groups_org_name,where:deleted_at IS NULL"`
+	Name  string `json:"name" gorm:"not null;uniqueIndex:idx_customer_groups_org_name"`
+}
+
+func (Group) TableName() string {
+	return "customer_groups"
+}
+
+func (Group) AuditEntityType() string {
+	return "customer_group"
+}
+
+// Customer is someone the organization sells to. A customer belongs to at most one
+// group.
+type Customer struct {
+	gorm.Model
+	OrgID   uint   `json:"-" gorm:"not null;index"`
+	Name    string `json:"name" gorm:"not null"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"`
+	GroupID *uint  `json:"groupId" gorm:"index"`
+	Group   *Group `json:"group" gorm:"foreignKey:GroupID"`
+}
+
+func (Customer) AuditEntityType() string {
+	return "customer"
+}
@@ -0,0 +1,124 @@
+package customer
+
+import (
+	"context"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// listFields are the fields customer lists can be sorted and filtered by.
+var listFields = query.Fields{
+	"id":        {Column: "customers.id", Kind: query.Number},
+	"name":      {Column: "customers.name", Kind: query.String},
+	"email":     {Column: "customers.email", Kind: query.String},
+	"phone":     {Column: "customers.phone", Kind: query.String},
+	"groupId":   {Column: "customers.group_id", Kind: query.Number},
+	"createdAt": {Column: "customers.created_at", Kind: query.Time},
+	"updatedAt": {Column: "customers.updated_at", Kind: query.Time},
+}
+
+type Repository interface {
+	List(ctx context.Context, params *query.Params) ([]Customer, int64, error)
+	FindByID(ctx context.Context, id string) (*Customer, error)
+	CountExisting(ctx context.Context, ids []uint) (int64, error)
+	Save(ctx context.Context, customer *Customer) (*Customer, error)
+	Update(ctx context.Context, customer *Customer) (*Customer, error)
+	Delete(ctx context.Context, customer *Customer) error
+	ListGroups(ctx context.Context) ([]Group, error)
+	FindGroupByID(ctx context.Context, id string) (*Group, error)
+	CountExistingGroups(ctx context.Context, ids []uint) (int64, error)
+	SaveGroup(ctx context.Context, group *Group) (*Group, error)
+	UpdateGroup(ctx context.Context, group *Group) (*Group, error)
+	DeleteGroup(ctx context.Context, group *Group) error
+	GroupHasCustomers(ctx context.Context, id uint) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) List(ctx context.Context, params *query.Params) ([]Customer, int64, error) {
+	db := r.db.WithContext(ctx).Model(&Customer{}).Scopes(params.Filter)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var customers []Customer
+	err := db.Scopes(params.Sort, params.Paginate).Preload("Group").Find(&customers).Error
+	return customers, total, err
+}
+
+func (r *repository) FindByID(ctx context.Context, id string) (*Customer, error) {
+	var customer Customer
+	err := r.db.WithContext(ctx).Preload("Group").First(&customer, id).Error
+	return &customer, err
+}
+
+// CountExisting returns how many of the IDs are customers of the organization.
+func (r *repository) CountExisting(ctx context.Context, ids []uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Customer{}).Where("id IN ?", ids).Count(&count).Error
+	return count, err
+}
+
+func (r *repository) Save(ctx context.Context, customer *Customer) (*Customer, error) {
+	err := r.db.WithContext(ctx).Omit(clause.Associations).Create(customer).Error
+	return customer, err
+}
+
+func (r *repository) Update(ctx context.Context, customer *Customer) (*Customer, error) {
+	err := r.db.WithContext(ctx).Model(customer).Omit(clause.Associations).Select("*").Updates(customer).Error
+	return customer, err
+}
+
+func (r *repository) Delete(ctx context.Context, customer *Customer) error {
+	return r.db.WithContext(ctx).Delete(customer).Error
+}
+
+func (r *repository) ListGroups(ctx context.Context) ([]Group, error) {
+	var groups []Group
+	err := r.db.WithContext(ctx).Order("name").Find(&groups).Error
+	return groups, err
+}
+
+func (r *repository) FindGroupByID(ctx context.Context, id string) (*Group, error) {
+	var group Group
+	err := r.db.WithContext(ctx).First(&group, id).Error
+	return &group, err
+}
+
+// CountExistingGroups returns how many of the IDs are customer groups of the
+// organization.
+func (r *repository) CountExistingGroups(ctx context.Context, ids []uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Group{}).Where("id IN ?", ids).Count(&count).Error
+	return count, err
+}
+
+func (r *repository) SaveGroup(ctx context.Context, group *Group) (*Group, error) {
+	err := r.db.WithContext(ctx).Create(group).Error
+	return group, err
+}
+
+func (r *repository) UpdateGroup(ctx context.Context, group *Group) (*Group, error) {
+	err := r.db.WithContext(ctx).Model(group).Select("*").Updates(group).Error
+	return group, err
+}
+
+func (r *repository) DeleteGroup(ctx context.Context, group *Group) error {
+	return r.db.WithContext(ctx).Delete(group).Error
+}
+
+func (r *repository) GroupHasCustomers(ctx context.Context, id uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Customer{}).Where("group_id = ?", id).Limit(1).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,22 @@
+package customer
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, h *Handler) {
+	customerRoutes := router.Group("/customers")
+	{
+		customerRoutes.GET("", h.GetCustomers)
+		customerRoutes.POST("", h.CreateCustomer)
+		customerRoutes.GET("/:id", h.GetCustomer)
+		customerRoutes.PUT("/:id", h.UpdateCustomer)
+		customerRoutes.DELETE("/:id", h.DeleteCustomer)
+	}
+
+	groupRoutes := router.Group("/customer-groups")
+	{
+		groupRoutes.GET("", h.GetGroups)
+		groupRoutes.POST("", h.CreateGroup)
+		groupRoutes.PUT("/:id", h.UpdateGroup)
+		groupRoutes.DELETE("/:id", h.DeleteGroup)
+	}
+}
@@ -0,0 +1,210 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/query"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownGroup   = errors.New("unknown customer group")
+	ErrGroupNotFound  = errors.New("customer group not found")
+	ErrGroupNameInUse = errors.New("another customer group already has this name")
+	ErrGroupNotEmpty  = errors.New("customer group still has customers; move them first")
+)
+
+type Service interface {
+	ListCustomers(ctx context.Context, params *query.Params) (*CustomerListResponse, error)
+	GetCustomer(ctx context.Context, id string) (*CustomerResponse, error)
+	CreateCustomer(ctx context.Context, input CustomerInput) (*CustomerResponse, error)
+	UpdateCustomer(ctx context.Context, id string, input CustomerInput) (*CustomerResponse, error)
+	DeleteCustomer(ctx context.Context, id string) error
+	ListGroups(ctx context.Context) ([]GroupResponse, error)
+	CreateGroup(ctx context.Context, input GroupInput) (*GroupResponse, error)
+	UpdateGroup(ctx context.Context, id string, input GroupInput) (*GroupResponse, error)
+	DeleteGroup(ctx context.Context, id string) error
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func toCustomerResponse(customer Customer) CustomerResponse {
+	response := CustomerResponse{
+		ID:        customer.ID,
+		CreatedAt: customer.CreatedAt,
+		Name:      customer.Name,
+		Email:     customer.Email,
+		Phone:     customer.Phone,
+		GroupID:   customer.GroupID,
+	}
+	if customer.Group != nil {
+		response.Group = customer.Group.Name
+	}
+	return response
+}
+
+func toGroupResponse(group Group) GroupResponse {
+	return GroupResponse{ID: group.ID, Name: group.Name}
+}
+
+func (s *service) ListCustomers(ctx context.Context, params *query.Params) (*CustomerListResponse, error) {
+	customers, total, err := s.repo.List(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]CustomerResponse, 0, len(customers))
+	for _, customer := range customers {
+		responses = append(responses, toCustomerResponse(customer))
+	}
+	return &CustomerListResponse{
+		Data:     responses,
+		Page:     params.Page,
+		PageSize: params.PageSize,
+		Total:    total,
+	}, nil
+}
+
+func (s *service) GetCustomer(ctx context.Context, id string) (*CustomerResponse, error) {
+	customer, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	response := toCustomerResponse(*customer)
+	return &response, nil
+}
+
+func (s *service) CreateCustomer(ctx context.Context, input CustomerInput) (*CustomerResponse, error) {
+	customer := Customer{}
+	if err := s.apply(ctx, &customer, input); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.repo.Save(ctx, &customer)
+	if err != nil {
+		return nil, err
+	}
+	response := toCustomerResponse(*saved)
+	return &response, nil
+}
+
+func (s *service) UpdateCustomer(ctx context.Context, id string, input CustomerInput) (*CustomerResponse, error) {
+	customer, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(ctx, customer, input); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.Update(ctx, customer)
+	if err != nil {
+		return nil, err
+	}
+	response := toCustomerResponse(*updated)
+	return &response, nil
+}
+
+// apply sets the customer's fields, making sure its group exists.
+func (s *service) apply(ctx context.Context, customer *Customer, input CustomerInput) error {
+	customer.Name = input.Name
+	customer.Email = input.Email
+	customer.Phone = input.Phone
+	customer.GroupID, customer.Group = nil, nil
+	if input.GroupID != nil {
+		group, err := s.repo.FindGroupByID(ctx, strconv.FormatUint(uint64(*input.GroupID), 10))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUnknownGroup
+		}
+		if err != nil {
+			return err
+		}
+		customer.GroupID, customer.Group = &group.ID, group
+	}
+	return nil
+}
+
+func (s *service) DeleteCustomer(ctx context.Context, id string) error {
+	customer, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, customer)
+}
+
+func (s *service) ListGroups(ctx context.Context) ([]GroupResponse, error) {
+	groups, err := s.repo.ListGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]GroupResponse, 0, len(groups))
+	for _, group := range groups {
+		responses = append(responses, toGroupResponse(group))
+	}
+	return responses, nil
+}
+
+func (s *service) CreateGroup(ctx context.Context, input GroupInput) (*GroupResponse, error) {
+	group := Group{Name: input.Name}
+	saved, err := s.repo.SaveGroup(ctx, &group)
+	if err != nil {
+		return nil, translateConflict(err)
+	}
+	response := toGroupResponse(*saved)
+	return &response, nil
+}
+
+func (s *service) UpdateGroup(ctx context.Context, id string, input GroupInput) (*GroupResponse, error) {
+	group, err := s.findGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	group.Name = input.Name
+	updated, err := s.repo.UpdateGroup(ctx, group)
+	if err != nil {
+		return nil, translateConflict(err)
+	}
+	response := toGroupResponse(*updated)
+	return &response, nil
+}
+
+// DeleteGroup deletes a customer group without customers.
+func (s *service) DeleteGroup(ctx context.Context, id string) error {
+	group, err := s.findGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+	if hasCustomers, err := s.repo.GroupHasCustomers(ctx, group.ID); err != nil {
+		return err
+	} else if hasCustomers {
+		return ErrGroupNotEmpty
+	}
+	return s.repo.DeleteGroup(ctx, group)
+}
+
+func (s *service) findGroup(ctx context.Context, id string) (*Group, error) {
+	group, err := s.repo.FindGroupByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrGroupNotFound
+	}
+	return group, err
+}
+
+func translateConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrGroupNameInUse
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package inventory
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type AssemblyType string
+
+const (
+	Assemble    AssemblyType = "assemble"
+	Disassemble AssemblyType = "disassemble"
+)
+
+var (
+	ErrNoBOM             = errors.New("the product has no bill of materials")
+	ErrKitAssembly       = errors.New("kits aren't assembled; their stock follows from their components")
+	ErrKitMovement       = errors.New("kits have no stock of their own; move their components instead")
+	ErrInsufficientStock = errors.New("not enough stock")
+)
+
+// Assembly builds a quantity of a product from its components, or takes it apart into
+// them again. Its inventory transactions take stock out of one side and put it into
+// the other.
+type Assembly struct {
+	gorm.Model
+	OrgID     uint         `json:"-" gorm:"not null;index"`
+	ProductID uint         `json:"productID" gorm:"not null;index"`
+	UserID    uint         `json:"userID" gorm:"not null"`
+	Type      AssemblyType `json:"type" gorm:"not null"`
+	Quantity  float64      `json:"quantity" gorm:"type:numeric(18,6);not null"`
+	Notes     string       `json:"notes,omitempty"`
+}
+
+func (Assembly) AuditEntityType() string {
+	return "assembly"
+}
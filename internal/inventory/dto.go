@@ -2,19 +2,51 @@ package inventory
 
 import "time"
 
+// CreateTransactionInput is a stock movement. The product is given by its ID or, e.g.
+// when scanned, by one of its barcodes. Movements of a product with variants must
+// give the variant. The quantity is in the product's base unit unless another unit is
+// given by its code.
 type CreateTransactionInput struct {
-	ProductID      uint            `json:"productID" binding:"required"`
+	ProductID      uint            `json:"productID" binding:"required_without=Barcode"`
+	Barcode        string          `json:"barcode,omitempty" binding:"required_without=ProductID"`
+	VariantID      *uint           `json:"variantID,omitempty"`
 	Type           TransactionType `json:"type" binding:"required,oneof=stock_in stock_out adjustment"`
-	QuantityChange int             `json:"quantityChange" binding:"required"`
+	QuantityChange float64         `json:"quantityChange" binding:"required"`
+	Unit           string          `json:"unit,omitempty"`
 	Notes          string          `json:"notes,omitempty"`
 }
 
 type TransactionResponse struct {
-	ID             uint            `json:"id"`
-	CreatedAt      time.Time       `json:"createdAt"`
-	ProductID      uint            `json:"productID"`
-	UserID         uint            `json:"userID"`
-	Type           TransactionType `json:"type"`
-	QuantityChange int             `json:"quantityChange"`
-	Notes          string          `json:"notes,omitempty"`
+	ID              uint            `json:"id"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	ProductID       uint            `json:"productID"`
+	VariantID       *uint           `json:"variantID,omitempty"`
+	UserID          uint            `json:"userID"`
+	Type            TransactionType `json:"type"`
+	QuantityChange  float64         `json:"quantityChange"`
+	Unit            string          `json:"unit,omitempty"`
+	EnteredQuantity *float64        `json:"enteredQuantity,omitempty"`
+	AssemblyID      *uint           `json:"assemblyID,omitempty"`
+	Notes           string          `json:"notes,omitempty"`
+}
+
+// AssemblyInput is a quantity of a product, in its base unit, to assemble from its
+// bill of materials or to disassemble into its components.
+type AssemblyInput struct {
+	ProductID uint    `json:"productID" binding:"required"`
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+	Notes     string  `json:"notes,omitempty"`
+}
+
+// AssemblyResponse is an assembly or disassembly with the inventory transactions it
+// posted.
+type AssemblyResponse struct {
+	ID           uint                  `json:"id"`
+	CreatedAt    time.Time             `json:"createdAt"`
+	ProductID    uint                  `json:"productID"`
+	UserID       uint                  `json:"userID"`
+	Type         AssemblyType          `json:"type"`
+	Quantity     float64               `json:"quantity"`
+	Notes        string                `json:"notes,omitempty"`
+	Transactions []TransactionResponse `json:"transactions"`
 }
@@ -1,11 +1,15 @@
 package inventory
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
+	"github.com/RezaBG/Inventory-management-api/internal/product"
 	"github.com/RezaBG/Inventory-management-api/internal/user"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type Handler struct {
@@ -18,15 +22,18 @@ func NewHandler(svc Service) *Handler {
 
 // CreateTransaction creates a new inventory transaction (e.g., stock-in, stock-out).
 // @Summary      Create an inventory transaction
-// @Description  Creates a new stock movement record. Use positive quantity for stock-in, negative for stock-out.
+// @Description  Creates a new stock movement record. Use positive quantity for stock-in, negative for stock-out. Only managers and administrators can post adjustments. Give the product by productID or, e.g. from a scanner, by one of its barcodes.
 // @Tags         Inventory
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Param        transaction body CreateTransactionInput true "Transaction Details"
 // @Success      201  {object}  TransactionResponse //
 // @Failure      400  {object}  map[string]interface{}
 // @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
 // @Router       /inventory/transactions [post]
 func (h *Handler) CreateTransaction(c *gin.Context) {
@@ -49,7 +56,15 @@ func (h *Handler) CreateTransaction(c *gin.Context) {
 		return
 	}
 
-	newTransaction, err := h.svc.CreateTransaction(input, *user)
+	newTransaction, err := h.svc.CreateTransaction(c.Request.Context(), input, *user)
+	if errors.Is(err, ErrAdjustmentNotAllowed) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, product.ErrArchived) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		// Return a 400 Bad Request for business logic errors (e.g., negative stock-in).
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -59,3 +74,71 @@ func (h *Handler) CreateTransaction(c *gin.Context) {
 	c.JSON(http.StatusCreated, newTransaction)
 
 }
+
+// Assemble builds a quantity of a product from its bill of materials.
+// @Summary      Assemble a product
+// @Description  Takes the product's components out of stock and puts the product in, in one transaction. Kit components are replaced by their own components. The components must have enough stock.
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        assembly  body      AssemblyInput  true  "Product and quantity"
+// @Success      201  {object}  AssemblyResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /inventory/assemble [post]
+func (h *Handler) Assemble(c *gin.Context) {
+	h.assemble(c, h.svc.Assemble)
+}
+
+// Disassemble takes a quantity of a product apart into its components.
+// @Summary      Disassemble a product
+// @Description  Takes the product out of stock and puts its components back in, in one transaction. The product must have enough stock.
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        assembly  body      AssemblyInput  true  "Product and quantity"
+// @Success      201  {object}  AssemblyResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /inventory/disassemble [post]
+func (h *Handler) Disassemble(c *gin.Context) {
+	h.assemble(c, h.svc.Disassemble)
+}
+
+func (h *Handler) assemble(c *gin.Context, assemble func(ctx context.Context, input AssemblyInput, currentUser user.User) (*AssemblyResponse, error)) {
+	currentUser, ok := c.Get("currentUser")
+	u, isUser := currentUser.(*user.User)
+	if !ok || !isUser || u == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	var input AssemblyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assembly, err := assemble(c.Request.Context(), input, *u)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		case errors.Is(err, ErrNoBOM), errors.Is(err, ErrKitAssembly), errors.Is(err, product.ErrFractionalQuantity):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrInsufficientStock), errors.Is(err, product.ErrArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, assembly)
+}
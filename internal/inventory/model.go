@@ -1,6 +1,7 @@
 package inventory
 
 import (
+	"github.com/RezaBG/Inventory-management-api/internal/product"
 	"github.com/RezaBG/Inventory-management-api/internal/user"
 
 	"gorm.io/gorm"
@@ -16,10 +17,33 @@ const (
 
 type InventoryTransaction struct {
 	gorm.Model
-	ProductID      uint            `json:"productID" gorm:"not null"`
-	UserID         uint            `json:"userID" gorm:"not null"`
-	User           user.User       `json:"user"`
-	Type           TransactionType `json:"type" gorm:"not null"`
-	QuantityChange int             `json:"quantityChange" gorm:"not null"`
-	Notes          string          `json:"notes,omitempty"`
+	OrgID     uint `json:"-" gorm:"not null;index"`
+	ProductID uint `json:"productID" gorm:"not null"`
+	// VariantID is set for movements of a product variant; ProductID is then the
+	// variant's product, so the product's stock includes its variants'.
+	VariantID *uint           `json:"variantID" gorm:"index"`
+	UserID    uint            `json:"userID" gorm:"not null"`
+	User      user.User       `json:"user"`
+	Type      TransactionType `json:"type" gorm:"not null"`
+	// QuantityChange is in the product's base unit. A movement entered in another unit
+	// keeps that unit and the quantity in it, e.g. 2 cases for a change of 48 bottles.
+	QuantityChange  float64  `json:"quantityChange" gorm:"type:numeric(18,6);not null"`
+	EnteredUnitID   *uint    `json:"enteredUnitID"`
+	EnteredQuantity *float64 `json:"enteredQuantity" gorm:"type:numeric(18,6)"`
+	// AssemblyID is set for the movements of an assembly or disassembly.
+	AssemblyID *uint  `json:"assemblyID" gorm:"index"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+func (InventoryTransaction) AuditEntityType() string {
+	return "inventory_transaction"
+}
+
+// AfterCreate increments the product's version in the same transaction: its stock
+// level, and therefore its ETag, has changed.
+func (t *InventoryTransaction) AfterCreate(tx *gorm.DB) error {
+	return tx.Session(&gorm.Session{NewDB: true}).
+		Model(&product.Product{}).
+		Where("id = ?", t.ProductID).
+		UpdateColumn("version", gorm.Expr("version + 1")).Error
 }
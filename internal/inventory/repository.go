@@ -1,15 +1,22 @@
 package inventory
 
 import (
+	"context"
 	"database/sql"
 
+	"github.com/RezaBG/Inventory-management-api/internal/product"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
-	Create(tx *InventoryTransaction) error
-	GetTransactionsForProduct(productID uint) ([]InventoryTransaction, error)
-	CalculateStockForProduct(productID uint) (int, error)
+	Create(ctx context.Context, tx *InventoryTransaction) error
+	GetTransactionsForProduct(ctx context.Context, productID uint) ([]InventoryTransaction, error)
+	CalculateStockForProduct(ctx context.Context, productID uint) (float64, error)
+	CalculateStockForVariants(ctx context.Context, productID uint) (map[uint]float64, error)
+	CalculateStockForProducts(ctx context.Context, productIDs []uint) (map[uint]float64, error)
+	HasTransactions(ctx context.Context, productID uint) (bool, error)
+	CreateAssembly(ctx context.Context, assembly *Assembly, transactions []InventoryTransaction) error
 }
 
 type repository struct {
@@ -20,19 +27,19 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) Create(tx *InventoryTransaction) error {
-	return r.db.Create(tx).Error
+func (r *repository) Create(ctx context.Context, tx *InventoryTransaction) error {
+	return r.db.WithContext(ctx).Create(tx).Error
 }
 
-func (r *repository) GetTransactionsForProduct(productID uint) ([]InventoryTransaction, error) {
+func (r *repository) GetTransactionsForProduct(ctx context.Context, productID uint) ([]InventoryTransaction, error) {
 	var transactions []InventoryTransaction
-	err := r.db.Where("product_id = ?", productID).Order("created_at DESC").Find(&transactions).Error
+	err := r.db.WithContext(ctx).Where("product_id = ?", productID).Order("created_at DESC").Find(&transactions).Error
 	return transactions, err
 }
 
-func (r *repository) CalculateStockForProduct(productID uint) (int, error) {
-	var total sql.NullInt64
-	err := r.db.Model(&InventoryTransaction{}).
+func (r *repository) CalculateStockForProduct(ctx context.Context, productID uint) (float64, error) {
+	var total sql.NullFloat64
+	err := r.db.WithContext(ctx).Model(&InventoryTransaction{}).
 		Where("product_id = ?", productID).
 		Select("sum(quantity_change)").
 		Row().
@@ -42,5 +49,108 @@ func (r *repository) CalculateStockForProduct(productID uint) (int, error) {
 		return 0, err
 	}
 
-	return int(total.Int64), err
+	return total.Float64, err
+}
+
+// CalculateStockForVariants returns the stock of each of the product's variants that
+// has inventory transactions.
+func (r *repository) CalculateStockForVariants(ctx context.Context, productID uint) (map[uint]float64, error) {
+	var rows []struct {
+		VariantID uint
+		Quantity  float64
+	}
+	err := r.db.WithContext(ctx).Model(&InventoryTransaction{}).
+		Where("product_id = ? AND variant_id IS NOT NULL", productID).
+		Select("variant_id, sum(quantity_change) AS quantity").
+		Group("variant_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stock := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		stock[row.VariantID] = row.Quantity
+	}
+	return stock, nil
+}
+
+// CalculateStockForProducts returns the stock of each of the products that has
+// inventory transactions.
+func (r *repository) CalculateStockForProducts(ctx context.Context, productIDs []uint) (map[uint]float64, error) {
+	return stockByProduct(r.db.WithContext(ctx), productIDs)
+}
+
+func stockByProduct(db *gorm.DB, productIDs []uint) (map[uint]float64, error) {
+	var rows []struct {
+		ProductID uint
+		Quantity  float64
+	}
+	err := db.Model(&InventoryTransaction{}).
+		Where("product_id IN ?", productIDs).
+		Select("product_id, sum(quantity_change) AS quantity").
+		Group("product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stock := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		stock[row.ProductID] = row.Quantity
+	}
+	return stock, nil
+}
+
+// HasTransactions reports whether stock of the product, or of one of its variants, has
+// ever moved.
+func (r *repository) HasTransactions(ctx context.Context, productID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&InventoryTransaction{}).Where("product_id = ?", productID).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// CreateAssembly records the assembly and its transactions in one database
+// transaction. The products the transactions take stock from are locked first, and
+// must have enough; otherwise it returns ErrInsufficientStock.
+func (r *repository) CreateAssembly(ctx context.Context, assembly *Assembly, transactions []InventoryTransaction) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		needed := make(map[uint]float64)
+		var ids []uint
+		for _, transaction := range transactions {
+			if transaction.QuantityChange < 0 {
+				if _, ok := needed[transaction.ProductID]; !ok {
+					ids = append(ids, transaction.ProductID)
+				}
+				needed[transaction.ProductID] -= transaction.QuantityChange
+			}
+		}
+
+		if len(ids) > 0 {
+			var locked []uint
+			err := tx.Model(&product.Product{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id IN ?", ids).Order("id").Pluck("id", &locked).Error
+			if err != nil {
+				return err
+			}
+			stock, err := stockByProduct(tx, ids)
+			if err != nil {
+				return err
+			}
+			for id, quantity := range needed {
+				// Allow for the rounding errors of decimal quantities.
+				if stock[id]-quantity < -1e-9 {
+					return ErrInsufficientStock
+				}
+			}
+		}
+
+		if err := tx.Create(assembly).Error; err != nil {
+			return err
+		}
+		for i := range transactions {
+			transactions[i].AssemblyID = &assembly.ID
+		}
+		return tx.Create(&transactions).Error
+	})
 }
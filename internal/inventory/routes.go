@@ -8,5 +8,7 @@ func RegisterRoutes(router *gin.RouterGroup, h *Handler) {
 	inventoryRoutes := router.Group("/inventory")
 	{
 		inventoryRoutes.POST("/transactions", h.CreateTransaction)
+		inventoryRoutes.POST("/assemble", h.Assemble)
+		inventoryRoutes.POST("/disassemble", h.Disassemble)
 	}
 }
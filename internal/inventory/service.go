@@ -1,29 +1,39 @@
 package inventory
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/RezaBG/Inventory-management-api/internal/product"
+	"github.com/RezaBG/Inventory-management-api/internal/uom"
 	"github.com/RezaBG/Inventory-management-api/internal/user"
 )
 
+var ErrAdjustmentNotAllowed = errors.New("only managers and administrators can post stock adjustments")
+
 type Service interface {
-	CreateTransaction(input CreateTransactionInput, currentUser user.User) (*TransactionResponse, error)
+	CreateTransaction(ctx context.Context, input CreateTransactionInput, currentUser user.User) (*TransactionResponse, error)
+	Assemble(ctx context.Context, input AssemblyInput, currentUser user.User) (*AssemblyResponse, error)
+	Disassemble(ctx context.Context, input AssemblyInput, currentUser user.User) (*AssemblyResponse, error)
 }
 
 type service struct {
 	inventoryRepo Repository
 	productRepo   product.Repository
+	unitRepo      uom.Repository
 }
 
-func NewService(inventoryRepo Repository, productRepo product.Repository) Service {
+func NewService(inventoryRepo Repository, productRepo product.Repository, unitRepo uom.Repository) Service {
 	return &service{
 		inventoryRepo: inventoryRepo,
 		productRepo:   productRepo,
+		unitRepo:      unitRepo,
 	}
 }
 
-func (s *service) CreateTransaction(input CreateTransactionInput, currentUser user.User) (*TransactionResponse, error) {
+func (s *service) CreateTransaction(ctx context.Context, input CreateTransactionInput, currentUser user.User) (*TransactionResponse, error) {
 	switch input.Type {
 	case StockIn:
 		if input.QuantityChange <= 0 {
@@ -34,6 +44,9 @@ func (s *service) CreateTransaction(input CreateTransactionInput, currentUser us
 			return nil, fmt.Errorf("stock-out quantity must be negative")
 		}
 	case Adjustment:
+		if !currentUser.CanManageInventory() {
+			return nil, ErrAdjustmentNotAllowed
+		}
 		if input.QuantityChange == 0 {
 			return nil, fmt.Errorf("quantity change for adjustment cannot be zero")
 		}
@@ -42,42 +55,220 @@ func (s *service) CreateTransaction(input CreateTransactionInput, currentUser us
 	}
 
 	// Check if the product exists.
-	_, err := s.productRepo.FindByID(fmt.Sprint(input.ProductID))
+	p, err := s.findProduct(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("product with ID %d not found", input.ProductID)
+		return nil, err
+	}
+	if p.Status == product.Archived {
+		return nil, product.ErrArchived
+	}
+	if p.Type == product.Kit {
+		return nil, ErrKitMovement
+	}
+	if err := checkVariant(p, input.VariantID); err != nil {
+		return nil, err
 	}
 
 	newTransaction := &InventoryTransaction{
-		ProductID:      input.ProductID,
-		UserID:         currentUser.ID,
-		Type:           input.Type,
-		QuantityChange: input.QuantityChange,
-		Notes:          input.Notes,
+		ProductID: p.ID,
+		VariantID: input.VariantID,
+		UserID:    currentUser.ID,
+		Type:      input.Type,
+		Notes:     input.Notes,
+	}
+	if err := s.convert(ctx, p, input, newTransaction); err != nil {
+		return nil, err
 	}
 
-	err = s.inventoryRepo.Create(newTransaction)
+	err = s.inventoryRepo.Create(ctx, newTransaction)
 	if err != nil {
 		return nil, fmt.Errorf("could not create transaction: %w", err)
 	}
 
 	// TODO: Business Rule 3: Check if a stock-out would result in negative inventory.
 
-	// All checks passed, create the transaction.
-	response := &TransactionResponse{
-		ID:             newTransaction.ID,
-		CreatedAt:      newTransaction.CreatedAt,
-		ProductID:      newTransaction.ProductID,
-		UserID:         newTransaction.UserID,
-		Type:           newTransaction.Type,
-		QuantityChange: newTransaction.QuantityChange,
-		Notes:          newTransaction.Notes,
+	response := toTransactionResponse(*newTransaction)
+	if newTransaction.EnteredUnitID != nil {
+		response.Unit = input.Unit
+	}
+
+	return &response, nil
+}
+
+// Assemble builds the quantity of a product from its components: it takes the
+// components' stock out and puts the product's in, all at once. Kit components, which
+// have no stock of their own, are replaced by their components.
+func (s *service) Assemble(ctx context.Context, input AssemblyInput, currentUser user.User) (*AssemblyResponse, error) {
+	return s.assemble(ctx, input, currentUser, Assemble)
+}
+
+// Disassemble takes the quantity of a product apart into its components: the reverse
+// of Assemble.
+func (s *service) Disassemble(ctx context.Context, input AssemblyInput, currentUser user.User) (*AssemblyResponse, error) {
+	return s.assemble(ctx, input, currentUser, Disassemble)
+}
+
+func (s *service) assemble(ctx context.Context, input AssemblyInput, currentUser user.User, assemblyType AssemblyType) (*AssemblyResponse, error) {
+	p, err := s.productRepo.FindByID(ctx, fmt.Sprint(input.ProductID))
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == product.Archived {
+		return nil, product.ErrArchived
+	}
+	if p.Type == product.Kit {
+		return nil, ErrKitAssembly
+	}
+	quantity, err := p.ToBase(input.Quantity, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	edges, err := s.productRepo.BOMEdges(ctx, []uint{p.ID})
+	if err != nil {
+		return nil, err
+	}
+	requirements := product.Explode(edges, p.ID, quantity, product.ThroughKits)
+	if len(requirements) == 0 {
+		return nil, ErrNoBOM
+	}
+	componentIDs := make([]uint, 0, len(requirements))
+	for id := range requirements {
+		componentIDs = append(componentIDs, id)
+	}
+	sort.Slice(componentIDs, func(i, j int) bool { return componentIDs[i] < componentIDs[j] })
+
+	// Components go out and the product comes in, or the other way around.
+	sign := 1.0
+	if assemblyType == Disassemble {
+		sign = -1
+	}
+	transactions := make([]InventoryTransaction, 0, len(componentIDs)+1)
+	for _, id := range componentIDs {
+		transactions = append(transactions, movement(id, -sign*requirements[id], currentUser.ID, input.Notes))
+	}
+	transactions = append(transactions, movement(p.ID, sign*quantity, currentUser.ID, input.Notes))
+
+	assembly := &Assembly{
+		ProductID: p.ID,
+		UserID:    currentUser.ID,
+		Type:      assemblyType,
+		Quantity:  quantity,
+		Notes:     input.Notes,
+	}
+	if err := s.inventoryRepo.CreateAssembly(ctx, assembly, transactions); err != nil {
+		return nil, err
+	}
+
+	responses := make([]TransactionResponse, 0, len(transactions))
+	for _, transaction := range transactions {
+		responses = append(responses, toTransactionResponse(transaction))
+	}
+	return &AssemblyResponse{
+		ID:           assembly.ID,
+		CreatedAt:    assembly.CreatedAt,
+		ProductID:    assembly.ProductID,
+		UserID:       assembly.UserID,
+		Type:         assembly.Type,
+		Quantity:     assembly.Quantity,
+		Notes:        assembly.Notes,
+		Transactions: responses,
+	}, nil
+}
+
+// movement is a stock-in of a positive quantity or a stock-out of a negative one.
+func movement(productID uint, quantity float64, userID uint, notes string) InventoryTransaction {
+	transactionType := StockIn
+	if quantity < 0 {
+		transactionType = StockOut
+	}
+	return InventoryTransaction{
+		ProductID:      productID,
+		UserID:         userID,
+		Type:           transactionType,
+		QuantityChange: quantity,
+		Notes:          notes,
+	}
+}
+
+func toTransactionResponse(transaction InventoryTransaction) TransactionResponse {
+	return TransactionResponse{
+		ID:              transaction.ID,
+		CreatedAt:       transaction.CreatedAt,
+		ProductID:       transaction.ProductID,
+		VariantID:       transaction.VariantID,
+		UserID:          transaction.UserID,
+		Type:            transaction.Type,
+		QuantityChange:  transaction.QuantityChange,
+		EnteredQuantity: transaction.EnteredQuantity,
+		AssemblyID:      transaction.AssemblyID,
+		Notes:           transaction.Notes,
+	}
+}
+
+// findProduct finds the product of a transaction by its ID or, if none is given, by
+// its barcode.
+func (s *service) findProduct(ctx context.Context, input CreateTransactionInput) (*product.Product, error) {
+	if input.ProductID != 0 {
+		p, err := s.productRepo.FindByID(ctx, fmt.Sprint(input.ProductID))
+		if err != nil {
+			return nil, fmt.Errorf("product with ID %d not found", input.ProductID)
+		}
+		return p, nil
+	}
+
+	gtin, err := product.NormalizeGTIN(input.Barcode)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.productRepo.FindByGTIN(ctx, gtin)
+	if err != nil {
+		return nil, fmt.Errorf("product with barcode %s not found", input.Barcode)
+	}
+	return p, nil
+}
+
+// convert sets the transaction's quantity change to the input's quantity in the
+// product's base unit. If the input gives the quantity in another unit, the transaction
+// also keeps that unit and quantity.
+func (s *service) convert(ctx context.Context, p *product.Product, input CreateTransactionInput, transaction *InventoryTransaction) error {
+	var unit *uom.Unit
+	if input.Unit != "" {
+		var err error
+		unit, err = s.unitRepo.FindByCode(ctx, input.Unit)
+		if err != nil {
+			return fmt.Errorf("unit %s not found", input.Unit)
+		}
 	}
 
-	// Call the repository to save the transaction.
-	err = s.inventoryRepo.Create(newTransaction)
+	quantity, err := p.ToBase(input.QuantityChange, unit)
 	if err != nil {
-		return nil, fmt.Errorf("could not save transaction: %w", err)
+		return err
 	}
 
-	return response, nil
+	transaction.QuantityChange = quantity
+	if unit != nil && (p.BaseUnitID == nil || unit.ID != *p.BaseUnitID) {
+		entered := input.QuantityChange
+		transaction.EnteredUnitID = &unit.ID
+		transaction.EnteredQuantity = &entered
+	}
+	return nil
+}
+
+// checkVariant makes sure that a movement of a product with variants gives one of
+// them, and that a movement of a product without variants doesn't give any.
+func checkVariant(p *product.Product, variantID *uint) error {
+	if variantID == nil {
+		if len(p.Variants) > 0 {
+			return product.ErrVariantRequired
+		}
+		return nil
+	}
+
+	for _, variant := range p.Variants {
+		if variant.ID == *variantID {
+			return nil
+		}
+	}
+	return fmt.Errorf("product %d has no variant %d", p.ID, *variantID)
 }
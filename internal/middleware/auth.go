@@ -3,98 +3,196 @@ package middleware
 import (
 	"errors"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 
+	"github.com/RezaBG/Inventory-management-api/internal/audit"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/token"
 	"github.com/RezaBG/Inventory-management-api/internal/user"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// AuthMiddleware authenticates the request with either a Bearer JWT or an X-API-Key
+// header and stores the user in the context. API keys and OAuth client tokens are
+// additionally limited to the scopes they were granted.
 func AuthMiddleware(userSvc user.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHandler := c.GetHeader("Authorization")
-		if authHandler == "" {
+		var (
+			principal *user.Principal
+			ok        bool
+		)
+
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			principal, ok = authenticateAPIKey(c, userSvc, apiKey)
+		} else {
+			principal, ok = authenticateBearer(c, userSvc)
+		}
+		if !ok {
+			return
+		}
+		foundUser, scopes := principal.User, principal.Scopes
+
+		// Checked on every request so deactivation takes effect immediately.
+		if !foundUser.IsActive() {
 			c.AbortWithStatusJSON(
-				http.StatusUnauthorized,
-				gin.H{"error": "Authorization header is required"},
+				http.StatusForbidden,
+				gin.H{"error": "Account has been deactivated"},
 			)
 			return
 		}
 
-		// The header should be in the format "Bearer <token>"
-		parts := strings.Split(authHandler, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		// An administrator may require a new password; until it is set, access tokens
+		// issued before are no good either.
+		if foundUser.PasswordResetRequired {
 			c.AbortWithStatusJSON(
-				http.StatusUnauthorized,
-				gin.H{"error": "Invalid authorization format"},
+				http.StatusForbidden,
+				gin.H{"error": "A password reset is required"},
 			)
 			return
 		}
 
-		tokenString := parts[1]
-		claims := &jwt.RegisteredClaims{}
-
-		token, err := jwt.ParseWithClaims(
-			tokenString,
-			claims,
-			func(token *jwt.Token) (interface{}, error) {
-				// We use HMAC, so we need to check the signing method
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, errors.New("unexpected signing method")
-				}
-				return []byte(os.Getenv("JWT_SECRET")), nil
-			})
-
-		// This is where we habdle the token validation errors you asked about
-		if err != nil {
-			if errors.Is(err, jwt.ErrTokenExpired) {
-				c.AbortWithStatusJSON(
-					http.StatusUnauthorized,
-					gin.H{"error": "Token has expired"},
-				)
-			} else {
+		if !foundUser.IsEmailVerified() {
+			c.AbortWithStatusJSON(
+				http.StatusForbidden,
+				gin.H{"error": "Email address has not been verified"},
+			)
+			return
+		}
+
+		// Scoped credentials may only reach the resources they were granted.
+		// Interactive sessions carry no scopes and are not restricted here.
+		if scopes != nil {
+			required := requiredScope(c)
+			if !user.HasScope(scopes, required) {
 				c.AbortWithStatusJSON(
-					http.StatusUnauthorized,
-					gin.H{"error": "Invalid token"},
+					http.StatusForbidden,
+					gin.H{"error": "Insufficient scope", "requiredScope": required},
 				)
+				return
 			}
-			return
 		}
 
-		if !token.Valid {
+		// Set the full user object in the context
+		c.Set("currentUser", foundUser)
+		c.Set("scopes", scopes)
+		c.Set("orgID", principal.OrganizationID)
+
+		// Changes made by this request are attributed to the user in the audit log.
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), audit.Actor{
+			UserID:    foundUser.ID,
+			RequestID: c.GetString("requestID"),
+			IPAddress: c.ClientIP(),
+		}))
+
+		// Call the next handler in the chain
+		c.Next()
+	}
+}
+
+func authenticateAPIKey(c *gin.Context, userSvc user.Service, apiKey string) (*user.Principal, bool) {
+	principal, err := userSvc.AuthenticateAPIKey(apiKey)
+	if err != nil {
+		c.AbortWithStatusJSON(
+			http.StatusUnauthorized,
+			gin.H{"error": "Invalid API key"},
+		)
+		return nil, false
+	}
+
+	if principal.Scopes == nil {
+		principal.Scopes = []string{}
+	}
+	return principal, true
+}
+
+func authenticateBearer(c *gin.Context, userSvc user.Service) (*user.Principal, bool) {
+	authHandler := c.GetHeader("Authorization")
+	if authHandler == "" {
+		c.AbortWithStatusJSON(
+			http.StatusUnauthorized,
+			gin.H{"error": "Authorization header is required"},
+		)
+		return nil, false
+	}
+
+	// The header should be in the format "Bearer <token>"
+	parts := strings.Split(authHandler, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.AbortWithStatusJSON(
+			http.StatusUnauthorized,
+			gin.H{"error": "Invalid authorization format"},
+		)
+		return nil, false
+	}
+
+	claims, err := token.Parse(parts[1])
+
+	// This is where we habdle the token validation errors you asked about
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
 			c.AbortWithStatusJSON(
 				http.StatusUnauthorized,
-				gin.H{"error": "Token is not valid"},
+				gin.H{"error": "Token has expired"},
 			)
-			return
-		}
-
-		// Token is valid, let's get the user ID from "subject" claim
-		userID, err := strconv.Atoi(claims.Subject)
-		if err != nil {
+		} else {
 			c.AbortWithStatusJSON(
 				http.StatusUnauthorized,
-				gin.H{"error": "Invalid user ID in token"},
+				gin.H{"error": "Invalid token"},
 			)
-			return
 		}
+		return nil, false
+	}
 
-		foundUser, err := userSvc.FindByID(uint(userID))
+	// Token is valid, let's get the user ID from "subject" claim
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		c.AbortWithStatusJSON(
+			http.StatusUnauthorized,
+			gin.H{"error": "Invalid user ID in token"},
+		)
+		return nil, false
+	}
 
-		if err != nil {
-			c.AbortWithStatusJSON(
-				http.StatusUnauthorized,
-				gin.H{"error": "User not found"},
-			)
-			return
+	foundUser, err := userSvc.FindByID(uint(userID))
+
+	if err != nil {
+		c.AbortWithStatusJSON(
+			http.StatusUnauthorized,
+			gin.H{"error": "User not found"},
+		)
+		return nil, false
+	}
+
+	principal := &user.Principal{User: foundUser, OrganizationID: claims.Org}
+
+	// Tokens issued to OAuth clients are limited to their granted scopes.
+	if claims.ClientID != "" {
+		principal.Scopes = user.ParseScopes(claims.Scope)
+		if principal.Scopes == nil {
+			principal.Scopes = []string{}
 		}
+	}
 
-		// Set the full user object in the context
-		c.Set("currentUser", foundUser)
+	return principal, true
+}
 
-		// Call the next handler in the chain
-		c.Next()
+// requiredScope derives the scope for the matched route from its first path segment,
+// e.g. GET /products/:id needs "products:read" and POST /inventory/transactions
+// needs "inventory:write". Routes outside the scoped resources need a scope that is
+// never granted, so machine credentials can't reach them. Attachments nested under
+// their owner, e.g. POST /products/:id/attachments, need the attachments scopes like
+// the /attachments routes.
+func requiredScope(c *gin.Context) string {
+	resource, rest, _ := strings.Cut(strings.TrimPrefix(c.FullPath(), "/"), "/")
+	if strings.HasSuffix(rest, "/attachments") {
+		resource = "attachments"
+	}
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return resource + ":read"
+	default:
+		return resource + ":write"
 	}
 }
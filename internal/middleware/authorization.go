@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/RezaBG/Inventory-management-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole only lets users holding at least one of the given roles through.
+// It must run after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currentUser, ok := userFromContext(c)
+		if !ok {
+			return
+		}
+
+		if !currentUser.HasRole(roles...) {
+			c.AbortWithStatusJSON(
+				http.StatusForbidden,
+				gin.H{"error": "You do not have permission to perform this action"},
+			)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireMFAEnrollment blocks users whose role requires two-factor authentication
+// until they have enabled it. It must run after AuthMiddleware.
+func RequireMFAEnrollment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currentUser, ok := userFromContext(c)
+		if !ok {
+			return
+		}
+
+		if currentUser.RequiresMFA() && !currentUser.IsTOTPEnabled() {
+			c.AbortWithStatusJSON(
+				http.StatusForbidden,
+				gin.H{"error": "Two-factor authentication must be enabled for your role. Enrol at /mfa/totp/enroll"},
+			)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func userFromContext(c *gin.Context) (*user.User, bool) {
+	value, exists := c.Get("currentUser")
+	if !exists {
+		c.AbortWithStatusJSON(
+			http.StatusUnauthorized,
+			gin.H{"error": "User not found in context"},
+		)
+		return nil, false
+	}
+
+	currentUser, ok := value.(*user.User)
+	if !ok || currentUser == nil {
+		c.AbortWithStatusJSON(
+			http.StatusInternalServerError,
+			gin.H{"error": "Invalid user context"},
+		)
+		return nil, false
+	}
+
+	return currentUser, true
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// requestIDPattern limits client-supplied request IDs to something safe to log.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestID tags every request with an ID, taken from the X-Request-ID header when the
+// client sends a valid one. The ID is echoed in the response and recorded in the
+// audit log.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Set("requestID", requestID)
+		c.Header(requestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	// crypto/rand.Read never returns an error on supported platforms.
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
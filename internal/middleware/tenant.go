@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/tenant"
+	"github.com/RezaBG/Inventory-management-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireOrganization scopes the request's database access to the active organization
+// carried by the credential. Membership is checked on every request, so removing a
+// user from an organization takes effect immediately. It must run after AuthMiddleware.
+func RequireOrganization(orgs user.OrganizationDirectory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currentUser, ok := userFromContext(c)
+		if !ok {
+			return
+		}
+
+		orgID := c.GetUint("orgID")
+		if orgID == 0 {
+			c.AbortWithStatusJSON(
+				http.StatusForbidden,
+				gin.H{"error": "You do not belong to any organization"},
+			)
+			return
+		}
+
+		isMember, err := orgs.IsMember(orgID, currentUser.ID)
+		if err != nil {
+			c.AbortWithStatusJSON(
+				http.StatusInternalServerError,
+				gin.H{"error": "Failed to check organization membership"},
+			)
+			return
+		}
+		if !isMember {
+			c.AbortWithStatusJSON(
+				http.StatusForbidden,
+				gin.H{"error": "You are no longer a member of this organization"},
+			)
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithOrganization(c.Request.Context(), orgID))
+		c.Next()
+	}
+}
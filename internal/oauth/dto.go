@@ -0,0 +1,54 @@
+package oauth
+
+import "time"
+
+// TokenRequest is the form body of POST /oauth/token (RFC 6749 section 4.4.2).
+// Client credentials may be sent here or, preferably, with HTTP Basic authentication.
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// TokenResponse is the successful response of POST /oauth/token (RFC 6749 section 5.1).
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// ErrorResponse is the error response of POST /oauth/token (RFC 6749 section 5.2).
+type ErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type CreateClientInput struct {
+	Name string `json:"name" binding:"required"`
+	// ServiceAccountID is the service account the client's tokens act as.
+	ServiceAccountID     uint     `json:"serviceAccountId" binding:"required"`
+	Scopes               []string `json:"scopes" binding:"required,min=1"`
+	TokenLifetimeSeconds int      `json:"tokenLifetimeSeconds" binding:"omitempty,min=60,max=86400"`
+	// OrganizationID must be one of the service account's organizations.
+	OrganizationID *uint `json:"organizationId"`
+}
+
+type ClientResponse struct {
+	ID                   uint       `json:"id"`
+	CreatedAt            time.Time  `json:"createdAt"`
+	ClientID             string     `json:"clientId"`
+	Name                 string     `json:"name"`
+	ServiceAccountID     uint       `json:"serviceAccountId"`
+	Scopes               []string   `json:"scopes"`
+	TokenLifetimeSeconds int        `json:"tokenLifetimeSeconds"`
+	OrganizationID       *uint      `json:"organizationId,omitempty"`
+	RevokedAt            *time.Time `json:"revokedAt,omitempty"`
+}
+
+// CreatedClientResponse includes the client secret, which is only ever shown once.
+type CreatedClientResponse struct {
+	ClientResponse
+	ClientSecret string `json:"clientSecret"`
+}
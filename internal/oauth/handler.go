@@ -0,0 +1,165 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// Token issues access tokens to registered OAuth clients.
+// @Summary      OAuth2 token endpoint
+// @Description  Implements the client_credentials grant (RFC 6749 section 4.4). Authenticate the client with HTTP Basic (preferred) or client_id/client_secret form fields. The returned access token is used as a Bearer token and is limited to the granted scopes.
+// @Tags         OAuth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        grant_type     formData  string  true   "Must be client_credentials"
+// @Param        scope          formData  string  false  "Space-separated subset of the client's scopes"
+// @Param        client_id      formData  string  false  "Client ID, if not using HTTP Basic"
+// @Param        client_secret  formData  string  false  "Client secret, if not using HTTP Basic"
+// @Success      200  {object}  TokenResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Router       /oauth/token [post]
+func (h *Handler) Token(c *gin.Context) {
+	// Token responses must never be cached (RFC 6749 section 5.1).
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var input TokenRequest
+	if err := c.ShouldBind(&input); err != nil {
+		respondTokenError(c, &Error{ErrCodeInvalidRequest, err.Error()}, false)
+		return
+	}
+
+	// HTTP Basic credentials are form-encoded before being base64-encoded (section 2.3.1).
+	username, password, usedBasic := c.Request.BasicAuth()
+	if usedBasic {
+		if input.ClientID != "" || input.ClientSecret != "" {
+			respondTokenError(c, &Error{ErrCodeInvalidRequest, "use only one client authentication method"}, false)
+			return
+		}
+		clientID, errID := url.QueryUnescape(username)
+		clientSecret, errSecret := url.QueryUnescape(password)
+		if errID != nil || errSecret != nil {
+			respondTokenError(c, &Error{ErrCodeInvalidClient, "malformed client credentials"}, true)
+			return
+		}
+		input.ClientID, input.ClientSecret = clientID, clientSecret
+	}
+
+	response, err := h.svc.IssueToken(input)
+	if err != nil {
+		var oauthErr *Error
+		if errors.As(err, &oauthErr) {
+			respondTokenError(c, oauthErr, usedBasic)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateClient registers an OAuth client.
+// @Summary      Register an OAuth client
+// @Description  Registers a client_credentials client acting as a service account. The client secret is only returned in this response.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        client  body      CreateClientInput  true  "Client Details"
+// @Success      201  {object}  CreatedClientResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/oauth/clients [post]
+func (h *Handler) CreateClient(c *gin.Context) {
+	var input CreateClientInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client, err := h.svc.CreateClient(input)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Service account not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, client)
+}
+
+// GetAllClients lists the registered OAuth clients.
+// @Summary      List OAuth clients
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   ClientResponse
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/oauth/clients [get]
+func (h *Handler) GetAllClients(c *gin.Context) {
+	clients, err := h.svc.GetAllClients()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch OAuth clients"})
+		return
+	}
+
+	c.JSON(http.StatusOK, clients)
+}
+
+// RevokeClient revokes an OAuth client.
+// @Summary      Revoke an OAuth client
+// @Description  The client can no longer obtain tokens. Tokens already issued remain valid until they expire.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Client ID (numeric)"
+// @Success      204  "No Content"
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/oauth/clients/{id} [delete]
+func (h *Handler) RevokeClient(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid client ID"})
+		return
+	}
+
+	if err := h.svc.RevokeClient(uint(id)); err != nil {
+		if errors.Is(err, ErrClientNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke OAuth client"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondTokenError(c *gin.Context, err *Error, usedBasic bool) {
+	status := http.StatusBadRequest
+	if err.Code == ErrCodeInvalidClient {
+		status = http.StatusUnauthorized
+		if usedBasic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+	}
+	c.JSON(status, ErrorResponse{Error: err.Code, ErrorDescription: err.Description})
+}
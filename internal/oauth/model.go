@@ -0,0 +1,36 @@
+package oauth
+
+import (
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/user"
+	"gorm.io/gorm"
+)
+
+// Client is a registered OAuth2 client that may use the client_credentials grant.
+// Tokens issued to the client act as its service account, limited to the client's scopes.
+// The secret is only stored as a SHA-256 hash.
+type Client struct {
+	gorm.Model
+	ClientID   string `gorm:"uniqueIndex;not null"`
+	SecretHash string `json:"-" gorm:"not null"`
+	Name       string `gorm:"not null"`
+	UserID     uint   `gorm:"not null;index"`
+	User       user.User
+	// Scopes is a space-separated list, see user.KnownScopes.
+	Scopes string `gorm:"not null"`
+	// OrganizationID is the organization the client acts in; nil means the service
+	// account's default organization.
+	OrganizationID *uint
+	// TokenLifetimeSeconds overrides OAUTH_TOKEN_LIFETIME_SECONDS when non-zero.
+	TokenLifetimeSeconds int
+	RevokedAt            *time.Time
+}
+
+func (Client) TableName() string {
+	return "oauth_clients"
+}
+
+func (Client) AuditEntityType() string {
+	return "oauth_client"
+}
@@ -0,0 +1,51 @@
+package oauth
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(client *Client) error
+	FindByClientID(clientID string) (*Client, error)
+	FindByID(id uint) (*Client, error)
+	FindAll() ([]Client, error)
+	Revoke(id uint) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(client *Client) error {
+	return r.db.Create(client).Error
+}
+
+func (r *repository) FindByClientID(clientID string) (*Client, error) {
+	var client Client
+	err := r.db.Where("client_id = ?", clientID).First(&client).Error
+	return &client, err
+}
+
+func (r *repository) FindByID(id uint) (*Client, error) {
+	var client Client
+	err := r.db.First(&client, id).Error
+	return &client, err
+}
+
+func (r *repository) FindAll() ([]Client, error) {
+	var clients []Client
+	err := r.db.Order("id").Find(&clients).Error
+	return clients, err
+}
+
+func (r *repository) Revoke(id uint) error {
+	return r.db.Model(&Client{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
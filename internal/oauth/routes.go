@@ -0,0 +1,18 @@
+package oauth
+
+import "github.com/gin-gonic/gin"
+
+func RegisterTokenRoutes(router *gin.Engine, h *Handler) {
+	router.POST("/oauth/token", h.Token)
+}
+
+// RegisterAdminRoutes registers OAuth client management. The router group is expected
+// to be restricted to administrators.
+func RegisterAdminRoutes(router *gin.RouterGroup, h *Handler) {
+	clientRoutes := router.Group("/admin/oauth/clients")
+	{
+		clientRoutes.POST("", h.CreateClient)
+		clientRoutes.GET("", h.GetAllClients)
+		clientRoutes.DELETE("/:id", h.RevokeClient)
+	}
+}
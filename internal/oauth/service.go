@@ -0,0 +1,249 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/token"
+	"github.com/RezaBG/Inventory-management-api/internal/user"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	GrantTypeClientCredentials = "client_credentials"
+
+	defaultTokenLifetimeSeconds = 3600
+)
+
+// Error codes from RFC 6749 section 5.2.
+const (
+	ErrCodeInvalidRequest       = "invalid_request"
+	ErrCodeInvalidClient        = "invalid_client"
+	ErrCodeUnsupportedGrantType = "unsupported_grant_type"
+	ErrCodeInvalidScope         = "invalid_scope"
+)
+
+var (
+	ErrClientNotFound    = errors.New("OAuth client not found")
+	ErrNotServiceAccount = errors.New("OAuth clients must act as a service account")
+)
+
+// Error is an error returned by the token endpoint, reported to the client as-is.
+type Error struct {
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+type Service interface {
+	IssueToken(input TokenRequest) (*TokenResponse, error)
+	CreateClient(input CreateClientInput) (*CreatedClientResponse, error)
+	GetAllClients() ([]ClientResponse, error)
+	RevokeClient(id uint) error
+}
+
+type service struct {
+	repo     Repository
+	userRepo user.Repository
+	orgs     user.OrganizationDirectory
+}
+
+func NewService(repo Repository, userRepo user.Repository, orgs user.OrganizationDirectory) Service {
+	return &service{repo: repo, userRepo: userRepo, orgs: orgs}
+}
+
+// IssueToken implements the client_credentials grant. The access token is a regular
+// API token for the client's service account, carrying the granted scopes.
+func (s *service) IssueToken(input TokenRequest) (*TokenResponse, error) {
+	if input.GrantType == "" {
+		return nil, &Error{ErrCodeInvalidRequest, "grant_type is required"}
+	}
+	if input.GrantType != GrantTypeClientCredentials {
+		return nil, &Error{ErrCodeUnsupportedGrantType, "only the client_credentials grant is supported"}
+	}
+	if input.ClientID == "" || input.ClientSecret == "" {
+		return nil, &Error{ErrCodeInvalidClient, "client authentication failed"}
+	}
+
+	client, err := s.repo.FindByClientID(input.ClientID)
+	if err != nil || client.RevokedAt != nil {
+		return nil, &Error{ErrCodeInvalidClient, "client authentication failed"}
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(input.ClientSecret)), []byte(client.SecretHash)) != 1 {
+		return nil, &Error{ErrCodeInvalidClient, "client authentication failed"}
+	}
+
+	account, err := s.userRepo.FindByID(client.UserID)
+	if err != nil || !account.IsActive() {
+		return nil, &Error{ErrCodeInvalidClient, "client authentication failed"}
+	}
+
+	// Without a scope parameter the client gets everything it is registered for.
+	allowed := user.ParseScopes(client.Scopes)
+	granted := allowed
+	if input.Scope != "" {
+		granted = user.ParseScopes(input.Scope)
+		for _, scope := range granted {
+			if !user.HasScope(allowed, scope) {
+				return nil, &Error{ErrCodeInvalidScope, fmt.Sprintf("scope %q is not allowed for this client", scope)}
+			}
+		}
+	}
+
+	lifetime := client.TokenLifetimeSeconds
+	if lifetime == 0 {
+		lifetime = defaultTokenLifetime()
+	}
+
+	var orgID uint
+	if client.OrganizationID != nil {
+		orgID = *client.OrganizationID
+	} else if orgID, err = s.orgs.DefaultOrganizationID(account.ID); err != nil {
+		return nil, fmt.Errorf("could not load organization: %w", err)
+	}
+
+	scope := user.FormatScopes(granted)
+	accessToken, err := token.Sign(token.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(account.ID), 10),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(lifetime) * time.Second)),
+		},
+		Scope:    scope,
+		ClientID: client.ClientID,
+		Org:      orgID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not sign access token: %w", err)
+	}
+
+	return &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   lifetime,
+		Scope:       scope,
+	}, nil
+}
+
+// CreateClient registers a client for a service account. The secret is only returned here.
+func (s *service) CreateClient(input CreateClientInput) (*CreatedClientResponse, error) {
+	if err := user.ValidateScopes(input.Scopes); err != nil {
+		return nil, err
+	}
+
+	account, err := s.userRepo.FindByID(input.ServiceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if !account.IsServiceAccount {
+		return nil, ErrNotServiceAccount
+	}
+	if input.OrganizationID != nil {
+		isMember, err := s.orgs.IsMember(*input.OrganizationID, account.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !isMember {
+			return nil, user.ErrNotOrganizationMember
+		}
+	}
+
+	clientID, err := generateSecureRandomToken(16)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate client ID: %w", err)
+	}
+	secret, err := generateSecureRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate client secret: %w", err)
+	}
+
+	client := &Client{
+		ClientID:             clientID,
+		SecretHash:           hashSecret(secret),
+		Name:                 input.Name,
+		UserID:               account.ID,
+		Scopes:               user.FormatScopes(input.Scopes),
+		TokenLifetimeSeconds: input.TokenLifetimeSeconds,
+		OrganizationID:       input.OrganizationID,
+	}
+	if err := s.repo.Create(client); err != nil {
+		return nil, fmt.Errorf("could not save OAuth client: %w", err)
+	}
+
+	return &CreatedClientResponse{
+		ClientResponse: toClientResponse(*client),
+		ClientSecret:   secret,
+	}, nil
+}
+
+func (s *service) GetAllClients() ([]ClientResponse, error) {
+	clients, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]ClientResponse, 0, len(clients))
+	for _, client := range clients {
+		responses = append(responses, toClientResponse(client))
+	}
+	return responses, nil
+}
+
+// RevokeClient stops the client from obtaining new tokens. Tokens already issued stay
+// valid until they expire, so token lifetimes should be kept short.
+func (s *service) RevokeClient(id uint) error {
+	client, err := s.repo.FindByID(id)
+	if err != nil {
+		return ErrClientNotFound
+	}
+	return s.repo.Revoke(client.ID)
+}
+
+func toClientResponse(client Client) ClientResponse {
+	lifetime := client.TokenLifetimeSeconds
+	if lifetime == 0 {
+		lifetime = defaultTokenLifetime()
+	}
+
+	return ClientResponse{
+		ID:                   client.ID,
+		CreatedAt:            client.CreatedAt,
+		ClientID:             client.ClientID,
+		Name:                 client.Name,
+		ServiceAccountID:     client.UserID,
+		Scopes:               user.ParseScopes(client.Scopes),
+		TokenLifetimeSeconds: lifetime,
+		OrganizationID:       client.OrganizationID,
+		RevokedAt:            client.RevokedAt,
+	}
+}
+
+func defaultTokenLifetime() int {
+	lifetime, _ := strconv.Atoi(os.Getenv("OAUTH_TOKEN_LIFETIME_SECONDS"))
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetimeSeconds
+	}
+	return lifetime
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateSecureRandomToken(length int) (string, error) {
+	bytes := make([]byte, length)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
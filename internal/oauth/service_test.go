@@ -0,0 +1,179 @@
+package oauth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/token"
+	"github.com/RezaBG/Inventory-management-api/internal/user"
+	"gorm.io/gorm"
+)
+
+// fakeClients, fakeAccounts and fakeOrganizations keep what the tests need in memory.
+// Methods the tests don't need panic through the embedded nil interfaces.
+type fakeClients struct {
+	Repository
+	clients []Client
+}
+
+func (r *fakeClients) Create(client *Client) error {
+	client.ID = uint(len(r.clients) + 1)
+	r.clients = append(r.clients, *client)
+	return nil
+}
+
+func (r *fakeClients) FindByClientID(clientID string) (*Client, error) {
+	for i := range r.clients {
+		if r.clients[i].ClientID == clientID {
+			client := r.clients[i]
+			return &client, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeClients) FindByID(id uint) (*Client, error) {
+	for i := range r.clients {
+		if r.clients[i].ID == id {
+			client := r.clients[i]
+			return &client, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeClients) Revoke(id uint) error {
+	now := time.Now()
+	for i := range r.clients {
+		if r.clients[i].ID == id {
+			r.clients[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+type fakeAccounts struct {
+	user.Repository
+	users []user.User
+}
+
+func (r *fakeAccounts) FindByID(id uint) (*user.User, error) {
+	for i := range r.users {
+		if r.users[i].ID == id {
+			return &r.users[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeOrganizations struct {
+	user.OrganizationDirectory
+}
+
+func (fakeOrganizations) DefaultOrganizationID(userID uint) (uint, error) {
+	return 7, nil
+}
+
+func TestIssueToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	clients := &fakeClients{}
+	s := &service{
+		repo: clients,
+		userRepo: &fakeAccounts{users: []user.User{
+			{Model: gorm.Model{ID: 1}, Email: "ada@example.com"},
+			{Model: gorm.Model{ID: 2}, Email: "erp@example.com", IsServiceAccount: true},
+		}},
+		orgs: fakeOrganizations{},
+	}
+
+	if _, err := s.CreateClient(CreateClientInput{Name: "person", ServiceAccountID: 1, Scopes: []string{"products:read"}}); !errors.Is(err, ErrNotServiceAccount) {
+		t.Errorf("client for a person: err = %v, want ErrNotServiceAccount", err)
+	}
+	erp, err := s.CreateClient(CreateClientInput{Name: "erp", ServiceAccountID: 2, Scopes: []string{"products:read", "products:write"}})
+	if err != nil {
+		t.Fatalf("CreateClient: %v", err)
+	}
+	revoked, err := s.CreateClient(CreateClientInput{Name: "old", ServiceAccountID: 2, Scopes: []string{"products:read"}})
+	if err != nil {
+		t.Fatalf("CreateClient: %v", err)
+	}
+	if err := s.RevokeClient(revoked.ID); err != nil {
+		t.Fatalf("RevokeClient: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		input     TokenRequest
+		wantScope string
+		wantCode  string
+	}{
+		{
+			name:      "all registered scopes by default",
+			input:     TokenRequest{GrantType: GrantTypeClientCredentials, ClientID: erp.ClientID, ClientSecret: erp.ClientSecret},
+			wantScope: "products:read products:write",
+		},
+		{
+			name:      "narrowed scope",
+			input:     TokenRequest{GrantType: GrantTypeClientCredentials, ClientID: erp.ClientID, ClientSecret: erp.ClientSecret, Scope: "products:read"},
+			wantScope: "products:read",
+		},
+		{
+			name:     "scope the client isn't registered for",
+			input:    TokenRequest{GrantType: GrantTypeClientCredentials, ClientID: erp.ClientID, ClientSecret: erp.ClientSecret, Scope: "products:read suppliers:read"},
+			wantCode: ErrCodeInvalidScope,
+		},
+		{
+			name:     "wrong secret",
+			input:    TokenRequest{GrantType: GrantTypeClientCredentials, ClientID: erp.ClientID, ClientSecret: revoked.ClientSecret},
+			wantCode: ErrCodeInvalidClient,
+		},
+		{
+			name:     "unknown client",
+			input:    TokenRequest{GrantType: GrantTypeClientCredentials, ClientID: "nope", ClientSecret: erp.ClientSecret},
+			wantCode: ErrCodeInvalidClient,
+		},
+		{
+			name:     "revoked client",
+			input:    TokenRequest{GrantType: GrantTypeClientCredentials, ClientID: revoked.ClientID, ClientSecret: revoked.ClientSecret},
+			wantCode: ErrCodeInvalidClient,
+		},
+		{
+			name:     "other grant type",
+			input:    TokenRequest{GrantType: "password", ClientID: erp.ClientID, ClientSecret: erp.ClientSecret},
+			wantCode: ErrCodeUnsupportedGrantType,
+		},
+		{
+			name:     "missing grant type",
+			input:    TokenRequest{ClientID: erp.ClientID, ClientSecret: erp.ClientSecret},
+			wantCode: ErrCodeInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := s.IssueToken(tt.input)
+			if tt.wantCode != "" {
+				var oauthErr *Error
+				if !errors.As(err, &oauthErr) || oauthErr.Code != tt.wantCode {
+					t.Fatalf("err = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("IssueToken: %v", err)
+			}
+			if response.Scope != tt.wantScope {
+				t.Errorf("scope = %q, want %q", response.Scope, tt.wantScope)
+			}
+
+			claims, err := token.Parse(response.AccessToken)
+			if err != nil {
+				t.Fatalf("access token: %v", err)
+			}
+			if claims.Subject != "2" || claims.ClientID != erp.ClientID || claims.Org != 7 || claims.Scope != tt.wantScope {
+				t.Errorf("claims = %+v, want service account 2 of client %s in organization 7 with scope %q",
+					claims, erp.ClientID, tt.wantScope)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package organization
+
+import "time"
+
+type CreateOrganizationInput struct {
+	Name string `json:"name" binding:"required"`
+	Slug string `json:"slug" binding:"required,max=63"`
+}
+
+type AddMemberInput struct {
+	UserID uint `json:"userId" binding:"required"`
+}
+
+type OrganizationResponse struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	// Active is only set when listing the current user's organizations.
+	Active bool `json:"active,omitempty"`
+}
+
+type MemberResponse struct {
+	UserID   uint      `json:"userId"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
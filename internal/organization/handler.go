@@ -0,0 +1,203 @@
+package organization
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/RezaBG/Inventory-management-api/internal/user"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// GetMyOrganizations lists the organizations the current user belongs to.
+// @Summary      List my organizations
+// @Description  Lists the current user's organizations and marks the one the current session is active in. Use PUT /me/organization to switch.
+// @Tags         Organizations
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   OrganizationResponse
+// @Router       /organizations [get]
+func (h *Handler) GetMyOrganizations(c *gin.Context) {
+	value, _ := c.Get("currentUser")
+	currentUser, ok := value.(*user.User)
+	if !ok || currentUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	orgs, err := h.svc.GetOrganizationsForUser(currentUser.ID, c.GetUint("orgID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organizations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orgs)
+}
+
+// CreateOrganization creates an organization.
+// @Summary      Create an organization
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        organization  body      CreateOrganizationInput  true  "Organization Details"
+// @Success      201  {object}  OrganizationResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /admin/organizations [post]
+func (h *Handler) CreateOrganization(c *gin.Context) {
+	var input CreateOrganizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org, err := h.svc.CreateOrganization(input)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidSlug):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrSlugInUse):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, org)
+}
+
+// GetAllOrganizations lists all organizations.
+// @Summary      List all organizations
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   OrganizationResponse
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/organizations [get]
+func (h *Handler) GetAllOrganizations(c *gin.Context) {
+	orgs, err := h.svc.GetAllOrganizations()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organizations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orgs)
+}
+
+// GetMembers lists an organization's members.
+// @Summary      List organization members
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Organization ID"
+// @Success      200  {array}   MemberResponse
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/organizations/{id}/members [get]
+func (h *Handler) GetMembers(c *gin.Context) {
+	orgID, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+
+	members, err := h.svc.GetMembers(orgID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// AddMember adds a user to an organization.
+// @Summary      Add an organization member
+// @Tags         Admin
+// @Accept       json
+// @Security     BearerAuth
+// @Param        id      path      int             true  "Organization ID"
+// @Param        member  body      AddMemberInput  true  "Member"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/organizations/{id}/members [post]
+func (h *Handler) AddMember(c *gin.Context) {
+	orgID, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input AddMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.AddMember(orgID, input); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveMember removes a user from an organization.
+// @Summary      Remove an organization member
+// @Description  The user immediately loses access to the organization's data, including through API keys and OAuth clients.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        id      path      int  true  "Organization ID"
+// @Param        userId  path      int  true  "User ID"
+// @Success      204  "No Content"
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/organizations/{id}/members/{userId} [delete]
+func (h *Handler) RemoveMember(c *gin.Context) {
+	orgID, ok := uintParam(c, "id")
+	if !ok {
+		return
+	}
+	userID, ok := uintParam(c, "userId")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.RemoveMember(orgID, userID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func uintParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrOrganizationNotFound), errors.Is(err, ErrMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update organization"})
+	}
+}
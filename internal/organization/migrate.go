@@ -0,0 +1,61 @@
+package organization
+
+import (
+	"fmt"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+// Migrate creates the organization tables and the default organization. Rows of the
+// given tenant tables that predate multi-tenancy are assigned to the default
+// organization, and so are all existing users.
+func Migrate(db *gorm.DB, tenantTables ...string) error {
+	backfillMembers := db.Migrator().HasTable("users") && !db.Migrator().HasTable(&Membership{})
+
+	if err := db.AutoMigrate(&Organization{}, &Membership{}); err != nil {
+		return err
+	}
+
+	name := os.Getenv("DEFAULT_ORGANIZATION_NAME")
+	if name == "" {
+		name = "Default"
+	}
+	var defaultOrg Organization
+	if err := db.Where(Organization{Slug: DefaultSlug}).Attrs(Organization{Name: name}).FirstOrCreate(&defaultOrg).Error; err != nil {
+		return err
+	}
+
+	if backfillMembers {
+		err := db.Exec(`
+			INSERT INTO organization_memberships (organization_id, user_id, created_at)
+			SELECT ?, id, NOW() FROM users WHERE deleted_at IS NULL`,
+			defaultOrg.ID,
+		).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// The column is added here rather than by AutoMigrate because it is NOT NULL,
+	// which can only be enforced once existing rows have a value.
+	for _, table := range tenantTables {
+		if !db.Migrator().HasTable(table) || db.Migrator().HasColumn(table, "org_id") {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(fmt.Sprintf(`ALTER TABLE %q ADD COLUMN org_id bigint`, table)).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(fmt.Sprintf(`UPDATE %q SET org_id = ?`, table), defaultOrg.ID).Error; err != nil {
+				return err
+			}
+			return tx.Exec(fmt.Sprintf(`ALTER TABLE %q ALTER COLUMN org_id SET NOT NULL`, table)).Error
+		})
+		if err != nil {
+			return fmt.Errorf("could not add org_id to %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package organization
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Organization is a tenant. Products, suppliers and inventory transactions belong to
+// exactly one organization and are invisible to the others.
+type Organization struct {
+	gorm.Model
+	Name string `gorm:"not null"`
+	Slug string `gorm:"uniqueIndex;not null"`
+}
+
+func (Organization) AuditEntityType() string {
+	return "organization"
+}
+
+// Membership gives a user access to an organization's data. References to an
+// organization are named OrganizationID; OrgID is reserved for tenant-scoped data.
+type Membership struct {
+	OrganizationID uint `gorm:"primaryKey"`
+	UserID         uint `gorm:"primaryKey;index"`
+	CreatedAt      time.Time
+}
+
+func (Membership) TableName() string {
+	return "organization_memberships"
+}
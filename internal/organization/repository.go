@@ -0,0 +1,83 @@
+package organization
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Create(org *Organization) error
+	FindAll() ([]Organization, error)
+	FindByID(id uint) (*Organization, error)
+	FindBySlug(slug string) (*Organization, error)
+	FindForUser(userID uint) ([]Organization, error)
+	AddMember(orgID, userID uint) error
+	RemoveMember(orgID, userID uint) (bool, error)
+	FindMembers(orgID uint) ([]Membership, error)
+	IsMember(orgID, userID uint) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(org *Organization) error {
+	return r.db.Create(org).Error
+}
+
+func (r *repository) FindAll() ([]Organization, error) {
+	var orgs []Organization
+	err := r.db.Order("id").Find(&orgs).Error
+	return orgs, err
+}
+
+func (r *repository) FindByID(id uint) (*Organization, error) {
+	var org Organization
+	err := r.db.First(&org, id).Error
+	return &org, err
+}
+
+func (r *repository) FindBySlug(slug string) (*Organization, error) {
+	var org Organization
+	err := r.db.Where("slug = ?", slug).First(&org).Error
+	return &org, err
+}
+
+// FindForUser returns the user's organizations, oldest membership first.
+func (r *repository) FindForUser(userID uint) ([]Organization, error) {
+	var orgs []Organization
+	err := r.db.
+		Joins("JOIN organization_memberships m ON m.organization_id = organizations.id").
+		Where("m.user_id = ?", userID).
+		Order("m.created_at, organizations.id").
+		Find(&orgs).Error
+	return orgs, err
+}
+
+func (r *repository) AddMember(orgID, userID uint) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Membership{OrganizationID: orgID, UserID: userID}).Error
+}
+
+func (r *repository) RemoveMember(orgID, userID uint) (bool, error) {
+	result := r.db.Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&Membership{})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *repository) FindMembers(orgID uint) ([]Membership, error) {
+	var members []Membership
+	err := r.db.Where("organization_id = ?", orgID).Order("created_at").Find(&members).Error
+	return members, err
+}
+
+func (r *repository) IsMember(orgID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&Membership{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,20 @@
+package organization
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, h *Handler) {
+	router.GET("/organizations", h.GetMyOrganizations)
+}
+
+// RegisterAdminRoutes registers organization management. The router group is expected
+// to be restricted to administrators.
+func RegisterAdminRoutes(router *gin.RouterGroup, h *Handler) {
+	orgRoutes := router.Group("/admin/organizations")
+	{
+		orgRoutes.POST("", h.CreateOrganization)
+		orgRoutes.GET("", h.GetAllOrganizations)
+		orgRoutes.GET("/:id/members", h.GetMembers)
+		orgRoutes.POST("/:id/members", h.AddMember)
+		orgRoutes.DELETE("/:id/members/:userId", h.RemoveMember)
+	}
+}
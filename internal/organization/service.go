@@ -0,0 +1,193 @@
+package organization
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/RezaBG/Inventory-management-api/internal/user"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+const DefaultSlug = "default"
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrMemberNotFound       = errors.New("user is not a member of this organization")
+	ErrSlugInUse            = errors.New("an organization with this slug already exists")
+	ErrInvalidSlug          = errors.New("slug may only contain lowercase letters, digits and hyphens")
+
+	slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+// Service manages organizations and memberships. It also implements
+// user.OrganizationDirectory, which the user package uses when issuing tokens.
+type Service interface {
+	CreateOrganization(input CreateOrganizationInput) (*OrganizationResponse, error)
+	GetAllOrganizations() ([]OrganizationResponse, error)
+	GetOrganizationsForUser(userID uint, activeOrgID uint) ([]OrganizationResponse, error)
+	GetMembers(orgID uint) ([]MemberResponse, error)
+	AddMember(orgID uint, input AddMemberInput) error
+	RemoveMember(orgID, userID uint) error
+
+	IsMember(orgID, userID uint) (bool, error)
+	DefaultOrganizationID(userID uint) (uint, error)
+	AddNewUser(userID uint) error
+}
+
+type service struct {
+	repo     Repository
+	userRepo user.Repository
+}
+
+func NewService(repo Repository, userRepo user.Repository) Service {
+	return &service{repo: repo, userRepo: userRepo}
+}
+
+func toOrganizationResponse(org Organization) OrganizationResponse {
+	return OrganizationResponse{
+		ID:        org.ID,
+		CreatedAt: org.CreatedAt,
+		Name:      org.Name,
+		Slug:      org.Slug,
+	}
+}
+
+func (s *service) CreateOrganization(input CreateOrganizationInput) (*OrganizationResponse, error) {
+	if !slugPattern.MatchString(input.Slug) {
+		return nil, ErrInvalidSlug
+	}
+
+	org := Organization{Name: input.Name, Slug: input.Slug}
+	if err := s.repo.Create(&org); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrSlugInUse
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	response := toOrganizationResponse(org)
+	return &response, nil
+}
+
+func (s *service) GetAllOrganizations() ([]OrganizationResponse, error) {
+	orgs, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]OrganizationResponse, 0, len(orgs))
+	for _, org := range orgs {
+		responses = append(responses, toOrganizationResponse(org))
+	}
+	return responses, nil
+}
+
+func (s *service) GetOrganizationsForUser(userID uint, activeOrgID uint) ([]OrganizationResponse, error) {
+	orgs, err := s.repo.FindForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]OrganizationResponse, 0, len(orgs))
+	for _, org := range orgs {
+		response := toOrganizationResponse(org)
+		response.Active = org.ID == activeOrgID
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+func (s *service) GetMembers(orgID uint) ([]MemberResponse, error) {
+	if _, err := s.findOrganization(orgID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.repo.FindMembers(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]MemberResponse, 0, len(members))
+	for _, member := range members {
+		u, err := s.userRepo.FindByID(member.UserID)
+		if err != nil {
+			// Memberships of deleted users are left behind; don't list them.
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		responses = append(responses, MemberResponse{
+			UserID:   u.ID,
+			Name:     u.Name,
+			Email:    u.Email,
+			JoinedAt: member.CreatedAt,
+		})
+	}
+	return responses, nil
+}
+
+func (s *service) AddMember(orgID uint, input AddMemberInput) error {
+	if _, err := s.findOrganization(orgID); err != nil {
+		return err
+	}
+	if _, err := s.userRepo.FindByID(input.UserID); err != nil {
+		return err
+	}
+	return s.repo.AddMember(orgID, input.UserID)
+}
+
+func (s *service) RemoveMember(orgID, userID uint) error {
+	removed, err := s.repo.RemoveMember(orgID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrMemberNotFound
+	}
+	return nil
+}
+
+func (s *service) IsMember(orgID, userID uint) (bool, error) {
+	return s.repo.IsMember(orgID, userID)
+}
+
+// DefaultOrganizationID returns the user's oldest membership, or 0 if they have none.
+func (s *service) DefaultOrganizationID(userID uint) (uint, error) {
+	orgs, err := s.repo.FindForUser(userID)
+	if err != nil || len(orgs) == 0 {
+		return 0, err
+	}
+	return orgs[0].ID, nil
+}
+
+// AddNewUser adds a newly registered user to the organization named by
+// NEW_USER_ORGANIZATION (a slug). Without it, new users join no organization until
+// an administrator adds them.
+func (s *service) AddNewUser(userID uint) error {
+	slug := os.Getenv("NEW_USER_ORGANIZATION")
+	if slug == "" {
+		return nil
+	}
+
+	org, err := s.repo.FindBySlug(slug)
+	if err != nil {
+		return fmt.Errorf("could not load organization %q for new users: %w", slug, err)
+	}
+	return s.repo.AddMember(org.ID, userID)
+}
+
+func (s *service) findOrganization(id uint) (*Organization, error) {
+	org, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	return org, nil
+}
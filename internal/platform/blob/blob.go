@@ -0,0 +1,43 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrNotFound is returned by Get when no object is stored under the key.
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps uploaded files outside the database. Keys are slash-separated
+// paths chosen by the caller. Implementations must be safe for concurrent use.
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStore builds the Store selected by BLOB_DRIVER ("local" or "s3").
+// The local driver is the default so development needs no object storage.
+func NewStore() (Store, error) {
+	switch os.Getenv("BLOB_DRIVER") {
+	case "", "local":
+		dir := os.Getenv("BLOB_LOCAL_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		return NewLocalStore(dir)
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown BLOB_DRIVER %q", os.Getenv("BLOB_DRIVER"))
+	}
+}
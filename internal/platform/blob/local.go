@@ -0,0 +1,90 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps objects as plain files below a root directory.
+type LocalStore struct {
+	dir string
+}
+
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create blob directory: %w", err)
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+func (s *LocalStore) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("could not create blob directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial object.
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("could not store blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not store blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not store blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("could not store blob: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read blob: %w", err)
+	}
+	return f, nil
+}
+
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("could not delete blob: %w", err)
+	}
+	return nil
+}
+
+// path maps a key onto the filesystem, refusing keys that would escape the
+// root directory.
+func (s *LocalStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,212 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty body, used for GET and DELETE.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Config points an S3Store at a bucket. Endpoint may name any
+// S3-compatible service (MinIO, for instance); when empty the AWS endpoint
+// for Region is used.
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3Store keeps objects in an S3-compatible bucket. Requests use path-style
+// addressing and are signed with AWS Signature Version 4.
+type S3Store struct {
+	endpoint *url.URL
+	region   string
+	bucket   string
+	keyID    string
+	secret   string
+	client   *http.Client
+}
+
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3_BUCKET is required for the s3 blob driver")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required for the s3 blob driver")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3_ENDPOINT %q", cfg.Endpoint)
+	}
+
+	return &S3Store{
+		endpoint: endpoint,
+		region:   cfg.Region,
+		bucket:   cfg.Bucket,
+		keyID:    cfg.AccessKeyID,
+		secret:   cfg.SecretAccessKey,
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	// The body is streamed, so its hash is not known up front.
+	s.sign(req, "UNSIGNED-PAYLOAD", time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not store blob: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return responseError("could not store blob", resp)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, emptyPayloadHash, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not read blob: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, responseError("could not read blob", resp)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, emptyPayloadHash, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not delete blob: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return responseError("could not delete blob", resp)
+	}
+	return nil
+}
+
+func (s *S3Store) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if key == "" || strings.Contains(key, "..") {
+		return nil, fmt.Errorf("invalid blob key %q", key)
+	}
+	u := *s.endpoint
+	u.Path = s.endpoint.Path + "/" + s.bucket + "/" + strings.TrimPrefix(key, "/")
+	// The path is sent exactly as it is signed.
+	u.RawPath = canonicalPath(u.Path)
+	return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+// sign adds the SigV4 headers for a request whose body hashes to payloadHash.
+func (s *S3Store) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL.Path),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	digest := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secret), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.keyID, scope, signedHeaders, signature,
+	))
+}
+
+// canonicalPath URI-encodes every path segment the way SigV4 expects for S3: only
+// the unreserved characters A-Z, a-z, 0-9, '-', '.', '_' and '~' are left as they
+// are. url.PathEscape can't be used, as it leaves characters such as '$', '=' and
+// ':' alone.
+func canonicalPath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c == '/' || isUnreserved(c) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func responseError(msg string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s: %s: %s", msg, resp.Status, strings.TrimSpace(string(body)))
+}
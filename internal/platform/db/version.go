@@ -0,0 +1,7 @@
+package db
+
+import "errors"
+
+// ErrStaleVersion is returned when a versioned record was changed by another request
+// after it was read, so writing it would overwrite that change.
+var ErrStaleVersion = errors.New("the record was modified by another request")
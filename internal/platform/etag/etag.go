@@ -0,0 +1,66 @@
+// Package etag implements conditional requests (If-Match, If-None-Match) for resources
+// with a version number. A resource's ETag is its version in quotes, e.g. "3".
+package etag
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrPreconditionFailed   = errors.New("the resource has been modified; fetch it again and retry with its current ETag")
+	ErrPreconditionRequired = errors.New("this request must be conditional; send the resource's ETag in an If-Match header")
+)
+
+// Format returns the ETag of a resource at version.
+func Format(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// Precondition is the value of an If-Match header. The empty precondition allows
+// every version.
+type Precondition string
+
+// ParseIfMatch returns the precondition of an If-Match header. A missing header is
+// rejected with ErrPreconditionRequired when REQUIRE_IF_MATCH is set.
+func ParseIfMatch(header string) (Precondition, error) {
+	header = strings.TrimSpace(header)
+	if header == "" && Required() {
+		return "", ErrPreconditionRequired
+	}
+	return Precondition(header), nil
+}
+
+// Allows reports whether a resource at version satisfies the precondition. If-Match
+// uses the strong comparison, so weak ETags never match.
+func (p Precondition) Allows(version uint) bool {
+	return p == "" || matches(string(p), version, false)
+}
+
+// NotModified reports whether an If-None-Match header matches a resource at version,
+// i.e. whether a GET can be answered with 304 Not Modified.
+func NotModified(ifNoneMatch string, version uint) bool {
+	return strings.TrimSpace(ifNoneMatch) != "" && matches(ifNoneMatch, version, true)
+}
+
+// Required reports whether updates and deletes must carry an If-Match header.
+func Required() bool {
+	required, _ := strconv.ParseBool(os.Getenv("REQUIRE_IF_MATCH"))
+	return required
+}
+
+func matches(header string, version uint, weak bool) bool {
+	current := Format(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
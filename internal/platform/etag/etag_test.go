@@ -0,0 +1,82 @@
+package etag
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		weak   bool
+		want   bool
+	}{
+		{`"3"`, false, true},
+		{`"4"`, false, false},
+		{`3`, false, false},
+		{`*`, false, true},
+		{`"1", "3"`, false, true},
+		{` "1" ,"3" `, false, true},
+		{`"1", "2"`, false, false},
+		{`W/"3"`, false, false},
+		{`W/"3"`, true, true},
+		{`W/"1", "3"`, true, true},
+		{`"33"`, false, false},
+		{``, false, false},
+	}
+	for _, tt := range tests {
+		if got := matches(tt.header, 3, tt.weak); got != tt.want {
+			t.Errorf("matches(%q, 3, %v) = %v, want %v", tt.header, tt.weak, got, tt.want)
+		}
+	}
+}
+
+func TestPreconditionAllows(t *testing.T) {
+	tests := []struct {
+		precondition Precondition
+		want         bool
+	}{
+		{"", true},
+		{`"3"`, true},
+		{`"2"`, false},
+		{`W/"3"`, false},
+	}
+	for _, tt := range tests {
+		if got := tt.precondition.Allows(3); got != tt.want {
+			t.Errorf("Precondition(%q).Allows(3) = %v, want %v", tt.precondition, got, tt.want)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{"", false},
+		{"  ", false},
+		{`"3"`, true},
+		{`W/"3"`, true},
+		{`"2"`, false},
+	}
+	for _, tt := range tests {
+		if got := NotModified(tt.ifNoneMatch, 3); got != tt.want {
+			t.Errorf("NotModified(%q, 3) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	t.Setenv("REQUIRE_IF_MATCH", "true")
+	if _, err := ParseIfMatch(" "); !errors.Is(err, ErrPreconditionRequired) {
+		t.Errorf("ParseIfMatch without a header: err = %v, want ErrPreconditionRequired", err)
+	}
+	if p, err := ParseIfMatch(` "3" `); err != nil || p != `"3"` {
+		t.Errorf(`ParseIfMatch(" \"3\" ") = %q, %v`, p, err)
+	}
+
+	t.Setenv("REQUIRE_IF_MATCH", "false")
+	if p, err := ParseIfMatch(""); err != nil || p != "" {
+		t.Errorf(`ParseIfMatch("") = %q, %v; want the empty precondition`, p, err)
+	}
+}
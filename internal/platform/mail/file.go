@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSender writes every message as an .eml file into an outbox directory
+// instead of delivering it. It is meant for local development and tests.
+type FileSender struct {
+	dir  string
+	from string
+	mu   sync.Mutex
+	seq  int
+}
+
+func NewFileSender(dir, from string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create mail outbox: %w", err)
+	}
+	return &FileSender{dir: dir, from: from}, nil
+}
+
+func (s *FileSender) Send(msg Message) error {
+	s.mu.Lock()
+	s.seq++
+	seq := s.seq
+	s.mu.Unlock()
+
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	name := fmt.Sprintf("%s-%04d-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), seq, recipient)
+
+	if err := os.WriteFile(filepath.Join(s.dir, name), formatMessage(s.from, msg), 0o644); err != nil {
+		return fmt.Errorf("could not write message to outbox: %w", err)
+	}
+	return nil
+}
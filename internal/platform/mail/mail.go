@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+)
+
+// Message is a plain-text email ready to be handed to a Sender.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers outgoing email. Implementations must be safe for concurrent use.
+type Sender interface {
+	Send(msg Message) error
+}
+
+// NewSender builds the Sender selected by MAIL_DRIVER ("file" or "smtp").
+// The file driver is the default so local development never sends real email.
+func NewSender() (Sender, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@inventory-api.local"
+	}
+
+	switch os.Getenv("MAIL_DRIVER") {
+	case "", "file":
+		dir := os.Getenv("MAIL_OUTBOX_DIR")
+		if dir == "" {
+			dir = "outbox"
+		}
+		return NewFileSender(dir, from)
+	case "smtp":
+		return NewSMTPSender(
+			os.Getenv("SMTP_HOST"),
+			os.Getenv("SMTP_PORT"),
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			from,
+		)
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", os.Getenv("MAIL_DRIVER"))
+	}
+}
+
+func formatMessage(from string, msg Message) []byte {
+	return []byte(fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		from, msg.To, msg.Subject, msg.Body,
+	))
+}
@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+)
+
+// SMTPSender delivers messages through an SMTP relay.
+type SMTPSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPSender(host, port, username, password, from string) (*SMTPSender, error) {
+	if host == "" {
+		return nil, fmt.Errorf("SMTP_HOST is required for the smtp mail driver")
+	}
+	if port == "" {
+		port = "587"
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPSender{
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+	}, nil
+}
+
+func (s *SMTPSender) Send(msg Message) error {
+	if err := smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, formatMessage(s.from, msg)); err != nil {
+		return fmt.Errorf("could not send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Package money handles amounts of money, which are stored as integers in the minor
+// unit of their ISO 4217 currency, e.g. 1999 EUR for €19.99.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+var ErrUnknownCurrency = errors.New("unknown currency; use an ISO 4217 code such as EUR, USD or GBP")
+
+// exponents are the number of minor unit digits of the supported currencies.
+var exponents = map[string]int{
+	"AED": 2, "AUD": 2, "BGN": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0,
+	"CNY": 2, "CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2,
+	"ILS": 2, "INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2,
+	"MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PHP": 2, "PLN": 2, "RON": 2, "SAR": 2,
+	"SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "TWD": 2, "UAH": 2, "USD": 2,
+	"VND": 0, "ZAR": 2,
+}
+
+// DefaultCurrency is the currency of amounts given without one, from DEFAULT_CURRENCY.
+// It defaults to EUR.
+func DefaultCurrency() string {
+	if currency := strings.ToUpper(os.Getenv("DEFAULT_CURRENCY")); currency != "" {
+		return currency
+	}
+	return "EUR"
+}
+
+// ValidateDefaultCurrency makes sure that DEFAULT_CURRENCY is a supported currency.
+// It is checked at startup, as migrations write the default currency into existing
+// rows and every amount without a currency is in it.
+func ValidateDefaultCurrency() error {
+	if _, ok := exponents[DefaultCurrency()]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownCurrency, DefaultCurrency())
+	}
+	return nil
+}
+
+// Normalize upper-cases a currency code and makes sure it is supported. An empty code
+// is the default currency.
+func Normalize(currency string) (string, error) {
+	if currency == "" {
+		return DefaultCurrency(), nil
+	}
+	currency = strings.ToUpper(currency)
+	if _, ok := exponents[currency]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return currency, nil
+}
+
+// Exponent returns the number of minor unit digits of a currency.
+func Exponent(currency string) int {
+	if exponent, ok := exponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// ToMinor converts an amount in major units, e.g. 19.99, to minor units, rounding to
+// the nearest minor unit.
+func ToMinor(amount float64, currency string) int64 {
+	return int64(math.Round(amount * math.Pow10(Exponent(currency))))
+}
+
+// ToMajor converts an amount in minor units to major units.
+func ToMajor(minor float64, currency string) float64 {
+	return minor / math.Pow10(Exponent(currency))
+}
+
+// MajorSQL is an SQL expression for an amount in minor units in major units, given
+// the columns of the amount and of its currency.
+func MajorSQL(minorColumn, currencyColumn string) string {
+	divisors := map[int][]string{}
+	for currency, exponent := range exponents {
+		if exponent != 2 {
+			divisors[exponent] = append(divisors[exponent], "'"+currency+"'")
+		}
+	}
+	exponentsUsed := make([]int, 0, len(divisors))
+	for exponent := range divisors {
+		exponentsUsed = append(exponentsUsed, exponent)
+	}
+	sort.Ints(exponentsUsed)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "(%s::numeric / CASE", minorColumn)
+	for _, exponent := range exponentsUsed {
+		sort.Strings(divisors[exponent])
+		fmt.Fprintf(&b, " WHEN %s IN (%s) THEN %d", currencyColumn, strings.Join(divisors[exponent], ", "), int(math.Pow10(exponent)))
+	}
+	b.WriteString(" ELSE 100 END)")
+	return b.String()
+}
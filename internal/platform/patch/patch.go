@@ -0,0 +1,73 @@
+// Package patch applies partial updates sent with PATCH: RFC 7396 JSON Merge Patch and
+// RFC 6902 JSON Patch. A patch is applied to the JSON form of a resource's editable
+// fields, and the result is validated as a whole, like a full update would be.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	ErrUnsupportedMediaType = errors.New("patches must be sent as " + MergePatchType + " or " + JSONPatchType)
+	ErrInvalidPatch         = errors.New("invalid patch")
+)
+
+// Patch is the body of a PATCH request and its media type. Plain application/json is
+// treated as a merge patch.
+type Patch struct {
+	ContentType string
+	Body        []byte
+}
+
+// Apply patches target, a pointer to a struct of editable fields holding the current
+// values. Unknown fields are rejected, so read-only fields can't be patched. The
+// result is validated with the struct's binding tags.
+func (p Patch) Apply(target interface{}) error {
+	doc, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	switch p.ContentType {
+	case MergePatchType, binding.MIMEJSON:
+		patched, err = jsonpatch.MergePatch(doc, p.Body)
+	case JSONPatchType:
+		var operations jsonpatch.Patch
+		operations, err = jsonpatch.DecodePatch(p.Body)
+		if err == nil {
+			patched, err = operations.Apply(doc)
+		}
+	default:
+		return ErrUnsupportedMediaType
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	// Decode into a zero value, so fields the patch removed end up empty.
+	value := reflect.ValueOf(target).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	if err := binding.Validator.ValidateStruct(target); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return nil
+}
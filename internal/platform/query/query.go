@@ -0,0 +1,259 @@
+// Package query turns the query string of a list endpoint into pagination, sorting
+// and filter clauses. Only whitelisted fields can be sorted and filtered by, and
+// values are always bound as parameters.
+//
+//	GET /products?page=2&pageSize=20&sort=-price,name&price[gte]=10&name[contains]=lap
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+type Kind int
+
+const (
+	String Kind = iota
+	Number
+	Time
+)
+
+// Field is a column a list can be sorted and filtered by. Column is an SQL expression
+// and is never taken from the request.
+type Field struct {
+	Column string
+	Kind   Kind
+}
+
+// Fields whitelists the fields of a list, keyed by their name in the API.
+type Fields map[string]Field
+
+// operators maps filter operators to SQL, by the kinds they apply to.
+var operators = map[string]struct {
+	sql   string
+	kinds []Kind
+}{
+	"eq":       {"= ?", []Kind{String, Number, Time}},
+	"ne":       {"<> ?", []Kind{String, Number, Time}},
+	"lt":       {"< ?", []Kind{Number, Time}},
+	"lte":      {"<= ?", []Kind{Number, Time}},
+	"gt":       {"> ?", []Kind{Number, Time}},
+	"gte":      {">= ?", []Kind{Number, Time}},
+	"contains": {"ILIKE ?", []Kind{String}},
+}
+
+// reserved parameters are not filters.
+var reserved = map[string]bool{"page": true, "pageSize": true, "limit": true, "sort": true}
+
+var filterKey = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9]*)(?:\[([a-z]+)\])?$`)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike escapes the wildcards and backslashes in s, so that a LIKE or ILIKE
+// pattern built from it matches s literally.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+type order struct {
+	column string
+	desc   bool
+}
+
+type filter struct {
+	condition string
+	value     interface{}
+}
+
+// Params is a parsed list query.
+type Params struct {
+	Page     int
+	PageSize int
+	orders   []order
+	filters  []filter
+}
+
+// Parse reads page, pageSize (or its alias limit), sort and filters from values.
+// Without a sort parameter the list is sorted by defaultSort. If fields has an "id",
+// it is used as the final tie-breaker so pages are stable.
+func Parse(values url.Values, fields Fields, defaultSort string) (*Params, error) {
+	params := &Params{Page: 1, PageSize: DefaultPageSize}
+
+	var err error
+	if page := values.Get("page"); page != "" {
+		if params.Page, err = strconv.Atoi(page); err != nil || params.Page < 1 {
+			return nil, fmt.Errorf("%w: page must be a positive number", ErrInvalidQuery)
+		}
+	}
+	pageSize := values.Get("pageSize")
+	if pageSize == "" {
+		pageSize = values.Get("limit")
+	}
+	if pageSize != "" {
+		if params.PageSize, err = strconv.Atoi(pageSize); err != nil || params.PageSize < 1 || params.PageSize > MaxPageSize {
+			return nil, fmt.Errorf("%w: pageSize must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+		}
+	}
+
+	sort := values.Get("sort")
+	if sort == "" {
+		sort = defaultSort
+	}
+	sortedByID := false
+	for _, name := range strings.Split(sort, ",") {
+		name = strings.TrimSpace(name)
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		field, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, name)
+		}
+		params.orders = append(params.orders, order{column: field.Column, desc: desc})
+		sortedByID = sortedByID || name == "id"
+	}
+	if id, ok := fields["id"]; ok && !sortedByID {
+		params.orders = append(params.orders, order{column: id.Column})
+	}
+
+	for key, vals := range values {
+		if reserved[key] {
+			continue
+		}
+		match := filterKey.FindStringSubmatch(key)
+		if match == nil {
+			return nil, fmt.Errorf("%w: unknown parameter %q", ErrInvalidQuery, key)
+		}
+		field, ok := fields[match[1]]
+		if !ok {
+			return nil, fmt.Errorf("%w: cannot filter by %q", ErrInvalidQuery, match[1])
+		}
+		op := match[2]
+		if op == "" {
+			op = "eq"
+		}
+		for _, raw := range vals {
+			f, err := newFilter(field, op, raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidQuery, key, err)
+			}
+			params.filters = append(params.filters, f)
+		}
+	}
+
+	return params, nil
+}
+
+func newFilter(field Field, op, raw string) (filter, error) {
+	operator, ok := operators[op]
+	if !ok {
+		return filter{}, fmt.Errorf("unknown operator %q", op)
+	}
+	applies := false
+	for _, kind := range operator.kinds {
+		applies = applies || kind == field.Kind
+	}
+	if !applies {
+		return filter{}, fmt.Errorf("operator %q is not supported for this field", op)
+	}
+
+	var value interface{}
+	switch {
+	case op == "contains":
+		value = "%" + EscapeLike(raw) + "%"
+	case field.Kind == Number:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return filter{}, fmt.Errorf("%q is not a number", raw)
+		}
+		value = number
+	case field.Kind == Time:
+		t, err := ParseTime(raw)
+		if err != nil {
+			return filter{}, err
+		}
+		value = t
+	default:
+		value = raw
+	}
+
+	return filter{condition: field.Column + " " + operator.sql, value: value}, nil
+}
+
+// ParseTime parses a date (YYYY-MM-DD, as midnight UTC) or an RFC 3339 timestamp.
+func ParseTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD) or RFC 3339 timestamp", raw)
+}
+
+// Filter applies the filters; use it for both the count and the page query.
+func (p *Params) Filter(db *gorm.DB) *gorm.DB {
+	for _, f := range p.filters {
+		db = db.Where(f.condition, f.value)
+	}
+	return db
+}
+
+// Sort applies the sort order.
+func (p *Params) Sort(db *gorm.DB) *gorm.DB {
+	columns := make([]clause.OrderByColumn, 0, len(p.orders))
+	for _, o := range p.orders {
+		columns = append(columns, clause.OrderByColumn{
+			Column: clause.Column{Name: o.column, Raw: true},
+			Desc:   o.desc,
+		})
+	}
+	return db.Order(clause.OrderBy{Columns: columns})
+}
+
+// Paginate limits the query to the requested page.
+func (p *Params) Paginate(db *gorm.DB) *gorm.DB {
+	return db.Offset((p.Page - 1) * p.PageSize).Limit(p.PageSize)
+}
+
+// Links builds a Link header (RFC 8288) with the first, prev, next and last pages of
+// a list of total items, keeping the other parameters of the request URL.
+func (p *Params) Links(requestURL *url.URL, total int64) string {
+	lastPage := int((total + int64(p.PageSize) - 1) / int64(p.PageSize))
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	link := func(page int, rel string) string {
+		values := requestURL.Query()
+		values.Del("limit")
+		values.Set("page", strconv.Itoa(page))
+		values.Set("pageSize", strconv.Itoa(p.PageSize))
+		target := url.URL{Path: requestURL.Path, RawQuery: values.Encode()}
+		return fmt.Sprintf(`<%s>; rel="%s"`, target.String(), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if p.Page > 1 {
+		links = append(links, link(min(p.Page-1, lastPage), "prev"))
+	}
+	if p.Page < lastPage {
+		links = append(links, link(p.Page+1, "next"))
+	}
+	links = append(links, link(lastPage, "last"))
+	return strings.Join(links, ", ")
+}
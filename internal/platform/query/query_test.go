@@ -0,0 +1,137 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testFields = Fields{
+	"id":        {Column: "products.id", Kind: Number},
+	"name":      {Column: "products.name", Kind: String},
+	"price":     {Column: "products.price_minor", Kind: Number},
+	"createdAt": {Column: "products.created_at", Kind: Time},
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		page     int
+		pageSize int
+		orders   []order
+		filters  []filter
+	}{
+		{
+			name:     "defaults",
+			query:    "",
+			page:     1,
+			pageSize: DefaultPageSize,
+			orders:   []order{{column: "products.name"}, {column: "products.id"}},
+		},
+		{
+			name:     "limit is an alias of pageSize",
+			query:    "page=3&limit=50",
+			page:     3,
+			pageSize: 50,
+			orders:   []order{{column: "products.name"}, {column: "products.id"}},
+		},
+		{
+			name:     "sort without a second id",
+			query:    "sort=-price,id",
+			page:     1,
+			pageSize: DefaultPageSize,
+			orders:   []order{{column: "products.price_minor", desc: true}, {column: "products.id"}},
+		},
+		{
+			name:     "filters",
+			query:    "price[gte]=10&createdAt[lt]=2025-03-01",
+			page:     1,
+			pageSize: DefaultPageSize,
+			orders:   []order{{column: "products.name"}, {column: "products.id"}},
+			filters: []filter{
+				{condition: "products.created_at < ?", value: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+				{condition: "products.price_minor >= ?", value: 10.0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			params, err := Parse(values, testFields, "name")
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if params.Page != tt.page || params.PageSize != tt.pageSize {
+				t.Errorf("page %d, pageSize %d; want %d, %d", params.Page, params.PageSize, tt.page, tt.pageSize)
+			}
+			if !reflect.DeepEqual(params.orders, tt.orders) {
+				t.Errorf("orders = %+v, want %+v", params.orders, tt.orders)
+			}
+			// Filters come from a map, so their order is not fixed.
+			if len(params.filters) != len(tt.filters) {
+				t.Fatalf("filters = %+v, want %+v", params.filters, tt.filters)
+			}
+			for _, want := range tt.filters {
+				found := false
+				for _, got := range params.filters {
+					found = found || reflect.DeepEqual(got, want)
+				}
+				if !found {
+					t.Errorf("filters = %+v, missing %+v", params.filters, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"page below one", "page=0"},
+		{"page not a number", "page=two"},
+		{"page size too large", "pageSize=101"},
+		{"sort by unknown field", "sort=cost"},
+		{"sort by column name", "sort=price_minor"},
+		{"filter by unknown field", "cost=1"},
+		{"filter key with SQL", "name%20OR%201=1"},
+		{"unknown operator", "price[like]=1"},
+		{"operator for another kind", "name[gt]=a"},
+		{"contains on a number", "price[contains]=1"},
+		{"number that isn't one", "price=ten"},
+		{"time that isn't one", "createdAt=yesterday"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			if _, err := Parse(values, testFields, "name"); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalidQuery", tt.query, err)
+			}
+		})
+	}
+}
+
+func TestNewFilterEscapesLike(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"lap", "%lap%"},
+		{"100%", `%100\%%`},
+		{"a_b", `%a\_b%`},
+		{`back\slash`, `%back\\slash%`},
+	}
+	for _, tt := range tests {
+		f, err := newFilter(testFields["name"], "contains", tt.raw)
+		if err != nil {
+			t.Fatalf("newFilter(%q): %v", tt.raw, err)
+		}
+		if f.condition != "products.name ILIKE ?" || f.value != tt.want {
+			t.Errorf("newFilter(%q) = %q with %v, want ILIKE with %q", tt.raw, f.condition, f.value, tt.want)
+		}
+	}
+}
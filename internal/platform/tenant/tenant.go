@@ -0,0 +1,128 @@
+// Package tenant isolates the data of different organizations. Models with an OrgID
+// field are tenant-scoped: every query, update and delete on them is restricted to the
+// organization stored in the statement's context, and creates are assigned to it.
+// Statements on tenant-scoped models without an organization in the context fail.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const orgField = "OrgID"
+
+var (
+	ErrMissingOrganization = errors.New("tenant: no active organization in context")
+	ErrUpsertNotAllowed    = errors.New("tenant: upserts are not allowed on tenant-scoped models")
+)
+
+type contextKey struct{}
+
+// WithOrganization returns a context whose database statements are scoped to orgID.
+func WithOrganization(ctx context.Context, orgID uint) context.Context {
+	return context.WithValue(ctx, contextKey{}, orgID)
+}
+
+// OrganizationID returns the organization the context is scoped to.
+func OrganizationID(ctx context.Context) (uint, bool) {
+	orgID, ok := ctx.Value(contextKey{}).(uint)
+	return orgID, ok && orgID != 0
+}
+
+// Plugin registers the scoping callbacks; install it with db.Use(tenant.Plugin{}).
+type Plugin struct{}
+
+func (Plugin) Name() string {
+	return "tenant"
+}
+
+func (Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("tenant:create", assignOrganization); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:query", scopeToOrganization); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:update", scopeUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant:delete", scopeToOrganization); err != nil {
+		return err
+	}
+	return callbacks.Row().Before("gorm:row").Register("tenant:row", scopeToOrganization)
+}
+
+func tenantField(db *gorm.DB) *schema.Field {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField(orgField)
+}
+
+func scopeToOrganization(db *gorm.DB) {
+	field := tenantField(db)
+	if field == nil || db.Error != nil {
+		return
+	}
+
+	orgID, ok := OrganizationID(db.Statement.Context)
+	if !ok {
+		_ = db.AddError(ErrMissingOrganization)
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: orgID},
+	}})
+}
+
+// scopeUpdate also keeps org_id out of the SET clause, so a record can never be moved
+// to another organization (or lose its organization) through an update.
+func scopeUpdate(db *gorm.DB) {
+	scopeToOrganization(db)
+	if field := tenantField(db); field != nil && db.Error == nil {
+		db.Statement.Omits = append(db.Statement.Omits, field.DBName)
+	}
+}
+
+func assignOrganization(db *gorm.DB) {
+	field := tenantField(db)
+	if field == nil || db.Error != nil {
+		return
+	}
+
+	orgID, ok := OrganizationID(db.Statement.Context)
+	if !ok {
+		_ = db.AddError(ErrMissingOrganization)
+		return
+	}
+
+	// Save() falls back to an upsert on the primary key when its update matches no row,
+	// which could overwrite another organization's record.
+	if _, ok := db.Statement.Clauses["ON CONFLICT"]; ok {
+		_ = db.AddError(ErrUpsertNotAllowed)
+		return
+	}
+
+	ctx := db.Statement.Context
+	value := db.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := field.Set(ctx, reflect.Indirect(value.Index(i)), orgID); err != nil {
+				_ = db.AddError(err)
+				return
+			}
+		}
+	case reflect.Struct:
+		if err := field.Set(ctx, value, orgID); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// record is tenant-scoped, setting isn't.
+type record struct {
+	ID    uint
+	OrgID uint
+	Name  string
+}
+
+type setting struct {
+	ID   uint
+	Name string
+}
+
+// newDryRunDB returns a database that builds statements without running them, so the
+// tests can check the SQL the plugin produces without a server.
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(Plugin{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestPluginScopesStatements(t *testing.T) {
+	db := newDryRunDB(t).WithContext(WithOrganization(context.Background(), 1))
+
+	tests := []struct {
+		name     string
+		run      func(db *gorm.DB) *gorm.DB
+		wantSQL  string
+		wantVars []interface{}
+	}{
+		{
+			name: "query",
+			run: func(db *gorm.DB) *gorm.DB {
+				var records []record
+				return db.Where("name = ?", "a").Find(&records)
+			},
+			wantSQL:  `SELECT * FROM "records" WHERE name = $1 AND "records"."org_id" = $2`,
+			wantVars: []interface{}{"a", uint(1)},
+		},
+		{
+			// Record 5 of another organization doesn't match the lookup.
+			name: "lookup by primary key",
+			run: func(db *gorm.DB) *gorm.DB {
+				var r record
+				return db.First(&r, 5)
+			},
+			wantSQL:  `SELECT * FROM "records" WHERE "records"."id" = $1 AND "records"."org_id" = $2 ORDER BY "records"."id" LIMIT $3`,
+			wantVars: []interface{}{5, uint(1), 1},
+		},
+		{
+			name: "count",
+			run: func(db *gorm.DB) *gorm.DB {
+				var count int64
+				return db.Model(&record{}).Count(&count)
+			},
+			wantSQL:  `SELECT count(*) FROM "records" WHERE "records"."org_id" = $1`,
+			wantVars: []interface{}{uint(1)},
+		},
+		{
+			name: "update can't move the record to another organization",
+			run: func(db *gorm.DB) *gorm.DB {
+				return db.Model(&record{ID: 5}).Updates(map[string]interface{}{"name": "b", "org_id": 2})
+			},
+			wantSQL:  `UPDATE "records" SET "name"=$1 WHERE "records"."org_id" = $2 AND "id" = $3`,
+			wantVars: []interface{}{"b", uint(1), uint(5)},
+		},
+		{
+			name: "delete",
+			run: func(db *gorm.DB) *gorm.DB {
+				return db.Delete(&record{}, 5)
+			},
+			wantSQL:  `DELETE FROM "records" WHERE "records"."id" = $1 AND "records"."org_id" = $2`,
+			wantVars: []interface{}{5, uint(1)},
+		},
+		{
+			name: "create is assigned to the organization",
+			run: func(db *gorm.DB) *gorm.DB {
+				return db.Create(&record{Name: "c", OrgID: 2})
+			},
+			wantSQL:  `INSERT INTO "records" ("org_id","name") VALUES ($1,$2) RETURNING "id"`,
+			wantVars: []interface{}{uint(1), "c"},
+		},
+		{
+			name: "models without OrgID aren't scoped",
+			run: func(db *gorm.DB) *gorm.DB {
+				var settings []setting
+				return db.Find(&settings)
+			},
+			wantSQL: `SELECT * FROM "settings"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.run(db)
+			if result.Error != nil {
+				t.Fatalf("error: %v", result.Error)
+			}
+			if got := result.Statement.SQL.String(); got != tt.wantSQL {
+				t.Errorf("SQL = %s\nwant  %s", got, tt.wantSQL)
+			}
+			if got := result.Statement.Vars; !reflect.DeepEqual(got, tt.wantVars) {
+				t.Errorf("vars = %#v, want %#v", got, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestPluginRejects(t *testing.T) {
+	db := newDryRunDB(t)
+	scoped := db.WithContext(WithOrganization(context.Background(), 1))
+
+	tests := []struct {
+		name    string
+		run     func() error
+		wantErr error
+	}{
+		{"query without organization", func() error {
+			var records []record
+			return db.Find(&records).Error
+		}, ErrMissingOrganization},
+		{"update without organization", func() error {
+			return db.Model(&record{ID: 5}).Update("name", "b").Error
+		}, ErrMissingOrganization},
+		{"delete without organization", func() error {
+			return db.Delete(&record{}, 5).Error
+		}, ErrMissingOrganization},
+		{"create without organization", func() error {
+			return db.Create(&record{Name: "c"}).Error
+		}, ErrMissingOrganization},
+		{"upsert", func() error {
+			return scoped.Clauses(clause.OnConflict{UpdateAll: true}).Create(&record{ID: 5, Name: "c"}).Error
+		}, ErrUpsertNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package pricelist
+
+import "time"
+
+// PriceListInput is a price list. Prices are in major units of the currency, which
+// defaults to DEFAULT_CURRENCY. Without customers and customer groups, the list
+// applies to everyone.
+type PriceListInput struct {
+	Name             string      `json:"name" binding:"required"`
+	Currency         string      `json:"currency" binding:"omitempty,len=3"`
+	ValidFrom        *time.Time  `json:"validFrom"`
+	ValidTo          *time.Time  `json:"validTo"`
+	Items            []ItemInput `json:"items" binding:"dive"`
+	CustomerIDs      []uint      `json:"customerIds"`
+	CustomerGroupIDs []uint      `json:"customerGroupIds"`
+}
+
+// ItemInput is a product's unit price from a minimum quantity on; without one, the
+// price applies to any quantity.
+type ItemInput struct {
+	ProductID   uint    `json:"productId" binding:"required"`
+	MinQuantity float64 `json:"minQuantity" binding:"gte=0"`
+	Price       float64 `json:"price" binding:"required,gt=0"`
+}
+
+type ItemResponse struct {
+	ProductID   uint    `json:"productId"`
+	MinQuantity float64 `json:"minQuantity"`
+	Price       float64 `json:"price"`
+	PriceMinor  int64   `json:"priceMinor"`
+}
+
+type PriceListResponse struct {
+	ID               uint           `json:"id"`
+	CreatedAt        time.Time      `json:"createdAt"`
+	UpdatedAt        time.Time      `json:"updatedAt"`
+	Name             string         `json:"name"`
+	Currency         string         `json:"currency"`
+	ValidFrom        *time.Time     `json:"validFrom"`
+	ValidTo          *time.Time     `json:"validTo"`
+	Items            []ItemResponse `json:"items"`
+	CustomerIDs      []uint         `json:"customerIds"`
+	CustomerGroupIDs []uint         `json:"customerGroupIds"`
+}
+
+// ResolvePriceInput asks for the unit price of a quantity of a product for a
+// customer. The quantity defaults to 1 and the currency to the product's.
+type ResolvePriceInput struct {
+	ProductID  uint    `form:"productId" binding:"required"`
+	Quantity   float64 `form:"quantity" binding:"omitempty,gt=0"`
+	CustomerID uint    `form:"customerId"`
+	Currency   string  `form:"currency" binding:"omitempty,len=3"`
+}
+
+// PriceResolution is the effective unit price of a product. Source is price_list when
+// a price list set it, with the list and the tier's minimum quantity, or product when
+// the product's own price applies.
+type PriceResolution struct {
+	ProductID      uint      `json:"productId"`
+	CustomerID     *uint     `json:"customerId"`
+	Quantity       float64   `json:"quantity"`
+	At             time.Time `json:"at"`
+	UnitPrice      float64   `json:"unitPrice"`
+	UnitPriceMinor int64     `json:"unitPriceMinor"`
+	Total          float64   `json:"total"`
+	Currency       string    `json:"currency"`
+	Source         string    `json:"source"`
+	PriceListID    *uint     `json:"priceListId,omitempty"`
+	PriceListName  string    `json:"priceListName,omitempty"`
+	MinQuantity    *float64  `json:"minQuantity,omitempty"`
+}
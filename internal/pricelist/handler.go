@@ -0,0 +1,188 @@
+package pricelist
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/currency"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/query"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// GetPriceLists lists the price lists.
+// @Summary      List price lists
+// @Description  Lists all price lists with their items and assignments, by name.
+// @Tags         Price Lists
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {array}   PriceListResponse
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /price-lists [get]
+func (h *Handler) GetPriceLists(c *gin.Context) {
+	lists, err := h.svc.ListPriceLists(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch price lists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, lists)
+}
+
+// GetPriceList retrieves a single price list.
+// @Summary      Get a single price list
+// @Tags         Price Lists
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Price list ID"
+// @Success      200  {object}  PriceListResponse
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /price-lists/{id} [get]
+func (h *Handler) GetPriceList(c *gin.Context) {
+	list, err := h.svc.GetPriceList(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// CreatePriceList adds a price list.
+// @Summary      Create a price list
+// @Description  Adds a price list, e.g. {"name": "Key accounts 2025", "currency": "EUR", "validFrom": "2025-01-01T00:00:00Z", "items": [{"productId": 1, "price": 9.99}, {"productId": 1, "minQuantity": 100, "price": 8.99}], "customerGroupIds": [2]}. Without customers and customer groups, the list applies to everyone.
+// @Tags         Price Lists
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        list  body      PriceListInput  true  "Price list"
+// @Success      201  {object}  PriceListResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /price-lists [post]
+func (h *Handler) CreatePriceList(c *gin.Context) {
+	var input PriceListInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.svc.CreatePriceList(c.Request.Context(), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, list)
+}
+
+// UpdatePriceList replaces a price list.
+// @Summary      Update a price list
+// @Description  Replaces a price list, including all of its items and assignments.
+// @Tags         Price Lists
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id    path      int             true  "Price list ID"
+// @Param        list  body      PriceListInput  true  "Price list"
+// @Success      200  {object}  PriceListResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /price-lists/{id} [put]
+func (h *Handler) UpdatePriceList(c *gin.Context) {
+	var input PriceListInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.svc.UpdatePriceList(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// DeletePriceList deletes a price list.
+// @Summary      Delete a price list
+// @Tags         Price Lists
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Price list ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /price-lists/{id} [delete]
+func (h *Handler) DeletePriceList(c *gin.Context) {
+	if err := h.svc.DeletePriceList(c.Request.Context(), c.Param("id")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResolvePrice returns the effective unit price of a product.
+// @Summary      Resolve a product's price
+// @Description  Returns the unit price of a quantity of a product for a customer: the lowest price of the price lists valid at the time that apply to everyone, the customer or its group, taking the highest quantity tier each list has up to the quantity. Without any, the product's own price applies.
+// @Tags         Price Lists
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        productId   query     int     true   "Product ID"
+// @Param        quantity    query     number  false  "Quantity (default 1)"
+// @Param        customerId  query     int     false  "Customer ID"
+// @Param        date        query     string  false  "A date (YYYY-MM-DD, meaning midnight UTC) or RFC 3339 timestamp (default now)"
+// @Param        currency    query     string  false  "Currency of the price (default the product's)"
+// @Success      200  {object}  PriceResolution
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /price-lists/resolve [get]
+func (h *Handler) ResolvePrice(c *gin.Context) {
+	var input ResolvePriceInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	at := time.Now()
+	if date := c.Query("date"); date != "" {
+		var err error
+		if at, err = query.ParseTime(date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	resolution, err := h.svc.ResolvePrice(c.Request.Context(), input, at)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resolution)
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Price list not found"})
+	case errors.Is(err, ErrInvalidPriceList), errors.Is(err, ErrUnknownProduct), errors.Is(err, ErrUnknownCustomer),
+		errors.Is(err, ErrUnknownGroup), errors.Is(err, money.ErrUnknownCurrency), errors.Is(err, currency.ErrNoRate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,9 @@
+package pricelist
+
+import "gorm.io/gorm"
+
+// Migrate creates or updates the price_lists, price_list_items and
+// price_list_assignments tables.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&PriceList{}, &Item{}, &Assignment{})
+}
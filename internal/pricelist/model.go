@@ -0,0 +1,55 @@
+package pricelist
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PriceList is a set of product prices in one currency, valid from ValidFrom up to,
+// but excluding, ValidTo; either may be open. A list assigned to customers or customer
+// groups only applies to them; a list without assignments applies to everyone.
+type PriceList struct {
+	gorm.Model
+	OrgID       uint         `json:"-" gorm:"not null;index"`
+	Name        string       `json:"name" gorm:"not null"`
+	Currency    string       `json:"currency" gorm:"not null;size:3"`
+	ValidFrom   *time.Time   `json:"validFrom"`
+	ValidTo     *time.Time   `json:"validTo"`
+	Items       []Item       `json:"items" gorm:"foreignKey:PriceListID"`
+	Assignments []Assignment `json:"assignments" gorm:"foreignKey:PriceListID"`
+}
+
+func (PriceList) AuditEntityType() string {
+	return "price_list"
+}
+
+// Item is a product's price in a price list from a quantity on. Several items of a
+// product with different minimum quantities make up volume tiers, e.g. 9.99 from 1
+// and 8.99 from 100.
+type Item struct {
+	ID          uint    `json:"-" gorm:"primarykey"`
+	OrgID       uint    `json:"-" gorm:"not null;index"`
+	PriceListID uint    `json:"-" gorm:"not null;uniqueIndex:idx_price_list_items_list_product_quantity"`
+	ProductID   uint    `json:"productId" gorm:"not null;index;uniqueIndex:idx_price_list_items_list_product_quantity"`
+	MinQuantity float64 `json:"minQuantity" gorm:"type:numeric(18,6);not null;uniqueIndex:idx_price_list_items_list_product_quantity"`
+	// PriceMinor is the unit price in minor units of the list's currency.
+	PriceMinor int64 `json:"priceMinor" gorm:"not null"`
+}
+
+func (Item) TableName() string {
+	return "price_list_items"
+}
+
+// Assignment assigns a price list to either a customer or a customer group.
+type Assignment struct {
+	ID              uint  `json:"-" gorm:"primarykey"`
+	OrgID           uint  `json:"-" gorm:"not null;index"`
+	PriceListID     uint  `json:"-" gorm:"not null;index"`
+	CustomerID      *uint `json:"customerId" gorm:"index"`
+	CustomerGroupID *uint `json:"customerGroupId" gorm:"index"`
+}
+
+func (Assignment) TableName() string {
+	return "price_list_assignments"
+}
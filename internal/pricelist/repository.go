@@ -0,0 +1,143 @@
+package pricelist
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// appliesTo is true for price lists without assignments and for those assigned to the
+// bound customer or customer group (bound once each).
+const appliesTo = `(NOT EXISTS (SELECT 1 FROM price_list_assignments
+		WHERE price_list_assignments.price_list_id = price_lists.id)
+	OR EXISTS (SELECT 1 FROM price_list_assignments
+		WHERE price_list_assignments.price_list_id = price_lists.id
+			AND (price_list_assignments.customer_id = ? OR price_list_assignments.customer_group_id = ?)))`
+
+// Candidate is a price list item that could set a product's price: one that applies
+// to the customer at the time, for a quantity up to the one being priced.
+type Candidate struct {
+	PriceListID uint
+	Name        string
+	Currency    string
+	MinQuantity float64
+	PriceMinor  int64
+}
+
+type Repository interface {
+	List(ctx context.Context) ([]PriceList, error)
+	FindByID(ctx context.Context, id string) (*PriceList, error)
+	Save(ctx context.Context, list *PriceList) (*PriceList, error)
+	Update(ctx context.Context, list *PriceList) (*PriceList, error)
+	Delete(ctx context.Context, list *PriceList) error
+	Candidates(ctx context.Context, productID uint, quantity float64, at time.Time, customerID, groupID uint) ([]Candidate, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func withAssociations(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("product_id, min_quantity")
+	}).Preload("Assignments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	})
+}
+
+func (r *repository) List(ctx context.Context) ([]PriceList, error) {
+	var lists []PriceList
+	err := r.db.WithContext(ctx).Scopes(withAssociations).Order("name, id").Find(&lists).Error
+	return lists, err
+}
+
+func (r *repository) FindByID(ctx context.Context, id string) (*PriceList, error) {
+	var list PriceList
+	err := r.db.WithContext(ctx).Scopes(withAssociations).First(&list, id).Error
+	return &list, err
+}
+
+// Save creates the price list with its items and assignments.
+func (r *repository) Save(ctx context.Context, list *PriceList) (*PriceList, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(list).Error; err != nil {
+			return err
+		}
+		return createChildren(tx, list)
+	})
+	return list, err
+}
+
+// Update saves the price list and replaces its items and assignments.
+func (r *repository) Update(ctx context.Context, list *PriceList) (*PriceList, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(list).Omit(clause.Associations).Select("*").Updates(list).Error; err != nil {
+			return err
+		}
+		if err := deleteChildren(tx, list); err != nil {
+			return err
+		}
+		return createChildren(tx, list)
+	})
+	return list, err
+}
+
+// Delete deletes the price list; its items and assignments are deleted for good.
+func (r *repository) Delete(ctx context.Context, list *PriceList) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteChildren(tx, list); err != nil {
+			return err
+		}
+		return tx.Delete(list).Error
+	})
+}
+
+func createChildren(tx *gorm.DB, list *PriceList) error {
+	for i := range list.Items {
+		list.Items[i].ID = 0
+		list.Items[i].PriceListID = list.ID
+	}
+	for i := range list.Assignments {
+		list.Assignments[i].ID = 0
+		list.Assignments[i].PriceListID = list.ID
+	}
+	if len(list.Items) > 0 {
+		if err := tx.Create(&list.Items).Error; err != nil {
+			return err
+		}
+	}
+	if len(list.Assignments) > 0 {
+		return tx.Create(&list.Assignments).Error
+	}
+	return nil
+}
+
+func deleteChildren(tx *gorm.DB, list *PriceList) error {
+	if err := tx.Where("price_list_id = ?", list.ID).Delete(&Item{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("price_list_id = ?", list.ID).Delete(&Assignment{}).Error
+}
+
+// Candidates returns the items of the product in the price lists valid at the time that
+// apply to the customer or its group, up to the quantity. A customer or group of 0
+// means none.
+func (r *repository) Candidates(ctx context.Context, productID uint, quantity float64, at time.Time, customerID, groupID uint) ([]Candidate, error) {
+	var candidates []Candidate
+	err := r.db.WithContext(ctx).Model(&Item{}).
+		Select("price_lists.id AS price_list_id, price_lists.name, price_lists.currency, price_list_items.min_quantity, price_list_items.price_minor").
+		Joins("JOIN price_lists ON price_lists.id = price_list_items.price_list_id AND price_lists.deleted_at IS NULL").
+		Where("price_list_items.product_id = ? AND price_list_items.min_quantity <= ?", productID, quantity).
+		Where("(price_lists.valid_from IS NULL OR price_lists.valid_from <= ?)", at).
+		Where("(price_lists.valid_to IS NULL OR price_lists.valid_to > ?)", at).
+		Where(appliesTo, customerID, groupID).
+		Order("price_lists.id, price_list_items.min_quantity DESC").
+		Scan(&candidates).Error
+	return candidates, err
+}
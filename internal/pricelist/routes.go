@@ -0,0 +1,15 @@
+package pricelist
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, h *Handler) {
+	priceListRoutes := router.Group("/price-lists")
+	{
+		priceListRoutes.GET("", h.GetPriceLists)
+		priceListRoutes.POST("", h.CreatePriceList)
+		priceListRoutes.GET("/resolve", h.ResolvePrice)
+		priceListRoutes.GET("/:id", h.GetPriceList)
+		priceListRoutes.PUT("/:id", h.UpdatePriceList)
+		priceListRoutes.DELETE("/:id", h.DeletePriceList)
+	}
+}
@@ -0,0 +1,352 @@
+package pricelist
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/currency"
+	"github.com/RezaBG/Inventory-management-api/internal/customer"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+	"github.com/RezaBG/Inventory-management-api/internal/product"
+	"gorm.io/gorm"
+)
+
+// Sources of resolved prices.
+const (
+	SourcePriceList = "price_list"
+	SourceProduct   = "product"
+)
+
+var (
+	ErrInvalidPriceList = errors.New("price lists need validTo after validFrom and at most one price per product and minimum quantity")
+	ErrUnknownProduct   = errors.New("product not found")
+	ErrUnknownCustomer  = errors.New("customer not found")
+	ErrUnknownGroup     = errors.New("customer group not found")
+)
+
+// ProductCatalog looks up the products price lists set prices for.
+type ProductCatalog interface {
+	FindByID(ctx context.Context, id string) (*product.Product, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]product.Product, error)
+}
+
+// CustomerDirectory looks up the customers and customer groups price lists are
+// assigned to.
+type CustomerDirectory interface {
+	FindByID(ctx context.Context, id string) (*customer.Customer, error)
+	CountExisting(ctx context.Context, ids []uint) (int64, error)
+	CountExistingGroups(ctx context.Context, ids []uint) (int64, error)
+}
+
+// ExchangeRates looks up the exchange rates prices in other currencies are compared
+// and returned with.
+type ExchangeRates interface {
+	Table(ctx context.Context, at time.Time) (*currency.Table, error)
+}
+
+type Service interface {
+	ListPriceLists(ctx context.Context) ([]PriceListResponse, error)
+	GetPriceList(ctx context.Context, id string) (*PriceListResponse, error)
+	CreatePriceList(ctx context.Context, input PriceListInput) (*PriceListResponse, error)
+	UpdatePriceList(ctx context.Context, id string, input PriceListInput) (*PriceListResponse, error)
+	DeletePriceList(ctx context.Context, id string) error
+	ResolvePrice(ctx context.Context, input ResolvePriceInput, at time.Time) (*PriceResolution, error)
+}
+
+type service struct {
+	repo      Repository
+	products  ProductCatalog
+	customers CustomerDirectory
+	rates     ExchangeRates
+}
+
+func NewService(repo Repository, products ProductCatalog, customers CustomerDirectory, rates ExchangeRates) Service {
+	return &service{repo: repo, products: products, customers: customers, rates: rates}
+}
+
+func (s *service) ListPriceLists(ctx context.Context) ([]PriceListResponse, error) {
+	lists, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]PriceListResponse, 0, len(lists))
+	for _, list := range lists {
+		responses = append(responses, toPriceListResponse(list))
+	}
+	return responses, nil
+}
+
+func (s *service) GetPriceList(ctx context.Context, id string) (*PriceListResponse, error) {
+	list, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	response := toPriceListResponse(*list)
+	return &response, nil
+}
+
+func (s *service) CreatePriceList(ctx context.Context, input PriceListInput) (*PriceListResponse, error) {
+	list := PriceList{}
+	if err := s.apply(ctx, &list, input); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.repo.Save(ctx, &list)
+	if err != nil {
+		return nil, err
+	}
+	response := toPriceListResponse(*saved)
+	return &response, nil
+}
+
+// UpdatePriceList replaces a price list, including its items and assignments.
+func (s *service) UpdatePriceList(ctx context.Context, id string, input PriceListInput) (*PriceListResponse, error) {
+	list, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(ctx, list, input); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.Update(ctx, list)
+	if err != nil {
+		return nil, err
+	}
+	response := toPriceListResponse(*updated)
+	return &response, nil
+}
+
+func (s *service) DeletePriceList(ctx context.Context, id string) error {
+	list, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, list)
+}
+
+// apply validates the input and sets the price list's fields, items and assignments.
+func (s *service) apply(ctx context.Context, list *PriceList, input PriceListInput) error {
+	listCurrency, err := money.Normalize(input.Currency)
+	if err != nil {
+		return err
+	}
+	if input.ValidFrom != nil && input.ValidTo != nil && !input.ValidTo.After(*input.ValidFrom) {
+		return ErrInvalidPriceList
+	}
+
+	type tier struct {
+		productID   uint
+		minQuantity float64
+	}
+	seen := map[tier]bool{}
+	productIDs := map[uint]bool{}
+	items := make([]Item, 0, len(input.Items))
+	for _, item := range input.Items {
+		key := tier{item.ProductID, item.MinQuantity}
+		if seen[key] {
+			return ErrInvalidPriceList
+		}
+		seen[key] = true
+		productIDs[item.ProductID] = true
+		items = append(items, Item{
+			ProductID:   item.ProductID,
+			MinQuantity: item.MinQuantity,
+			PriceMinor:  money.ToMinor(item.Price, listCurrency),
+		})
+	}
+	if len(productIDs) > 0 {
+		ids := make([]uint, 0, len(productIDs))
+		for id := range productIDs {
+			ids = append(ids, id)
+		}
+		products, err := s.products.FindByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if len(products) != len(ids) {
+			return ErrUnknownProduct
+		}
+	}
+
+	customerIDs, groupIDs := unique(input.CustomerIDs), unique(input.CustomerGroupIDs)
+	if len(customerIDs) > 0 {
+		count, err := s.customers.CountExisting(ctx, customerIDs)
+		if err != nil {
+			return err
+		}
+		if count != int64(len(customerIDs)) {
+			return ErrUnknownCustomer
+		}
+	}
+	if len(groupIDs) > 0 {
+		count, err := s.customers.CountExistingGroups(ctx, groupIDs)
+		if err != nil {
+			return err
+		}
+		if count != int64(len(groupIDs)) {
+			return ErrUnknownGroup
+		}
+	}
+	assignments := make([]Assignment, 0, len(customerIDs)+len(groupIDs))
+	for i := range customerIDs {
+		assignments = append(assignments, Assignment{CustomerID: &customerIDs[i]})
+	}
+	for i := range groupIDs {
+		assignments = append(assignments, Assignment{CustomerGroupID: &groupIDs[i]})
+	}
+
+	list.Name = input.Name
+	list.Currency = listCurrency
+	list.ValidFrom = input.ValidFrom
+	list.ValidTo = input.ValidTo
+	list.Items = items
+	list.Assignments = assignments
+	return nil
+}
+
+// ResolvePrice returns the unit price of a quantity of a product for a customer at a
+// time. Of the price lists that apply, each offers its tier with the highest minimum
+// quantity up to the quantity, and the lowest of these offers wins. Without any, the
+// product's own price applies. Prices in other currencies are compared at the
+// exchange rates of the time.
+func (s *service) ResolvePrice(ctx context.Context, input ResolvePriceInput, at time.Time) (*PriceResolution, error) {
+	p, err := s.products.FindByID(ctx, strconv.FormatUint(uint64(input.ProductID), 10))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownProduct
+	}
+	if err != nil {
+		return nil, err
+	}
+	quantity := input.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	to := p.Currency
+	if input.Currency != "" {
+		if to, err = money.Normalize(input.Currency); err != nil {
+			return nil, err
+		}
+	}
+
+	resolution := &PriceResolution{
+		ProductID: p.ID,
+		Quantity:  quantity,
+		At:        at,
+		Currency:  to,
+		Source:    SourceProduct,
+	}
+	var groupID uint
+	if input.CustomerID != 0 {
+		c, err := s.customers.FindByID(ctx, strconv.FormatUint(uint64(input.CustomerID), 10))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnknownCustomer
+		}
+		if err != nil {
+			return nil, err
+		}
+		resolution.CustomerID = &c.ID
+		if c.GroupID != nil {
+			groupID = *c.GroupID
+		}
+	}
+
+	candidates, err := s.repo.Candidates(ctx, p.ID, quantity, at, input.CustomerID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The table is only needed for prices in another currency.
+	var table *currency.Table
+	convert := func(minor int64, from string) (float64, error) {
+		if from == to {
+			return money.ToMajor(float64(minor), to), nil
+		}
+		if table == nil {
+			if table, err = s.rates.Table(ctx, at); err != nil {
+				return 0, err
+			}
+		}
+		return table.Convert(float64(minor), from, to)
+	}
+
+	var best *Candidate
+	var unitPrice float64
+	for i, candidate := range candidates {
+		// Candidates come ordered by list and by descending minimum quantity, so the
+		// first of each list is its applicable tier.
+		if i > 0 && candidates[i-1].PriceListID == candidate.PriceListID {
+			continue
+		}
+		price, err := convert(candidate.PriceMinor, candidate.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if best == nil || price < unitPrice {
+			best, unitPrice = &candidates[i], price
+		}
+	}
+
+	if best != nil {
+		resolution.Source = SourcePriceList
+		resolution.PriceListID = &best.PriceListID
+		resolution.PriceListName = best.Name
+		resolution.MinQuantity = &best.MinQuantity
+	} else if unitPrice, err = convert(p.PriceMinor, p.Currency); err != nil {
+		return nil, err
+	}
+
+	resolution.UnitPriceMinor = money.ToMinor(unitPrice, to)
+	resolution.UnitPrice = money.ToMajor(float64(resolution.UnitPriceMinor), to)
+	resolution.Total = money.ToMajor(float64(money.ToMinor(resolution.UnitPrice*quantity, to)), to)
+	return resolution, nil
+}
+
+func toPriceListResponse(list PriceList) PriceListResponse {
+	items := make([]ItemResponse, 0, len(list.Items))
+	for _, item := range list.Items {
+		items = append(items, ItemResponse{
+			ProductID:   item.ProductID,
+			MinQuantity: item.MinQuantity,
+			Price:       money.ToMajor(float64(item.PriceMinor), list.Currency),
+			PriceMinor:  item.PriceMinor,
+		})
+	}
+	customerIDs, groupIDs := []uint{}, []uint{}
+	for _, assignment := range list.Assignments {
+		if assignment.CustomerID != nil {
+			customerIDs = append(customerIDs, *assignment.CustomerID)
+		}
+		if assignment.CustomerGroupID != nil {
+			groupIDs = append(groupIDs, *assignment.CustomerGroupID)
+		}
+	}
+
+	return PriceListResponse{
+		ID:               list.ID,
+		CreatedAt:        list.CreatedAt,
+		UpdatedAt:        list.UpdatedAt,
+		Name:             list.Name,
+		Currency:         list.Currency,
+		ValidFrom:        list.ValidFrom,
+		ValidTo:          list.ValidTo,
+		Items:            items,
+		CustomerIDs:      customerIDs,
+		CustomerGroupIDs: groupIDs,
+	}
+}
+
+func unique(ids []uint) []uint {
+	seen := map[uint]bool{}
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
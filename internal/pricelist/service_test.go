@@ -0,0 +1,165 @@
+package pricelist
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/currency"
+	"github.com/RezaBG/Inventory-management-api/internal/customer"
+	"github.com/RezaBG/Inventory-management-api/internal/product"
+	"gorm.io/gorm"
+)
+
+// fakeLists, fakeCatalog, fakeCustomers and fakeRates keep what the tests need in
+// memory. Methods the tests don't need panic through the embedded nil interfaces.
+type fakeLists struct {
+	Repository
+	lists []PriceList
+}
+
+// Candidates filters the items by assignment and quantity and orders them like the
+// query does. The tests use lists without validity periods.
+func (r *fakeLists) Candidates(ctx context.Context, productID uint, quantity float64, at time.Time, customerID, groupID uint) ([]Candidate, error) {
+	var candidates []Candidate
+	for _, list := range r.lists {
+		applies := len(list.Assignments) == 0
+		for _, assignment := range list.Assignments {
+			if (assignment.CustomerID != nil && *assignment.CustomerID == customerID) ||
+				(assignment.CustomerGroupID != nil && *assignment.CustomerGroupID == groupID) {
+				applies = true
+			}
+		}
+		if !applies {
+			continue
+		}
+		for _, item := range list.Items {
+			if item.ProductID == productID && item.MinQuantity <= quantity {
+				candidates = append(candidates, Candidate{
+					PriceListID: list.ID,
+					Name:        list.Name,
+					Currency:    list.Currency,
+					MinQuantity: item.MinQuantity,
+					PriceMinor:  item.PriceMinor,
+				})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].PriceListID != candidates[j].PriceListID {
+			return candidates[i].PriceListID < candidates[j].PriceListID
+		}
+		return candidates[i].MinQuantity > candidates[j].MinQuantity
+	})
+	return candidates, nil
+}
+
+type fakeCatalog struct {
+	ProductCatalog
+	products []product.Product
+}
+
+func (c fakeCatalog) FindByID(ctx context.Context, id string) (*product.Product, error) {
+	for i := range c.products {
+		if strconv.FormatUint(uint64(c.products[i].ID), 10) == id {
+			return &c.products[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeCustomers struct {
+	CustomerDirectory
+	customers []customer.Customer
+}
+
+func (c fakeCustomers) FindByID(ctx context.Context, id string) (*customer.Customer, error) {
+	for i := range c.customers {
+		if strconv.FormatUint(uint64(c.customers[i].ID), 10) == id {
+			return &c.customers[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeRates struct{}
+
+func (fakeRates) Table(ctx context.Context, at time.Time) (*currency.Table, error) {
+	return currency.NewTable([]currency.ExchangeRate{{Base: "USD", Quote: "EUR", Rate: 0.9}}), nil
+}
+
+func TestResolvePrice(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	group, vip := uint(5), uint(2)
+
+	s := &service{
+		repo: &fakeLists{lists: []PriceList{
+			{
+				Model: gorm.Model{ID: 1}, Name: "Retail", Currency: "EUR",
+				Items: []Item{{ProductID: 1, MinQuantity: 1, PriceMinor: 950}, {ProductID: 1, MinQuantity: 100, PriceMinor: 800}},
+			},
+			{
+				Model: gorm.Model{ID: 2}, Name: "Wholesale", Currency: "EUR",
+				Items:       []Item{{ProductID: 1, MinQuantity: 1, PriceMinor: 900}},
+				Assignments: []Assignment{{CustomerGroupID: &group}},
+			},
+			{
+				Model: gorm.Model{ID: 3}, Name: "VIP", Currency: "USD",
+				Items:       []Item{{ProductID: 1, MinQuantity: 1, PriceMinor: 900}},
+				Assignments: []Assignment{{CustomerID: &vip}},
+			},
+		}},
+		products: fakeCatalog{products: []product.Product{
+			{Model: gorm.Model{ID: 1}, PriceMinor: 1000, Currency: "EUR"},
+			{Model: gorm.Model{ID: 2}, PriceMinor: 1250, Currency: "EUR"},
+		}},
+		customers: fakeCustomers{customers: []customer.Customer{
+			{Model: gorm.Model{ID: 1}, GroupID: &group},
+			{Model: gorm.Model{ID: 2}},
+			{Model: gorm.Model{ID: 3}},
+		}},
+		rates: fakeRates{},
+	}
+
+	tests := []struct {
+		name       string
+		input      ResolvePriceInput
+		wantPrice  float64
+		wantList   uint
+		wantSource string
+	}{
+		{"list for everyone", ResolvePriceInput{ProductID: 1, Quantity: 1}, 9.50, 1, SourcePriceList},
+		{"highest tier up to the quantity", ResolvePriceInput{ProductID: 1, Quantity: 150}, 8.00, 1, SourcePriceList},
+		{"lower price of the customer's group", ResolvePriceInput{ProductID: 1, Quantity: 1, CustomerID: 1}, 9.00, 2, SourcePriceList},
+		{"lower tier of a list for everyone", ResolvePriceInput{ProductID: 1, Quantity: 100, CustomerID: 1}, 8.00, 1, SourcePriceList},
+		// 9.00 USD is 8.10 EUR.
+		{"lower price in another currency", ResolvePriceInput{ProductID: 1, Quantity: 1, CustomerID: 2}, 8.10, 3, SourcePriceList},
+		{"other customers' lists don't apply", ResolvePriceInput{ProductID: 1, Quantity: 1, CustomerID: 3}, 9.50, 1, SourcePriceList},
+		{"product price without a list", ResolvePriceInput{ProductID: 2, Quantity: 1}, 12.50, 0, SourceProduct},
+		// 12.50 EUR is 13.89 USD.
+		{"product price in another currency", ResolvePriceInput{ProductID: 2, Quantity: 1, Currency: "USD"}, 13.89, 0, SourceProduct},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolution, err := s.ResolvePrice(context.Background(), tt.input, now)
+			if err != nil {
+				t.Fatalf("ResolvePrice: %v", err)
+			}
+			if resolution.UnitPrice != tt.wantPrice || resolution.Source != tt.wantSource {
+				t.Errorf("price = %v from %s, want %v from %s", resolution.UnitPrice, resolution.Source, tt.wantPrice, tt.wantSource)
+			}
+			var list uint
+			if resolution.PriceListID != nil {
+				list = *resolution.PriceListID
+			}
+			if list != tt.wantList {
+				t.Errorf("price list = %d, want %d", list, tt.wantList)
+			}
+			if want := tt.wantPrice * tt.input.Quantity; resolution.Total != want {
+				t.Errorf("total = %v, want %v", resolution.Total, want)
+			}
+		})
+	}
+}
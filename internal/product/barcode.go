@@ -0,0 +1,94 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidBarcode = errors.New("barcodes must be a GTIN-8, UPC-A, EAN-13 or GTIN-14 with a valid check digit")
+	ErrInvalidSKU     = errors.New("SKUs may only contain letters, digits, dots, hyphens and underscores, and must start with a letter or digit")
+	ErrSKUInUse       = errors.New("another product already has this SKU")
+	ErrBarcodeInUse   = errors.New("another product already has this barcode")
+
+	skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+// Barcode is a GTIN printed on a product or its packaging. A product can have several,
+// e.g. an EAN-13 on the item and a GTIN-14 on the case.
+type Barcode struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	OrgID     uint `gorm:"not null;uniqueIndex:idx_product_barcodes_org_gtin"`
+	ProductID uint `gorm:"not null;index"`
+	// Code is the barcode as it was entered; GTIN is the same number padded to 14
+	// digits, so the UPC-A and EAN-13 forms of a code are recognised as the same.
+	Code string `gorm:"not null"`
+	GTIN string `gorm:"not null;size:14;uniqueIndex:idx_product_barcodes_org_gtin"`
+}
+
+func (Barcode) TableName() string {
+	return "product_barcodes"
+}
+
+func (Barcode) AuditEntityType() string {
+	return "product_barcode"
+}
+
+// NormalizeGTIN validates a GTIN-8, UPC-A (GTIN-12), EAN-13 or GTIN-14 and returns it
+// padded to 14 digits.
+func NormalizeGTIN(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidBarcode, code)
+	}
+
+	// The check digit is the last one; the others are weighted 3, 1, 3, ... from the right.
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if digit < 0 || digit > 9 {
+			return "", fmt.Errorf("%w: %q", ErrInvalidBarcode, code)
+		}
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	if check := int(code[len(code)-1] - '0'); check != (10-sum%10)%10 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidBarcode, code)
+	}
+
+	return strings.Repeat("0", 14-len(code)) + code, nil
+}
+
+// newBarcodes validates codes and drops duplicates.
+func newBarcodes(codes []string) ([]Barcode, error) {
+	barcodes := make([]Barcode, 0, len(codes))
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		gtin, err := NormalizeGTIN(code)
+		if err != nil {
+			return nil, err
+		}
+		if seen[gtin] {
+			continue
+		}
+		seen[gtin] = true
+		barcodes = append(barcodes, Barcode{Code: strings.TrimSpace(code), GTIN: gtin})
+	}
+	return barcodes, nil
+}
+
+func barcodeCodes(barcodes []Barcode) []string {
+	codes := make([]string, 0, len(barcodes))
+	for _, barcode := range barcodes {
+		codes = append(codes, barcode.Code)
+	}
+	return codes
+}
@@ -0,0 +1,50 @@
+package product
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeGTIN(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"96385074", "00000096385074"},       // EAN-8
+		{"012345678905", "00012345678905"},   // UPC-A
+		{"4006381333931", "04006381333931"},  // EAN-13
+		{"00012345600012", "00012345600012"}, // GTIN-14
+		{" 4006381333931\n", "04006381333931"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeGTIN(tt.code)
+		if err != nil {
+			t.Errorf("NormalizeGTIN(%q): %v", tt.code, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeGTIN(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeGTINRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"empty", ""},
+		{"wrong length", "1234567890"},
+		{"wrong check digit", "4006381333932"},
+		{"letter", "40063813339a1"},
+		{"letter as check digit", "400638133393x"},
+		{"sign", "-400638133393"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NormalizeGTIN(tt.code); !errors.Is(err, ErrInvalidBarcode) {
+				t.Errorf("NormalizeGTIN(%q) error = %v, want ErrInvalidBarcode", tt.code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package product
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// MaxBOMDepth is how many levels deep a bill of materials may be. Bills of materials
+// that would be deeper are refused, so exploding one never stops early.
+const MaxBOMDepth = 10
+
+type ProductType string
+
+const (
+	// Standard products have stock of their own; they may still have a bill of
+	// materials they are assembled from.
+	Standard ProductType = "standard"
+	// Kits are bundles with no stock of their own: what is available follows from the
+	// stock of their components.
+	Kit ProductType = "kit"
+)
+
+var (
+	ErrInvalidBOM       = errors.New("components need a quantity above zero, valid in their base unit, and may appear once each")
+	ErrUnknownComponent = errors.New("component not found")
+	ErrBOMCycle         = errors.New("a product can't be a component of itself, directly or through its components")
+	ErrBOMTooDeep       = errors.New("bills of materials can be at most 10 levels deep, counting the products this one is part of")
+	ErrVariantsInBOM    = errors.New("products with variants can't be part of a bill of materials")
+	ErrUsedAsComponent  = errors.New("the product is a component of another product; remove it from that bill of materials first")
+	ErrKitHasStock      = errors.New("the product has stock; adjust it to zero before making it a kit")
+)
+
+// BOMLine is a component of a product's bill of materials: the quantity of the
+// component, in its base unit, that goes into one of the product.
+type BOMLine struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	OrgID       uint    `gorm:"not null;index"`
+	ProductID   uint    `gorm:"not null;uniqueIndex:idx_product_bom_lines_product_component"`
+	ComponentID uint    `gorm:"not null;index;uniqueIndex:idx_product_bom_lines_product_component"`
+	Component   Product `gorm:"foreignKey:ComponentID"`
+	Quantity    float64 `gorm:"type:numeric(18,6);not null"`
+}
+
+func (BOMLine) TableName() string {
+	return "product_bom_lines"
+}
+
+func (BOMLine) AuditEntityType() string {
+	return "product_bom_line"
+}
+
+// BOMEdge is a line of a bill of materials with the type of its component, as loaded
+// for exploding bills of materials.
+type BOMEdge struct {
+	ProductID     uint
+	ComponentID   uint
+	Quantity      float64
+	ComponentType ProductType
+}
+
+// ThroughKits expands kit components only. Kits have no stock of their own, so this
+// explodes a bill of materials down to the products whose stock it takes.
+func ThroughKits(edge BOMEdge) bool {
+	return edge.ComponentType == Kit
+}
+
+// Explode returns the quantities of the components that go into the given quantity of
+// a product. Components for which expand returns true are replaced by their own
+// components, if they have any; a nil expand explodes every level.
+func Explode(edges []BOMEdge, productID uint, quantity float64, expand func(BOMEdge) bool) map[uint]float64 {
+	children := make(map[uint][]BOMEdge)
+	for _, edge := range edges {
+		children[edge.ProductID] = append(children[edge.ProductID], edge)
+	}
+
+	requirements := make(map[uint]float64)
+	var walk func(id uint, quantity float64, depth int)
+	walk = func(id uint, quantity float64, depth int) {
+		for _, edge := range children[id] {
+			needed := quantity * edge.Quantity
+			if depth < MaxBOMDepth && len(children[edge.ComponentID]) > 0 && (expand == nil || expand(edge)) {
+				walk(edge.ComponentID, needed, depth+1)
+				continue
+			}
+			requirements[edge.ComponentID] += needed
+		}
+	}
+	walk(productID, quantity, 1)
+	return requirements
+}
+
+// checkBOMShape makes sure that giving a product the components keeps every bill of
+// materials free of cycles and at most MaxBOMDepth levels deep. edges are the lines
+// below the components, as returned by BOMEdges, and above is how many levels of bills
+// of materials the product is part of.
+func checkBOMShape(productID uint, componentIDs []uint, edges []BOMEdge, above int) error {
+	children := make(map[uint][]uint)
+	for _, edge := range edges {
+		children[edge.ProductID] = append(children[edge.ProductID], edge.ComponentID)
+	}
+
+	// height is how many levels deep the bill of materials of a product is.
+	heights := make(map[uint]int)
+	visiting := make(map[uint]bool)
+	var height func(id uint) (int, error)
+	height = func(id uint) (int, error) {
+		if id == productID || visiting[id] {
+			return 0, ErrBOMCycle
+		}
+		if h, ok := heights[id]; ok {
+			return h, nil
+		}
+		visiting[id] = true
+		h := 0
+		for _, child := range children[id] {
+			childHeight, err := height(child)
+			if err != nil {
+				return 0, err
+			}
+			h = max(h, childHeight+1)
+		}
+		visiting[id] = false
+		heights[id] = h
+		return h, nil
+	}
+
+	depth := 0
+	for _, id := range componentIDs {
+		h, err := height(id)
+		if err != nil {
+			return err
+		}
+		depth = max(depth, h+1)
+	}
+	// BOMEdges stops at MaxBOMDepth levels, so a deeper bill of materials, or a cycle
+	// longer than that, shows up as one that is too deep.
+	if above+depth > MaxBOMDepth {
+		return ErrBOMTooDeep
+	}
+	return nil
+}
+
+// Available returns how many of a product can be made from the stock of its
+// components, given what one of it needs. A product without components is never
+// available.
+func Available(requirements map[uint]float64, stock map[uint]float64) float64 {
+	if len(requirements) == 0 {
+		return 0
+	}
+
+	available := math.Inf(1)
+	for id, needed := range requirements {
+		// Allow for the rounding errors of decimal quantities, e.g. 0.3 / 0.1.
+		available = math.Min(available, math.Floor(stock[id]/needed+1e-9))
+	}
+	return math.Max(available, 0)
+}
@@ -0,0 +1,126 @@
+package product
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// A kit (1) of two assemblies (2) and a cable (3). An assembly (2) is built from four
+// screws (4) and a subkit (5) of 0.5 m of tape (6).
+var testEdges = []BOMEdge{
+	{ProductID: 1, ComponentID: 2, Quantity: 2, ComponentType: Standard},
+	{ProductID: 1, ComponentID: 3, Quantity: 1, ComponentType: Standard},
+	{ProductID: 2, ComponentID: 4, Quantity: 4, ComponentType: Standard},
+	{ProductID: 2, ComponentID: 5, Quantity: 1, ComponentType: Kit},
+	{ProductID: 5, ComponentID: 6, Quantity: 0.5, ComponentType: Standard},
+}
+
+func TestExplode(t *testing.T) {
+	tests := []struct {
+		name      string
+		productID uint
+		quantity  float64
+		expand    func(BOMEdge) bool
+		want      map[uint]float64
+	}{
+		{"every level", 1, 3, nil, map[uint]float64{3: 3, 4: 24, 6: 3}},
+		{"through kits only", 1, 3, ThroughKits, map[uint]float64{2: 6, 3: 3}},
+		{"kit below a standard product", 2, 1, ThroughKits, map[uint]float64{4: 4, 6: 0.5}},
+		{"no components", 3, 1, nil, map[uint]float64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Explode(testEdges, tt.productID, tt.quantity, tt.expand)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Explode = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplodeStopsAtMaxDepth(t *testing.T) {
+	// A chain deeper than MaxBOMDepth: product i is made of one of product i+1.
+	var edges []BOMEdge
+	for i := uint(1); i <= MaxBOMDepth+5; i++ {
+		edges = append(edges, BOMEdge{ProductID: i, ComponentID: i + 1, Quantity: 1})
+	}
+	want := map[uint]float64{MaxBOMDepth + 1: 1}
+	if got := Explode(edges, 1, 1, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("Explode = %v, want %v", got, want)
+	}
+}
+
+// chain returns the lines of a chain of kits from first to last, each made of one of
+// the next.
+func chain(first, last uint) []BOMEdge {
+	var edges []BOMEdge
+	for i := first; i < last; i++ {
+		edges = append(edges, BOMEdge{ProductID: i, ComponentID: i + 1, Quantity: 1, ComponentType: Kit})
+	}
+	return edges
+}
+
+func TestCheckBOMShape(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []uint
+		edges      []BOMEdge
+		above      int
+		want       error
+	}{
+		{"no components", nil, nil, 0, nil},
+		{"tree below the product", []uint{2, 3}, testEdges[2:], 0, nil},
+		{"product as a component", []uint{2}, []BOMEdge{{ProductID: 2, ComponentID: 1, Quantity: 1}}, 0, ErrBOMCycle},
+		{"product deep below a component", []uint{2}, append(chain(2, 8), BOMEdge{ProductID: 8, ComponentID: 1, Quantity: 1}), 0, ErrBOMCycle},
+		// BOMEdges doesn't return the line closing a cycle this long.
+		{"cycle longer than MaxBOMDepth", []uint{2}, chain(2, 2+MaxBOMDepth), 0, ErrBOMTooDeep},
+		{"exactly MaxBOMDepth levels", []uint{2}, chain(2, 1+MaxBOMDepth), 0, nil},
+		{"one level too deep", []uint{2}, chain(2, 2+MaxBOMDepth), 0, ErrBOMTooDeep},
+		{"too deep counting the products above", []uint{2}, chain(2, MaxBOMDepth), 2, ErrBOMTooDeep},
+		{"deep enough counting the products above", []uint{2}, chain(2, MaxBOMDepth), 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkBOMShape(1, tt.components, tt.edges, tt.above); !errors.Is(err, tt.want) {
+				t.Errorf("checkBOMShape = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplodeAcceptedBOMReachesLeaves(t *testing.T) {
+	// Kits all the way down to a standard product at the deepest level allowed; were
+	// the last kit treated as a leaf, assembling would take stock out of a kit.
+	edges := append(chain(2, MaxBOMDepth), BOMEdge{ProductID: MaxBOMDepth, ComponentID: 100, Quantity: 3, ComponentType: Standard})
+	if err := checkBOMShape(1, []uint{2}, edges, 0); err != nil {
+		t.Fatalf("checkBOMShape: %v", err)
+	}
+	edges = append(edges, BOMEdge{ProductID: 1, ComponentID: 2, Quantity: 1, ComponentType: Kit})
+	want := map[uint]float64{100: 6}
+	if got := Explode(edges, 1, 2, ThroughKits); !reflect.DeepEqual(got, want) {
+		t.Errorf("Explode = %v, want %v", got, want)
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	tests := []struct {
+		name         string
+		requirements map[uint]float64
+		stock        map[uint]float64
+		want         float64
+	}{
+		{"no components", map[uint]float64{}, map[uint]float64{1: 10}, 0},
+		{"limited by the scarcest component", map[uint]float64{1: 2, 2: 1}, map[uint]float64{1: 7, 2: 10}, 3},
+		{"missing stock", map[uint]float64{1: 1, 2: 1}, map[uint]float64{1: 5}, 0},
+		{"negative stock", map[uint]float64{1: 1}, map[uint]float64{1: -4}, 0},
+		{"decimal quantities", map[uint]float64{1: 0.1}, map[uint]float64{1: 0.3}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Available(tt.requirements, tt.stock); got != tt.want {
+				t.Errorf("Available = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package product
+
+import (
+	"context"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/currency"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+)
+
+// ExchangeRates looks up the exchange rates prices are converted with.
+type ExchangeRates interface {
+	Table(ctx context.Context, at time.Time) (*currency.Table, error)
+}
+
+// ConvertPrices adds the prices of the products and their variants converted to a
+// currency at the current exchange rates. It does nothing without a currency.
+func (s *service) ConvertPrices(ctx context.Context, to string, products ...*ProductResponse) error {
+	if to == "" {
+		return nil
+	}
+	to, err := money.Normalize(to)
+	if err != nil {
+		return err
+	}
+	table, err := s.rates.Table(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		product.Converted, err = convertPrice(table, product.PriceMinor, product.Currency, to)
+		if err != nil {
+			return err
+		}
+		for i := range product.Variants {
+			product.Variants[i].Converted, err = convertPrice(table, product.Variants[i].PriceMinor, product.Currency, to)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func convertPrice(table *currency.Table, minor int64, from, to string) (*ConvertedPrice, error) {
+	rate, err := table.Rate(from, to)
+	if err != nil {
+		return nil, err
+	}
+	price, err := table.Convert(float64(minor), from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &ConvertedPrice{
+		Currency: to,
+		Rate:     rate,
+		Price:    money.ToMajor(float64(money.ToMinor(price, to)), to),
+	}, nil
+}
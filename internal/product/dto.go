@@ -1,21 +1,255 @@
 package product
 
+import "time"
+
+// CreateProductInput is a new product. Barcodes are GTIN-8, UPC-A, EAN-13 or GTIN-14
+// codes; categoryIds are the secondary categories. Stock is kept in the base unit; the
+// purchase and sales units must convert to it, by a conversion or by their dimension.
+// The type defaults to standard; kits have no stock of their own. The price is in major
+// units of the currency, which defaults to DEFAULT_CURRENCY.
 type CreateProductInput struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"required,gt=0"`
+	SKU               string                 `json:"sku" binding:"required,max=64"`
+	Name              string                 `json:"name" binding:"required"`
+	Description       string                 `json:"description"`
+	Price             float64                `json:"price" binding:"required,gt=0"`
+	Currency          string                 `json:"currency" binding:"omitempty,len=3"`
+	Type              ProductType            `json:"type" binding:"omitempty,oneof=standard kit"`
+	Status            ProductStatus          `json:"status" binding:"omitempty,oneof=draft active discontinued"`
+	Barcodes          []string               `json:"barcodes"`
+	PrimaryCategoryID *uint                  `json:"primaryCategoryId"`
+	CategoryIDs       []uint                 `json:"categoryIds"`
+	Attributes        map[string]interface{} `json:"attributes"`
+	BaseUnitID        *uint                  `json:"baseUnitId"`
+	PurchaseUnitID    *uint                  `json:"purchaseUnitId"`
+	SalesUnitID       *uint                  `json:"salesUnitId"`
+	Conversions       []ConversionInput      `json:"conversions" binding:"dive"`
 }
 
+// UpdateProductInput holds a product's editable fields. PUT replaces all of them; PATCH
+// applies a patch to their current values. Without a currency, the price is in the
+// product's current currency; without a status, the product keeps its status.
 type UpdateProductInput struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"gt=0"`
+	SKU               string                 `json:"sku" binding:"required,max=64"`
+	Name              string                 `json:"name" binding:"required"`
+	Description       string                 `json:"description"`
+	Price             float64                `json:"price" binding:"required,gt=0"`
+	Currency          string                 `json:"currency" binding:"omitempty,len=3"`
+	Type              ProductType            `json:"type" binding:"omitempty,oneof=standard kit"`
+	Status            ProductStatus          `json:"status" binding:"omitempty,oneof=draft active discontinued"`
+	Barcodes          []string               `json:"barcodes"`
+	PrimaryCategoryID *uint                  `json:"primaryCategoryId"`
+	CategoryIDs       []uint                 `json:"categoryIds"`
+	Attributes        map[string]interface{} `json:"attributes"`
+	BaseUnitID        *uint                  `json:"baseUnitId"`
+	PurchaseUnitID    *uint                  `json:"purchaseUnitId"`
+	SalesUnitID       *uint                  `json:"salesUnitId"`
+	Conversions       []ConversionInput      `json:"conversions" binding:"dive"`
+}
+
+// ConversionInput is how many of the product's base unit one of a unit holds, e.g. 24
+// for a case of 24 bottles.
+type ConversionInput struct {
+	UnitID uint    `json:"unitId" binding:"required"`
+	Factor float64 `json:"factor" binding:"required,gt=0"`
 }
 
+type ConversionResponse struct {
+	UnitID uint    `json:"unitId"`
+	Unit   string  `json:"unit"`
+	Factor float64 `json:"factor"`
+}
+
+// ConvertedPrice is a price converted to another currency at the current exchange
+// rate, rounded to that currency's minor unit.
+type ConvertedPrice struct {
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"rate"`
+	Price    float64 `json:"price"`
+}
+
+// ProductResponse is a product with its stock. Price is in major units of the
+// currency and priceMinor in minor units, e.g. 19.99 and 1999 for EUR; converted is
+// only set when another currency is requested.
 type ProductResponse struct {
-	ID                 uint    `json:"id"`
-	Name               string  `json:"name"`
-	Description        string  `json:"description"`
-	Price              float64 `json:"price"`
-	CalculatedQuantity int     `json:"quantity"`
+	ID                 uint                   `json:"id"`
+	SKU                string                 `json:"sku"`
+	Name               string                 `json:"name"`
+	Description        string                 `json:"description"`
+	Price              float64                `json:"price"`
+	PriceMinor         int64                  `json:"priceMinor"`
+	Currency           string                 `json:"currency"`
+	Converted          *ConvertedPrice        `json:"converted,omitempty"`
+	Type               ProductType            `json:"type"`
+	Status             ProductStatus          `json:"status"`
+	Barcodes           []string               `json:"barcodes"`
+	PrimaryCategoryID  *uint                  `json:"primaryCategoryId"`
+	CategoryIDs        []uint                 `json:"categoryIds"`
+	Attributes         map[string]interface{} `json:"attributes"`
+	Options            []Option               `json:"options"`
+	Variants           []VariantResponse      `json:"variants"`
+	BaseUnitID         *uint                  `json:"baseUnitId"`
+	BaseUnit           string                 `json:"baseUnit,omitempty"`
+	PurchaseUnitID     *uint                  `json:"purchaseUnitId"`
+	SalesUnitID        *uint                  `json:"salesUnitId"`
+	Conversions        []ConversionResponse   `json:"conversions"`
+	CalculatedQuantity float64                `json:"quantity"`
+	Attachments        []AttachmentLink       `json:"attachments"`
+	Version            uint                   `json:"version"`
+}
+
+// AttachmentLink is a file attached to a product. ThumbnailURL is only set for
+// images.
+type AttachmentLink struct {
+	ID           uint   `json:"id"`
+	FileName     string `json:"fileName"`
+	ContentType  string `json:"contentType"`
+	Size         int64  `json:"size"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl,omitempty"`
+}
+
+// VariantResponse is a variant with its own stock. Price is the variant's price
+// override or, without one, the product's price, both in the product's currency.
+type VariantResponse struct {
+	ID            uint              `json:"id"`
+	SKU           string            `json:"sku"`
+	OptionValues  map[string]string `json:"optionValues"`
+	PriceOverride *float64          `json:"priceOverride"`
+	Price         float64           `json:"price"`
+	PriceMinor    int64             `json:"priceMinor"`
+	Converted     *ConvertedPrice   `json:"converted,omitempty"`
+	Quantity      float64           `json:"quantity"`
+}
+
+type OptionInput struct {
+	Name   string   `json:"name" binding:"required"`
+	Values []string `json:"values" binding:"required,min=1,dive,required"`
+}
+
+// GenerateVariantsInput is an option matrix, e.g. sizes S, M, L by colours red and
+// blue, which generates a variant for each of the six combinations.
+type GenerateVariantsInput struct {
+	Options []OptionInput `json:"options" binding:"required,min=1,dive"`
+}
+
+// UpdateVariantInput is a variant's SKU and price override, in major units of the
+// product's currency.
+type UpdateVariantInput struct {
+	SKU           string   `json:"sku" binding:"required,max=64"`
+	PriceOverride *float64 `json:"priceOverride" binding:"omitempty,gt=0"`
+}
+
+// BOMComponentInput is a component of a bill of materials: the quantity of it, in its
+// base unit, that goes into one of the product.
+type BOMComponentInput struct {
+	ProductID uint    `json:"productId" binding:"required"`
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+}
+
+// SetBOMInput replaces a product's bill of materials; no components removes it.
+type SetBOMInput struct {
+	Components []BOMComponentInput `json:"components" binding:"dive"`
+}
+
+type BOMComponentResponse struct {
+	ProductID uint        `json:"productId"`
+	SKU       string      `json:"sku"`
+	Name      string      `json:"name"`
+	Type      ProductType `json:"type"`
+	Quantity  float64     `json:"quantity"`
+	Unit      string      `json:"unit,omitempty"`
+}
+
+// BOMResponse is a product's bill of materials: its direct components and the
+// explosion of all levels into the components that have no bill of materials
+// themselves, each for one of the product.
+type BOMResponse struct {
+	ProductID  uint                   `json:"productId"`
+	Type       ProductType            `json:"type"`
+	Components []BOMComponentResponse `json:"components"`
+	Explosion  []BOMComponentResponse `json:"explosion"`
+	Version    uint                   `json:"version"`
+}
+
+// SchedulePriceInput is a price change that takes effect at a future time. Without a
+// currency, the price is in the product's current currency.
+type SchedulePriceInput struct {
+	Price       float64   `json:"price" binding:"required,gt=0"`
+	Currency    string    `json:"currency" binding:"omitempty,len=3"`
+	EffectiveAt time.Time `json:"effectiveAt" binding:"required"`
+}
+
+// PriceChangeResponse is a change of a product's price. effectiveAt is when it took
+// effect, or null while a scheduled change is pending.
+type PriceChangeResponse struct {
+	ID           uint       `json:"id"`
+	OldPrice     *float64   `json:"oldPrice"`
+	OldCurrency  string     `json:"oldCurrency,omitempty"`
+	NewPrice     float64    `json:"newPrice"`
+	NewCurrency  string     `json:"newCurrency"`
+	ScheduledFor *time.Time `json:"scheduledFor,omitempty"`
+	EffectiveAt  *time.Time `json:"effectiveAt"`
+	UserID       *uint      `json:"userId"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// PriceHistoryResponse is a product's current price, the changes that led to it,
+// newest first, and the scheduled changes, soonest first.
+type PriceHistoryResponse struct {
+	ProductID uint                  `json:"productId"`
+	Price     float64               `json:"price"`
+	Currency  string                `json:"currency"`
+	History   []PriceChangeResponse `json:"history"`
+	Scheduled []PriceChangeResponse `json:"scheduled"`
+}
+
+// PriceAtResponse is the price a product had at a time, and since when. Converted is
+// the price in a requested currency at the exchange rates of that time.
+type PriceAtResponse struct {
+	ProductID   uint            `json:"productId"`
+	At          time.Time       `json:"at"`
+	Price       float64         `json:"price"`
+	Currency    string          `json:"currency"`
+	Converted   *ConvertedPrice `json:"converted,omitempty"`
+	EffectiveAt time.Time       `json:"effectiveAt"`
+}
+
+// ListProductsFilter narrows down a product list to a category and its descendants
+// and to products with the given attribute values. Archived products are only listed
+// with IncludeArchived.
+type ListProductsFilter struct {
+	CategoryID      uint
+	Attributes      map[string]string
+	IncludeArchived bool
+}
+
+type ProductListResponse struct {
+	Data     []ProductResponse `json:"data"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"pageSize"`
+	Total    int64             `json:"total"`
+}
+
+type SearchProductsInput struct {
+	Query    string `form:"q" binding:"required"`
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"pageSize,default=20" binding:"min=1,max=100"`
+	// Archived products are only found with IncludeArchived.
+	IncludeArchived bool `form:"include_archived"`
+}
+
+// ProductSearchResult is a product found by a search. The highlights mark the matching
+// words with <mark> tags; the rest of their text is HTML-escaped.
+type ProductSearchResult struct {
+	ProductResponse
+	Rank                 float64 `json:"rank"`
+	NameHighlight        string  `json:"nameHighlight"`
+	DescriptionHighlight string  `json:"descriptionHighlight"`
+}
+
+type ProductSearchResponse struct {
+	Data     []ProductSearchResult `json:"data"`
+	Page     int                   `json:"page"`
+	PageSize int                   `json:"pageSize"`
+	Total    int64                 `json:"total"`
 }
@@ -1,9 +1,19 @@
 package product
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/RezaBG/Inventory-management-api/internal/category"
+	"github.com/RezaBG/Inventory-management-api/internal/currency"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/db"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/etag"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/patch"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -16,30 +26,141 @@ func NewHandler(svc Service) *Handler {
 	return &Handler{svc: svc}
 }
 
+// GetProducts lists products a page at a time.
+// @Summary      List products
+// @Description  Lists products with their real-time inventory count. Filter with field[operator]=value, e.g. price[gte]=10, name[contains]=laptop, quantity[lt]=5 or createdAt[gte]=2024-01-01; operators are eq (the default), ne, lt, lte, gt, gte and contains. Sort with e.g. sort=-price,name. The Link header points to the first, previous, next and last pages.
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        page      query     int     false  "Page number (default 1)"
+// @Param        pageSize  query     int     false  "Page size (default 20, max 100); limit is an alias"
+// @Param        sort      query     string  false  "Comma-separated fields, prefixed with - for descending: id, sku, name, description, price, quantity, status, createdAt, updatedAt"
+// @Param        category  query     int     false  "Only products in this category or one of its subcategories"
+// @Param        attr.key  query     string  false  "Only products whose custom attribute key has this value, e.g. attr.material=steel"
+// @Param        currency  query     string  false  "Also return the prices converted to this currency"
+// @Param        include_archived  query  bool  false  "Also list archived products"
+// @Success      200  {object}  ProductListResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /products [get]
 func (h *Handler) GetProducts(c *gin.Context) {
-	products, err := h.svc.GetAllProducts()
+	// The category and attribute filters, the currency and include_archived aren't list
+	// fields; take them out first.
+	values := c.Request.URL.Query()
+	convertTo := values.Get("currency")
+	values.Del("currency")
+	filter := ListProductsFilter{Attributes: map[string]string{}}
+	var err error
+	if filter.IncludeArchived, err = includeArchived(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	values.Del("include_archived")
+	if categoryID := values.Get("category"); categoryID != "" {
+		id, err := strconv.ParseUint(categoryID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "category must be a category ID"})
+			return
+		}
+		filter.CategoryID = uint(id)
+		values.Del("category")
+	}
+	for key := range values {
+		if attribute, ok := strings.CutPrefix(key, "attr."); ok {
+			filter.Attributes[attribute] = values.Get(key)
+			values.Del(key)
+		}
+	}
+
+	params, err := query.Parse(values, listFields, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	products, err := h.svc.ListProducts(c.Request.Context(), params, filter)
 	if err != nil {
+		if errors.Is(err, ErrUnknownCategory) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
 		return
 	}
+	for i := range products.Data {
+		if err := h.svc.ConvertPrices(c.Request.Context(), convertTo, &products.Data[i]); err != nil {
+			respondLookupError(c, err)
+			return
+		}
+	}
+
+	c.Header("Link", params.Links(c.Request.URL, products.Total))
 	c.JSON(http.StatusOK, products)
 }
 
+// SearchProducts finds products by free text.
+// @Summary      Search products
+// @Description  Finds products whose name or description contains words starting with each search term, or whose name is similar to the search (so small typos still match). Results are ranked by relevance; the highlights mark matching words with <mark> tags and are otherwise HTML-escaped.
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        q         query     string  true   "Search terms"
+// @Param        page      query     int     false  "Page number (default 1)"
+// @Param        pageSize  query     int     false  "Page size (default 20, max 100)"
+// @Param        currency  query     string  false  "Also return the prices converted to this currency"
+// @Param        include_archived  query  bool  false  "Also find archived products"
+// @Success      200  {object}  ProductSearchResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /products/search [get]
+func (h *Handler) SearchProducts(c *gin.Context) {
+	var input SearchProductsInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := h.svc.SearchProducts(c.Request.Context(), input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search products"})
+		return
+	}
+	for i := range results.Data {
+		if err := h.svc.ConvertPrices(c.Request.Context(), c.Query("currency"), &results.Data[i].ProductResponse); err != nil {
+			respondLookupError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
 // GetProductByID retrieves a single product by its ID.
 // @Summary      Get a single product
-// @Description  Retrieves the details of a single product, including its real-time inventory count.
+// @Description  Retrieves the details of a single product, including its real-time inventory count. The response carries the product's version as ETag; send it back in If-None-Match to get 304 while the product is unchanged.
 // @Tags         Products
 // @Produce      json
 // @Security     BearerAuth
-// @Param        id   path      int  true  "Product ID"
+// @Security     ApiKeyAuth
+// @Param        id                path      int     true   "Product ID"
+// @Param        currency          query     string  false  "Also return the prices converted to this currency"
+// @Param        include_archived  query     bool    false  "Also find the product if it is archived"
+// @Param        If-None-Match     header    string  false  "ETag of a cached copy"
 // @Success      200  {object}  ProductResponse
+// @Success      304  "Not Modified"
+// @Failure      400  {object}  map[string]interface{}
 // @Failure      404  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
 // @Router       /products/{id} [get]
 func (h *Handler) GetProductByID(c *gin.Context) {
 	id := c.Param("id")
+	include, err := includeArchived(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	product, err := h.svc.GetProductByID(id)
+	product, err := h.svc.GetProductByID(c.Request.Context(), id, include)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
@@ -49,20 +170,102 @@ func (h *Handler) GetProductByID(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product"})
 		return
 	}
+	if err := h.svc.ConvertPrices(c.Request.Context(), c.Query("currency"), product); err != nil {
+		respondLookupError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag.Format(product.Version))
+	if etag.NotModified(c.GetHeader("If-None-Match"), product.Version) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, product)
+}
+
+// GetProductBySKU retrieves a single product by its SKU.
+// @Summary      Get a product by SKU
+// @Description  Retrieves the product with a SKU, including its real-time inventory count.
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        sku               path      string  true   "Product SKU"
+// @Param        currency          query     string  false  "Also return the prices converted to this currency"
+// @Param        include_archived  query     bool    false  "Also find the product if it is archived"
+// @Success      200  {object}  ProductResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /products/by-sku/{sku} [get]
+func (h *Handler) GetProductBySKU(c *gin.Context) {
+	include, err := includeArchived(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := h.svc.GetProductBySKU(c.Request.Context(), c.Param("sku"), include)
+	if err == nil {
+		err = h.svc.ConvertPrices(c.Request.Context(), c.Query("currency"), product)
+	}
+	if err != nil {
+		respondLookupError(c, err)
+		return
+	}
 
+	c.Header("ETag", etag.Format(product.Version))
+	c.JSON(http.StatusOK, product)
+}
+
+// GetProductByBarcode retrieves a single product by one of its barcodes.
+// @Summary      Get a product by barcode
+// @Description  Retrieves the product with a barcode (GTIN-8, UPC-A, EAN-13 or GTIN-14), as read by a scanner. A UPC-A also finds the product registered with the same code as EAN-13 or GTIN-14, and vice versa.
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        code              path      string  true   "Barcode"
+// @Param        currency          query     string  false  "Also return the prices converted to this currency"
+// @Param        include_archived  query     bool    false  "Also find the product if it is archived"
+// @Success      200  {object}  ProductResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /products/by-barcode/{code} [get]
+func (h *Handler) GetProductByBarcode(c *gin.Context) {
+	include, err := includeArchived(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := h.svc.GetProductByBarcode(c.Request.Context(), c.Param("code"), include)
+	if err == nil {
+		err = h.svc.ConvertPrices(c.Request.Context(), c.Query("currency"), product)
+	}
+	if err != nil {
+		respondLookupError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag.Format(product.Version))
 	c.JSON(http.StatusOK, product)
 }
 
 // CreateProduct creates a new product.
 // @Summary      Create a new product
-// @Description  Adds a new product to the system. The initial quantity will be 0.
+// @Description  Adds a new product to the system. The initial quantity will be 0. The SKU must be unique; barcodes must be valid GTIN-8, UPC-A, EAN-13 or GTIN-14 codes not used by another product.
 // @Tags         Products
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Param        product body CreateProductInput true "Product Information"
 // @Success      201  {object}  ProductResponse // <-- FIXED
 // @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
 // @Router       /products [post]
 func (h *Handler) CreateProduct(c *gin.Context) {
@@ -72,62 +275,547 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	createdProduct, err := h.svc.CreateNewProduct(input)
+	createdProduct, err := h.svc.CreateNewProduct(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
+		switch {
+		case errors.Is(err, ErrInvalidSKU), errors.Is(err, ErrInvalidBarcode), errors.Is(err, ErrUnknownCategory),
+			errors.Is(err, category.ErrInvalidAttributes), errors.Is(err, ErrUnknownUnit), errors.Is(err, ErrBaseUnitRequired),
+			errors.Is(err, ErrInvalidConversion), errors.Is(err, ErrNoConversion), errors.Is(err, money.ErrUnknownCurrency):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrSKUInUse), errors.Is(err, ErrBarcodeInUse):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
+		}
 		return
 	}
+	c.Header("ETag", etag.Format(createdProduct.Version))
 	c.JSON(http.StatusCreated, createdProduct)
 }
 
 // UpdateProduct updates an existing product's details.
 // @Summary      Update a product
-// @Description  Updates a product's details (e.g., name, price). Note: Quantity cannot be updated here.
+// @Description  Updates a product's details (e.g., SKU, name, price, barcodes, categories). Note: Quantity cannot be updated here. With If-Match, the update only succeeds if the product is still at that ETag.
 // @Tags         Products
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Param        id   path      int  true  "Product ID"
+// @Param        If-Match  header  string  false  "ETag the update is based on (required if REQUIRE_IF_MATCH is set)"
 // @Param        product body UpdateProductInput true "Product Update Information"
 // @Success      200  {object}  ProductResponse // <-- FIXED
 // @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
 // @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
 // @Router       /products/{id} [put]
 func (h *Handler) UpdateProduct(c *gin.Context) {
 	id := c.Param("id")
+	precondition, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+		return
+	}
+
 	var input UpdateProductInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	updatedProduct, err := h.svc.UpdateExistingProduct(id, input)
+	updatedProduct, err := h.svc.UpdateExistingProduct(c.Request.Context(), id, input, precondition)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondWriteError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag.Format(updatedProduct.Version))
+	c.JSON(http.StatusOK, updatedProduct)
+}
+
+// PatchProduct changes some of a product's details.
+// @Summary      Partially update a product
+// @Description  Applies a JSON Merge Patch (application/merge-patch+json, e.g. {"description": "..."}) or a JSON Patch (application/json-patch+json) to the product's sku, name, description, price, barcodes, categories and attributes. The patched product must be valid as a whole.
+// @Tags         Products
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id        path    int     true   "Product ID"
+// @Param        If-Match  header  string  false  "ETag the update is based on (required if REQUIRE_IF_MATCH is set)"
+// @Param        patch     body    object  true   "Merge patch or JSON Patch"
+// @Success      200  {object}  ProductResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      415  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Router       /products/{id} [patch]
+func (h *Handler) PatchProduct(c *gin.Context) {
+	id := c.Param("id")
+	precondition, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	p := patch.Patch{ContentType: c.ContentType(), Body: body}
+	updatedProduct, err := h.svc.PatchProduct(c.Request.Context(), id, p, precondition)
+	if err != nil {
+		respondWriteError(c, err)
 		return
 	}
 
+	c.Header("ETag", etag.Format(updatedProduct.Version))
 	c.JSON(http.StatusOK, updatedProduct)
 }
 
 // DeleteProduct deletes a product.
 // @Summary      Delete a product
-// @Description  Deletes a product from the system by its ID.
+// @Description  Deletes a product from the system by its ID. Products with stock or inventory history can't be deleted (409); archive them instead. With If-Match, the product is only deleted if it is still at that ETag.
 // @Tags         Products
 // @Security     BearerAuth
-// @Param        id   path      int  true  "Product ID"
+// @Security     ApiKeyAuth
+// @Param        id        path    int     true   "Product ID"
+// @Param        If-Match  header  string  false  "ETag the deletion is based on (required if REQUIRE_IF_MATCH is set)"
 // @Success      204  "No Content"
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
 // @Router       /products/{id} [delete]
 func (h *Handler) DeleteProduct(c *gin.Context) {
 	id := c.Param("id")
+	precondition, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+		return
+	}
 
-	err := h.svc.DeleteProductByID(id)
+	err = h.svc.DeleteProductByID(c.Request.Context(), id, precondition)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondWriteError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ArchiveProduct archives a product.
+// @Summary      Archive a product
+// @Description  Hides the product from lists, search and lookups unless include_archived=true, and stops its stock from moving. Its history is kept, and it can be restored. With If-Match, the product is only archived if it is still at that ETag.
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id        path    int     true   "Product ID"
+// @Param        If-Match  header  string  false  "ETag the change is based on (required if REQUIRE_IF_MATCH is set)"
+// @Success      200  {object}  ProductResponse
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Router       /products/{id}/archive [post]
+func (h *Handler) ArchiveProduct(c *gin.Context) {
+	h.changeStatus(c, h.svc.ArchiveProduct)
+}
+
+// RestoreProduct restores an archived product.
+// @Summary      Restore an archived product
+// @Description  Makes an archived product active again. With If-Match, the product is only restored if it is still at that ETag.
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id        path    int     true   "Product ID"
+// @Param        If-Match  header  string  false  "ETag the change is based on (required if REQUIRE_IF_MATCH is set)"
+// @Success      200  {object}  ProductResponse
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Router       /products/{id}/restore [post]
+func (h *Handler) RestoreProduct(c *gin.Context) {
+	h.changeStatus(c, h.svc.RestoreProduct)
+}
+
+func (h *Handler) changeStatus(c *gin.Context, change func(ctx context.Context, id string, precondition etag.Precondition) (*ProductResponse, error)) {
+	precondition, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := change(c.Request.Context(), c.Param("id"), precondition)
+	if err != nil {
+		respondWriteError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag.Format(product.Version))
+	c.JSON(http.StatusOK, product)
+}
+
+// GenerateVariants creates a product's variants from an option matrix.
+// @Summary      Generate product variants
+// @Description  Sets the product's options, e.g. [{"name": "size", "values": ["S", "M"]}, {"name": "colour", "values": ["red", "blue"]}], and creates a variant for every combination that doesn't have one yet, with a SKU derived from the product's (e.g. TSHIRT-M-RED). Existing variants are kept. At most 500 combinations.
+// @Tags         Products
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id        path    int                    true   "Product ID"
+// @Param        If-Match  header  string                 false  "ETag the change is based on (required if REQUIRE_IF_MATCH is set)"
+// @Param        options   body    GenerateVariantsInput  true   "Option matrix"
+// @Success      200  {object}  ProductResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Router       /products/{id}/variants [post]
+func (h *Handler) GenerateVariants(c *gin.Context) {
+	precondition, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input GenerateVariantsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := h.svc.GenerateVariants(c.Request.Context(), c.Param("id"), input, precondition)
+	if err != nil {
+		respondWriteError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag.Format(product.Version))
+	c.JSON(http.StatusOK, product)
+}
+
+// GetVariants lists a product's variants.
+// @Summary      List product variants
+// @Description  Lists the product's variants with their own stock and price.
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Product ID"
+// @Success      200  {array}   VariantResponse
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /products/{id}/variants [get]
+func (h *Handler) GetVariants(c *gin.Context) {
+	variants, err := h.svc.ListVariants(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondLookupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, variants)
+}
+
+// UpdateVariant updates a variant's SKU and price override.
+// @Summary      Update a product variant
+// @Description  Replaces the variant's SKU and price override; without a price override the variant has the product's price.
+// @Tags         Products
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id         path  int                 true  "Product ID"
+// @Param        variantId  path  int                 true  "Variant ID"
+// @Param        variant    body  UpdateVariantInput  true  "Variant Information"
+// @Success      200  {object}  VariantResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /products/{id}/variants/{variantId} [put]
+func (h *Handler) UpdateVariant(c *gin.Context) {
+	var input UpdateVariantInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	variant, err := h.svc.UpdateVariant(c.Request.Context(), c.Param("id"), c.Param("variantId"), input)
+	if err != nil {
+		respondWriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+// DeleteVariant deletes a variant.
+// @Summary      Delete a product variant
+// @Description  Deletes a variant that has no stock left.
+// @Tags         Products
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id         path  int  true  "Product ID"
+// @Param        variantId  path  int  true  "Variant ID"
+// @Success      204  "No Content"
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /products/{id}/variants/{variantId} [delete]
+func (h *Handler) DeleteVariant(c *gin.Context) {
+	if err := h.svc.DeleteVariant(c.Request.Context(), c.Param("id"), c.Param("variantId")); err != nil {
+		respondWriteError(c, err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// GetBOM returns a product's bill of materials.
+// @Summary      Get a product's bill of materials
+// @Description  Returns the product's direct components and the explosion of all levels into the components that have no bill of materials themselves, each for one of the product. Quantities are in the components' base units.
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Product ID"
+// @Success      200  {object}  BOMResponse
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /products/{id}/bom [get]
+func (h *Handler) GetBOM(c *gin.Context) {
+	bom, err := h.svc.GetBOM(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondLookupError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag.Format(bom.Version))
+	c.JSON(http.StatusOK, bom)
+}
+
+// SetBOM replaces a product's bill of materials.
+// @Summary      Set a product's bill of materials
+// @Description  Replaces the product's components, e.g. {"components": [{"productId": 12, "quantity": 2}]}, with the quantity of each, in its base unit, that goes into one of the product. Components may have bills of materials of their own, but must not contain the product at any level. Products with variants can't be part of a bill of materials.
+// @Tags         Products
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id        path    int          true   "Product ID"
+// @Param        If-Match  header  string       false  "ETag the change is based on (required if REQUIRE_IF_MATCH is set)"
+// @Param        bom       body    SetBOMInput  true   "Components"
+// @Success      200  {object}  BOMResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Router       /products/{id}/bom [put]
+func (h *Handler) SetBOM(c *gin.Context) {
+	precondition, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input SetBOMInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bom, err := h.svc.SetBOM(c.Request.Context(), c.Param("id"), input, precondition)
+	if err != nil {
+		respondWriteError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag.Format(bom.Version))
+	c.JSON(http.StatusOK, bom)
+}
+
+// GetPrices returns a product's price history.
+// @Summary      Get a product's price history
+// @Description  Returns the product's current price, every change that led to it with the old and new price, who made it and when it took effect, newest first, and the scheduled changes, soonest first.
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path      int  true  "Product ID"
+// @Success      200  {object}  PriceHistoryResponse
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /products/{id}/prices [get]
+func (h *Handler) GetPrices(c *gin.Context) {
+	prices, err := h.svc.ListPrices(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondLookupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, prices)
+}
+
+// GetPriceAt returns the price a product had at a time.
+// @Summary      Get a product's price at a time
+// @Description  Returns the price that was in effect at the given time, e.g. for reports on past sales, and when it took effect. With a currency, the price is also converted at the exchange rates of that time.
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id    path      int     true  "Product ID"
+// @Param        date      query     string  true   "A date (YYYY-MM-DD, meaning midnight UTC) or RFC 3339 timestamp"
+// @Param        currency  query     string  false  "Also return the price converted to this currency"
+// @Success      200  {object}  PriceAtResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /products/{id}/prices/at [get]
+func (h *Handler) GetPriceAt(c *gin.Context) {
+	at, err := query.ParseTime(c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	price, err := h.svc.GetPriceAt(c.Request.Context(), c.Param("id"), at, c.Query("currency"))
+	if err != nil {
+		if errors.Is(err, ErrNoPriceAt) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		respondLookupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, price)
+}
+
+// SchedulePrice schedules a change of a product's price.
+// @Summary      Schedule a price change
+// @Description  Schedules the product's price to change at a future time, e.g. {"price": 24.99, "effectiveAt": "2026-01-01T00:00:00Z"}, optionally in another currency. A background job applies it once it is due.
+// @Tags         Products
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id     path      int                 true  "Product ID"
+// @Param        price  body      SchedulePriceInput  true  "New price and when it takes effect"
+// @Success      201  {object}  PriceChangeResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /products/{id}/prices [post]
+func (h *Handler) SchedulePrice(c *gin.Context) {
+	var input SchedulePriceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	change, err := h.svc.SchedulePrice(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		respondWriteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, change)
+}
+
+// CancelScheduledPrice cancels a scheduled price change.
+// @Summary      Cancel a scheduled price change
+// @Description  Deletes a scheduled price change that hasn't taken effect yet.
+// @Tags         Products
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id        path  int  true  "Product ID"
+// @Param        changeId  path  int  true  "Price change ID"
+// @Success      204  "No Content"
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /products/{id}/prices/{changeId} [delete]
+func (h *Handler) CancelScheduledPrice(c *gin.Context) {
+	if err := h.svc.CancelScheduledPrice(c.Request.Context(), c.Param("id"), c.Param("changeId")); err != nil {
+		respondWriteError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondWriteError maps the errors of updates and deletes to a response.
+func respondWriteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case errors.Is(err, ErrVariantNotFound), errors.Is(err, ErrPriceChangeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, etag.ErrPreconditionFailed), errors.Is(err, db.ErrStaleVersion):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": etag.ErrPreconditionFailed.Error()})
+	case errors.Is(err, patch.ErrUnsupportedMediaType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, patch.ErrInvalidPatch), errors.Is(err, ErrInvalidSKU), errors.Is(err, ErrInvalidBarcode),
+		errors.Is(err, ErrUnknownCategory), errors.Is(err, ErrInvalidOptions), errors.Is(err, ErrTooManyVariants),
+		errors.Is(err, category.ErrInvalidAttributes), errors.Is(err, ErrUnknownUnit), errors.Is(err, ErrBaseUnitRequired),
+		errors.Is(err, ErrInvalidConversion), errors.Is(err, ErrNoConversion), errors.Is(err, ErrInvalidBOM),
+		errors.Is(err, ErrUnknownComponent), errors.Is(err, ErrBOMCycle), errors.Is(err, ErrBOMTooDeep), errors.Is(err, ErrScheduleInPast),
+		errors.Is(err, money.ErrUnknownCurrency):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrSKUInUse), errors.Is(err, ErrBarcodeInUse), errors.Is(err, ErrVariantHasStock),
+		errors.Is(err, ErrBaseUnitHasStock), errors.Is(err, ErrVariantsInBOM), errors.Is(err, ErrUsedAsComponent),
+		errors.Is(err, ErrKitHasStock), errors.Is(err, ErrHasHistory), errors.Is(err, ErrArchived),
+		errors.Is(err, ErrNotArchived), errors.Is(err, ErrOverridesInCurrency):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// includeArchived reads the include_archived query parameter. Archived products are
+// hidden without it.
+func includeArchived(c *gin.Context) (bool, error) {
+	value := c.Query("include_archived")
+	if value == "" {
+		return false, nil
+	}
+	include, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New("include_archived must be true or false")
+	}
+	return include, nil
+}
+
+// respondLookupError maps the errors of lookups, e.g. by SKU or barcode, and of price
+// conversions to a response.
+func respondLookupError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case errors.Is(err, ErrInvalidBarcode), errors.Is(err, money.ErrUnknownCurrency), errors.Is(err, currency.ErrNoRate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product"})
+	}
+}
@@ -0,0 +1,190 @@
+package product
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+	"gorm.io/gorm"
+)
+
+// searchDocument is the text products are found by in search. The SKU and name weigh
+// more than the description. Bump searchVersion whenever it changes, so Migrate rebuilds
+// the column.
+const (
+	searchVersion  = "2"
+	searchDocument = `setweight(to_tsvector('simple', coalesce(sku, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(description, '')), 'B')`
+)
+
+// Migrate creates or updates the products table, including the full-text search
+// column and the indexes for search, and the product_barcodes, product_variants,
+// product_unit_conversions, product_bom_lines and product_price_changes tables. Run it
+// after uom.Migrate.
+func Migrate(db *gorm.DB) error {
+	// Products created before SKUs were introduced get a placeholder SKU, so the
+	// column can be NOT NULL and unique.
+	if db.Migrator().HasTable(&Product{}) && !db.Migrator().HasColumn(&Product{}, "SKU") {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, statement := range []string{
+				`ALTER TABLE products ADD COLUMN sku varchar(64)`,
+				`UPDATE products SET sku = 'SKU-' || id`,
+				`ALTER TABLE products ALTER COLUMN sku SET NOT NULL`,
+			} {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("could not add SKUs to existing products: %w", err)
+		}
+	}
+
+	if err := migrateMinorUnits(db); err != nil {
+		return fmt.Errorf("could not convert prices to minor units: %w", err)
+	}
+
+	backfillPrices := db.Migrator().HasTable(&Product{}) && !db.Migrator().HasTable(&PriceChange{})
+	archiveDeleted := db.Migrator().HasTable(&Product{}) && !db.Migrator().HasColumn(&Product{}, "Status") &&
+		db.Migrator().HasTable("inventory_transactions")
+
+	if err := db.AutoMigrate(&Product{}, &Barcode{}, &Variant{}, &UnitConversion{}, &BOMLine{}, &PriceChange{}); err != nil {
+		return err
+	}
+
+	// Products created before price history was introduced start it with their current
+	// price, as of their creation.
+	if backfillPrices {
+		err := db.Exec(`
+			INSERT INTO product_price_changes (created_at, org_id, product_id, new_price_minor, new_currency, effective_at)
+			SELECT NOW(), org_id, id, price_minor, currency, created_at FROM products`,
+		).Error
+		if err != nil {
+			return fmt.Errorf("could not start the price history of existing products: %w", err)
+		}
+	}
+
+	// Products used to be deleted even with inventory history, leaving ledger rows that
+	// point at a product nobody can see. Those products are archived instead, unless
+	// their SKU has been reused since; of several with the same SKU, the latest one is.
+	// Their variants, barcodes and unit conversions stay deleted.
+	if archiveDeleted {
+		err := db.Exec(`
+			UPDATE products SET deleted_at = NULL, status = ?
+			WHERE deleted_at IS NOT NULL
+				AND EXISTS (SELECT 1 FROM inventory_transactions WHERE inventory_transactions.product_id = products.id)
+				AND NOT EXISTS (SELECT 1 FROM products other
+					WHERE other.org_id = products.org_id AND other.sku = products.sku
+						AND (other.deleted_at IS NULL OR (other.id > products.id
+							AND EXISTS (SELECT 1 FROM inventory_transactions WHERE inventory_transactions.product_id = other.id))))`,
+			Archived,
+		).Error
+		if err != nil {
+			return fmt.Errorf("could not archive deleted products with inventory history: %w", err)
+		}
+	}
+
+	// The column's comment records the version of searchDocument it was generated with.
+	var version sql.NullString
+	err := db.Raw(`
+		SELECT col_description(attrelid, attnum) FROM pg_attribute
+		WHERE attrelid = 'products'::regclass AND attname = 'search_vector' AND NOT attisdropped`,
+	).Scan(&version).Error
+	if err != nil {
+		return err
+	}
+
+	if !version.Valid || version.String != searchVersion {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, statement := range []string{
+				`ALTER TABLE products DROP COLUMN IF EXISTS search_vector`,
+				`ALTER TABLE products ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (` + searchDocument + `) STORED`,
+				`COMMENT ON COLUMN products.search_vector IS '` + searchVersion + `'`,
+				`CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector)`,
+			} {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("could not build the product search column: %w", err)
+		}
+	}
+
+	// jsonb_path_ops supports the containment queries of attribute filters.
+	err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_attributes ON products USING GIN (attributes jsonb_path_ops)`).Error
+	if err != nil {
+		return err
+	}
+
+	// Trigram similarity finds names with typos.
+	for _, statement := range []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops)`,
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("could not set up trigram search (requires the pg_trgm extension): %w", err)
+		}
+	}
+
+	return nil
+}
+
+// migrateMinorUnits converts the prices of products, variants and price changes from
+// before currencies were introduced to minor units of the default currency.
+func migrateMinorUnits(db *gorm.DB) error {
+	currency := money.DefaultCurrency()
+	params := map[string]interface{}{
+		"currency": currency,
+		"scale":    int(math.Pow10(money.Exponent(currency))),
+	}
+
+	type statement struct {
+		sql  string
+		args []interface{}
+	}
+	var statements []statement
+	if db.Migrator().HasTable(&Product{}) && db.Migrator().HasColumn(&Product{}, "price") {
+		statements = append(statements,
+			statement{sql: `ALTER TABLE products ADD COLUMN price_minor bigint, ADD COLUMN currency varchar(3)`},
+			statement{sql: `UPDATE products SET price_minor = ROUND(price * @scale), currency = @currency`, args: []interface{}{params}},
+			statement{sql: `ALTER TABLE products ALTER COLUMN price_minor SET NOT NULL, ALTER COLUMN currency SET NOT NULL, DROP COLUMN price`},
+		)
+	}
+	if db.Migrator().HasTable(&Variant{}) && db.Migrator().HasColumn(&Variant{}, "price_override") {
+		statements = append(statements,
+			statement{sql: `ALTER TABLE product_variants ADD COLUMN price_override_minor bigint`},
+			statement{sql: `UPDATE product_variants SET price_override_minor = ROUND(price_override * @scale)`, args: []interface{}{params}},
+			statement{sql: `ALTER TABLE product_variants DROP COLUMN price_override`},
+		)
+	}
+	if db.Migrator().HasTable(&PriceChange{}) && db.Migrator().HasColumn(&PriceChange{}, "new_price") {
+		statements = append(statements,
+			statement{sql: `ALTER TABLE product_price_changes ADD COLUMN old_price_minor bigint, ADD COLUMN old_currency varchar(3),
+				ADD COLUMN new_price_minor bigint, ADD COLUMN new_currency varchar(3)`},
+			statement{sql: `UPDATE product_price_changes SET old_price_minor = ROUND(old_price * @scale),
+				old_currency = CASE WHEN old_price IS NOT NULL THEN @currency END,
+				new_price_minor = ROUND(new_price * @scale), new_currency = @currency`, args: []interface{}{params}},
+			statement{sql: `ALTER TABLE product_price_changes ALTER COLUMN new_price_minor SET NOT NULL,
+				ALTER COLUMN new_currency SET NOT NULL, DROP COLUMN old_price, DROP COLUMN new_price`},
+		)
+	}
+	if len(statements) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement.sql, statement.args...).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
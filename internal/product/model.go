@@ -1,11 +1,48 @@
 package product
 
-import "gorm.io/gorm"
+import (
+	"github.com/RezaBG/Inventory-management-api/internal/category"
+	"github.com/RezaBG/Inventory-management-api/internal/uom"
+	"gorm.io/gorm"
+)
 
 type Product struct {
 	gorm.Model
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
+	// SKU is unique among an organization's products that haven't been deleted.
+	OrgID       uint      `json:"-" gorm:"not null;index;uniqueIndex:idx_products_org_sku,where:deleted_at IS NULL"`
+	SKU         string    `json:"sku" gorm:"not null;size:64;uniqueIndex:idx_products_org_sku"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Quantity    int       `json:"quantity"`
+	Barcodes    []Barcode `json:"barcodes" gorm:"foreignKey:ProductID"`
+	// The price is kept in minor units of its currency, e.g. 1999 with EUR for €19.99;
+	// see money.
+	PriceMinor int64  `json:"priceMinor" gorm:"not null"`
+	Currency   string `json:"currency" gorm:"not null;size:3"`
+	// Type is standard or kit. Either may have a bill of materials; see BOMLine.
+	Type ProductType `json:"type" gorm:"not null;default:standard"`
+	// Status is where the product is in its lifecycle. Products with history are
+	// archived rather than deleted, so the ledger never points at a missing product.
+	Status ProductStatus `json:"status" gorm:"not null;default:active;index"`
+	// A product has one primary category and any number of secondary ones.
+	PrimaryCategoryID *uint               `json:"primaryCategoryId" gorm:"index"`
+	Categories        []category.Category `json:"categories" gorm:"many2many:product_categories"`
+	// Options are the dimensions the product's variants differ in.
+	Options  []Option  `json:"options" gorm:"type:jsonb;serializer:json"`
+	Variants []Variant `json:"variants" gorm:"foreignKey:ProductID"`
+	// Attributes are custom fields defined by the product's categories; see category.
+	Attributes map[string]interface{} `json:"attributes" gorm:"type:jsonb;serializer:json"`
+	// Stock is kept in the base unit. Goods are usually bought in the purchase unit and
+	// sold in the sales unit; both must convert to the base unit.
+	BaseUnitID     *uint            `json:"baseUnitId" gorm:"index"`
+	BaseUnit       *uom.Unit        `json:"baseUnit" gorm:"foreignKey:BaseUnitID"`
+	PurchaseUnitID *uint            `json:"purchaseUnitId"`
+	SalesUnitID    *uint            `json:"salesUnitId"`
+	Conversions    []UnitConversion `json:"conversions" gorm:"foreignKey:ProductID"`
+	// Version is incremented on every change, including stock movements; see etag.
+	Version uint `json:"version" gorm:"not null;default:1"`
+}
+
+func (Product) AuditEntityType() string {
+	return "product"
 }
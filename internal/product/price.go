@@ -0,0 +1,57 @@
+package product
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrScheduleInPast      = errors.New("scheduled price changes must take effect in the future")
+	ErrPriceChangeNotFound = errors.New("scheduled price change not found")
+	ErrNoPriceAt           = errors.New("the product had no price at that time")
+)
+
+// PriceChange is a change of a product's price. Changes made through product updates
+// take effect at once; scheduled ones are pending until the price scheduler applies
+// them. A product's first price is a change without an old price. Prices are in minor
+// units of their currency, which may change along with the price.
+type PriceChange struct {
+	ID            uint `gorm:"primarykey"`
+	CreatedAt     time.Time
+	OrgID         uint   `gorm:"not null;index"`
+	ProductID     uint   `gorm:"not null;index:idx_product_price_changes_product_effective"`
+	OldPriceMinor *int64 `gorm:"column:old_price_minor"`
+	OldCurrency   string `gorm:"size:3"`
+	NewPriceMinor int64  `gorm:"not null"`
+	NewCurrency   string `gorm:"not null;size:3"`
+	// ScheduledFor is when a scheduled change is due.
+	ScheduledFor *time.Time `gorm:"index"`
+	// EffectiveAt is when the price took effect; it is nil while the change is pending.
+	// Scheduled changes take effect at ScheduledFor, however late they are applied.
+	EffectiveAt *time.Time `gorm:"index:idx_product_price_changes_product_effective"`
+	// UserID is the user who made or scheduled the change.
+	UserID *uint
+}
+
+func (PriceChange) TableName() string {
+	return "product_price_changes"
+}
+
+func (PriceChange) AuditEntityType() string {
+	return "product_price_change"
+}
+
+// Pending reports whether the change is scheduled and hasn't taken effect yet.
+func (c PriceChange) Pending() bool {
+	return c.EffectiveAt == nil
+}
+
+// effectiveAt is when the change takes effect if it is applied at now: when it was
+// scheduled for, so the price history doesn't depend on when the scheduler ran, or now
+// if it wasn't scheduled.
+func (c PriceChange) effectiveAt(now time.Time) time.Time {
+	if c.ScheduledFor != nil && c.ScheduledFor.Before(now) {
+		return *c.ScheduledFor
+	}
+	return now
+}
@@ -0,0 +1,203 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/audit"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/tenant"
+	"gorm.io/gorm"
+)
+
+// ListPrices returns the product's price history and its scheduled price changes.
+func (s *service) ListPrices(ctx context.Context, id string) (*PriceHistoryResponse, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := s.productRepo.PriceHistory(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PriceHistoryResponse{
+		ProductID: product.ID,
+		Price:     money.ToMajor(float64(product.PriceMinor), product.Currency),
+		Currency:  product.Currency,
+		History:   []PriceChangeResponse{},
+		Scheduled: []PriceChangeResponse{},
+	}
+	for _, change := range changes {
+		if change.Pending() {
+			response.Scheduled = append(response.Scheduled, toPriceChangeResponse(change))
+		} else {
+			response.History = append(response.History, toPriceChangeResponse(change))
+		}
+	}
+	return response, nil
+}
+
+// GetPriceAt returns the price the product had at the given time and, with a currency,
+// converted to it at the exchange rates of that time.
+func (s *service) GetPriceAt(ctx context.Context, id string, at time.Time, to string) (*PriceAtResponse, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	change, err := s.productRepo.PriceAt(ctx, product.ID, at)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoPriceAt
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PriceAtResponse{
+		ProductID:   product.ID,
+		At:          at,
+		Price:       money.ToMajor(float64(change.NewPriceMinor), change.NewCurrency),
+		Currency:    change.NewCurrency,
+		EffectiveAt: *change.EffectiveAt,
+	}
+	if to != "" {
+		if to, err = money.Normalize(to); err != nil {
+			return nil, err
+		}
+		table, err := s.rates.Table(ctx, at)
+		if err != nil {
+			return nil, err
+		}
+		response.Converted, err = convertPrice(table, change.NewPriceMinor, change.NewCurrency, to)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// SchedulePrice schedules a change of the product's price. The price scheduler applies
+// it once it is due.
+func (s *service) SchedulePrice(ctx context.Context, id string, input SchedulePriceInput) (*PriceChangeResponse, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !input.EffectiveAt.After(time.Now()) {
+		return nil, ErrScheduleInPast
+	}
+	currency := product.Currency
+	if input.Currency != "" {
+		if currency, err = money.Normalize(input.Currency); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkCurrencyChange(product, currency); err != nil {
+		return nil, err
+	}
+
+	change := PriceChange{
+		ProductID:     product.ID,
+		NewPriceMinor: money.ToMinor(input.Price, currency),
+		NewCurrency:   currency,
+		ScheduledFor:  &input.EffectiveAt,
+	}
+	if actor, ok := audit.ActorFrom(ctx); ok {
+		change.UserID = &actor.UserID
+	}
+	if err := s.productRepo.SchedulePriceChange(ctx, &change); err != nil {
+		return nil, err
+	}
+
+	response := toPriceChangeResponse(change)
+	return &response, nil
+}
+
+// CancelScheduledPrice deletes a price change that hasn't taken effect yet.
+func (s *service) CancelScheduledPrice(ctx context.Context, id, changeID string) error {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	change, err := s.productRepo.FindPendingPriceChange(ctx, product.ID, changeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPriceChangeNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.productRepo.DeletePriceChange(ctx, change)
+}
+
+// ApplyDuePriceChanges applies the scheduled price changes of all organizations that
+// are due at now, in the order they are due, and returns how many it applied. A change
+// that fails is logged and retried on the next run; later changes of the same product
+// wait for it, so they aren't overwritten by it.
+func (s *service) ApplyDuePriceChanges(ctx context.Context, now time.Time) (int, error) {
+	changes, err := s.productRepo.DuePriceChanges(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	failed := map[uint]bool{}
+	for _, change := range changes {
+		if failed[change.ProductID] {
+			continue
+		}
+		applied, err := s.productRepo.ApplyPriceChange(tenant.WithOrganization(ctx, change.OrgID), change.ID, now)
+		if err != nil {
+			log.Printf("could not apply price change %d of product %d: %v", change.ID, change.ProductID, err)
+			failed[change.ProductID] = true
+			continue
+		}
+		if applied {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// RunPriceScheduler applies scheduled price changes once they are due, checking every
+// interval, until ctx is done.
+func RunPriceScheduler(ctx context.Context, svc Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		applied, err := svc.ApplyDuePriceChanges(ctx, time.Now())
+		if err != nil {
+			log.Printf("Price scheduler: could not apply scheduled price changes: %v", err)
+		} else if applied > 0 {
+			log.Printf("Price scheduler: applied %d scheduled price changes", applied)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func toPriceChangeResponse(change PriceChange) PriceChangeResponse {
+	var oldPrice *float64
+	if change.OldPriceMinor != nil {
+		price := money.ToMajor(float64(*change.OldPriceMinor), change.OldCurrency)
+		oldPrice = &price
+	}
+	return PriceChangeResponse{
+		ID:           change.ID,
+		OldPrice:     oldPrice,
+		OldCurrency:  change.OldCurrency,
+		NewPrice:     money.ToMajor(float64(change.NewPriceMinor), change.NewCurrency),
+		NewCurrency:  change.NewCurrency,
+		ScheduledFor: change.ScheduledFor,
+		EffectiveAt:  change.EffectiveAt,
+		UserID:       change.UserID,
+		CreatedAt:    change.CreatedAt,
+	}
+}
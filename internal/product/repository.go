@@ -1,15 +1,134 @@
 package product
 
 import (
+	"context"
+	"encoding/json"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/RezaBG/Inventory-management-api/internal/audit"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/db"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/query"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/tenant"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// stockColumn is a product's current stock level, the sum of its inventory transactions.
+const stockColumn = `(SELECT COALESCE(SUM(inventory_transactions.quantity_change), 0)
+	FROM inventory_transactions
+	WHERE inventory_transactions.product_id = products.id AND inventory_transactions.deleted_at IS NULL)`
+
+// listFields are the fields product lists can be sorted and filtered by. Prices are
+// compared in major units of each product's own currency.
+var listFields = query.Fields{
+	"id":          {Column: "products.id", Kind: query.Number},
+	"sku":         {Column: "products.sku", Kind: query.String},
+	"name":        {Column: "products.name", Kind: query.String},
+	"description": {Column: "products.description", Kind: query.String},
+	"price":       {Column: money.MajorSQL("products.price_minor", "products.currency"), Kind: query.Number},
+	"quantity":    {Column: stockColumn, Kind: query.Number},
+	"status":      {Column: "products.status", Kind: query.String},
+	"createdAt":   {Column: "products.created_at", Kind: query.Time},
+	"updatedAt":   {Column: "products.updated_at", Kind: query.Time},
+}
+
+// barcodeMatch is true for products with a barcode whose GTIN is the bound value.
+const barcodeMatch = `EXISTS (SELECT 1 FROM product_barcodes
+	WHERE product_barcodes.product_id = products.id AND product_barcodes.gtin = ?)`
+
+// inCategories is true for products whose primary or a secondary category is one of
+// the bound IDs (bound twice).
+const inCategories = `(products.primary_category_id IN ? OR EXISTS (SELECT 1 FROM product_categories
+	WHERE product_categories.product_id = products.id AND product_categories.category_id IN ?))`
+
+// bomQuery selects the bill of materials lines of the products and, recursively, of
+// their components, with the type of each component. The recursive query bypasses the
+// tenant scope, so it is restricted to the organization explicitly.
+const bomQuery = `WITH RECURSIVE bom (product_id, component_id, quantity, depth) AS (
+		SELECT product_id, component_id, quantity, 1 FROM product_bom_lines
+		WHERE product_id IN @ids AND org_id = @org
+		UNION
+		SELECT product_bom_lines.product_id, product_bom_lines.component_id, product_bom_lines.quantity, bom.depth + 1
+		FROM product_bom_lines JOIN bom ON product_bom_lines.product_id = bom.component_id
+		WHERE product_bom_lines.org_id = @org AND bom.depth < @depth
+	)
+	SELECT DISTINCT bom.product_id, bom.component_id, bom.quantity, products.type AS component_type
+	FROM bom JOIN products ON products.id = bom.component_id`
+
+// bomParentsQuery selects how many levels of bills of materials the product is part
+// of, up to @depth.
+const bomParentsQuery = `WITH RECURSIVE parents (product_id, depth) AS (
+		SELECT product_id, 1 FROM product_bom_lines WHERE component_id = @id AND org_id = @org
+		UNION
+		SELECT product_bom_lines.product_id, parents.depth + 1
+		FROM product_bom_lines JOIN parents ON product_bom_lines.component_id = parents.product_id
+		WHERE product_bom_lines.org_id = @org AND parents.depth < @depth
+	)
+	SELECT COALESCE(MAX(depth), 0) FROM parents`
+
+// bomLock is the class of the advisory lock that serializes changes to the bills of
+// materials of an organization. The organization ID is the other half of the key.
+const bomLock = 2
+
+// SearchResult is a product found by a search, with its relevance and the matches
+// highlighted in <mark> tags. The rest of the highlights is HTML-escaped.
+type SearchResult struct {
+	Product
+	Rank                 float64
+	NameHighlight        string
+	DescriptionHighlight string
+}
+
+// ts_headline doesn't escape the text around the matches, so it marks them with control
+// characters instead of tags. markHighlight escapes the text and turns the markers into
+// <mark> tags.
+const (
+	highlightStart              = "\x02"
+	highlightStop               = "\x03"
+	nameHighlightOptions        = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true"
+	descriptionHighlightOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", MaxWords=25, MinWords=10, MaxFragments=2"
 )
 
+var highlightTags = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+func markHighlight(headline string) string {
+	return highlightTags.Replace(html.EscapeString(headline))
+}
+
 type Repository interface {
-	FindAll() ([]Product, error)
-	FindByID(id string) (*Product, error)
-	Save(product *Product) (*Product, error)
-	Update(product *Product) (*Product, error)
-	Delete(id string) error
+	List(ctx context.Context, params *query.Params, filter ListFilter) ([]Product, int64, error)
+	Search(ctx context.Context, terms string, offset, limit int, includeArchived bool) ([]SearchResult, int64, error)
+	FindByID(ctx context.Context, id string) (*Product, error)
+	FindBySKU(ctx context.Context, sku string) (*Product, error)
+	FindByGTIN(ctx context.Context, gtin string) (*Product, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]Product, error)
+	Save(ctx context.Context, product *Product) (*Product, error)
+	Update(ctx context.Context, product *Product) (*Product, error)
+	Delete(ctx context.Context, product *Product) error
+	SKUsInUse(ctx context.Context, skus []string, exceptProductID, exceptVariantID uint) (bool, error)
+	AddVariants(ctx context.Context, product *Product, variants []Variant) error
+	FindVariant(ctx context.Context, productID uint, variantID string) (*Variant, error)
+	UpdateVariant(ctx context.Context, variant *Variant) (*Variant, error)
+	DeleteVariant(ctx context.Context, variant *Variant) error
+	FindBOM(ctx context.Context, productID uint) ([]BOMLine, error)
+	BOMEdges(ctx context.Context, productIDs []uint) ([]BOMEdge, error)
+	ReplaceBOM(ctx context.Context, product *Product, lines []BOMLine) error
+	BOMDepthAbove(ctx context.Context, productID uint) (int, error)
+	WithBOMLock(ctx context.Context, fn func(repo Repository) error) error
+	BOMUsage(ctx context.Context, productID uint) (hasComponents, isComponent bool, err error)
+	PriceHistory(ctx context.Context, productID uint) ([]PriceChange, error)
+	PriceAt(ctx context.Context, productID uint, at time.Time) (*PriceChange, error)
+	SchedulePriceChange(ctx context.Context, change *PriceChange) error
+	FindPendingPriceChange(ctx context.Context, productID uint, changeID string) (*PriceChange, error)
+	DeletePriceChange(ctx context.Context, change *PriceChange) error
+	DuePriceChanges(ctx context.Context, now time.Time) ([]PriceChange, error)
+	ApplyPriceChange(ctx context.Context, changeID uint, now time.Time) (bool, error)
 }
 
 type repository struct {
@@ -20,28 +139,617 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) FindAll() ([]Product, error) {
+// withAssociations loads the barcodes, secondary categories, variants, base unit and
+// unit conversions of the products.
+func withAssociations(db *gorm.DB) *gorm.DB {
+	return db.Preload("Barcodes").Preload("Categories").Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("product_variants.id")
+	}).Preload("BaseUnit").Preload("Conversions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("product_unit_conversions.id")
+	}).Preload("Conversions.Unit")
+}
+
+// ListFilter narrows down a product list beyond the query parameters.
+type ListFilter struct {
+	// CategoryIDs, if not nil, lists only products in one of these categories.
+	CategoryIDs []uint
+	// Attributes lists only products with these attribute values.
+	Attributes map[string]string
+	// IncludeArchived also lists archived products.
+	IncludeArchived bool
+}
+
+// List returns a page of products.
+func (r *repository) List(ctx context.Context, params *query.Params, filter ListFilter) ([]Product, int64, error) {
+	db := r.db.WithContext(ctx).Model(&Product{}).Scopes(params.Filter)
+	if !filter.IncludeArchived {
+		db = db.Where("products.status <> ?", Archived)
+	}
+	if filter.CategoryIDs != nil {
+		db = db.Where(inCategories, filter.CategoryIDs, filter.CategoryIDs)
+	}
+	for key, value := range filter.Attributes {
+		condition, args := attributeCondition(key, value)
+		db = db.Where(condition, args...)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
 	var products []Product
-	err := r.db.Find(&products).Error
-	return products, err
+	err := db.Scopes(params.Sort, params.Paginate, withAssociations).Find(&products).Error
+	return products, total, err
 }
 
-func (r *repository) FindByID(id string) (*Product, error) {
+// Search finds products whose search document contains words starting with each of
+// the terms, whose name is similar to the terms (to tolerate typos), or that have the
+// terms as barcode, best first. Archived products are only found with includeArchived.
+func (r *repository) Search(ctx context.Context, terms string, offset, limit int, includeArchived bool) ([]SearchResult, int64, error) {
+	tsQuery := prefixQuery(terms)
+	// If the terms aren't a valid GTIN, gtin is empty and matches no barcode.
+	gtin, _ := NormalizeGTIN(terms)
+	db := r.db.WithContext(ctx).Model(&Product{}).
+		Where("products.search_vector @@ to_tsquery('simple', ?) OR products.name % ? OR "+barcodeMatch, tsQuery, terms, gtin)
+	if !includeArchived {
+		db = db.Where("products.status <> ?", Archived)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var results []SearchResult
+	err := db.Select(`products.*,
+		ts_rank(products.search_vector, to_tsquery('simple', ?)) + similarity(products.name, ?)
+			+ CASE WHEN `+barcodeMatch+` THEN 1 ELSE 0 END AS rank,
+		ts_headline('simple', products.name, to_tsquery('simple', ?), ?) AS name_highlight,
+		ts_headline('simple', products.description, to_tsquery('simple', ?), ?) AS description_highlight`,
+		tsQuery, terms, gtin, tsQuery, nameHighlightOptions, tsQuery, descriptionHighlightOptions,
+	).Order("rank DESC, products.id").Offset(offset).Limit(limit).Scopes(withAssociations).Find(&results).Error
+	for i := range results {
+		results[i].NameHighlight = markHighlight(results[i].NameHighlight)
+		results[i].DescriptionHighlight = markHighlight(results[i].DescriptionHighlight)
+	}
+	return results, total, err
+}
+
+// attributeCondition matches products whose attribute has the value from a query
+// string. The value may stand for a string, a number or a boolean, so each is tried;
+// every alternative is a containment query that can use idx_products_attributes.
+func attributeCondition(key, value string) (string, []interface{}) {
+	candidates := []interface{}{value}
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		candidates = append(candidates, number)
+	}
+	if value == "true" || value == "false" {
+		candidates = append(candidates, value == "true")
+	}
+
+	conditions := make([]string, 0, len(candidates))
+	args := make([]interface{}, 0, len(candidates))
+	for _, candidate := range candidates {
+		document, _ := json.Marshal(map[string]interface{}{key: candidate})
+		conditions = append(conditions, "products.attributes @> ?::jsonb")
+		args = append(args, string(document))
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// prefixQuery turns free text into a tsquery that matches words starting with each
+// term, e.g. "usb-c cab" becomes "usb:* & c:* & cab:*". Everything but letters and
+// digits is dropped, so the result is always a valid tsquery.
+func prefixQuery(terms string) string {
+	words := strings.FieldsFunc(terms, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = strings.ToLower(word) + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+func (r *repository) FindByID(ctx context.Context, id string) (*Product, error) {
 	var product Product
-	err := r.db.First(&product, id).Error
+	err := r.db.WithContext(ctx).Scopes(withAssociations).First(&product, id).Error
 	return &product, err
 }
 
-func (r *repository) Save(product *Product) (*Product, error) {
-	err := r.db.Create(product).Error
+// FindBySKU finds the product with a SKU, or the product of the variant with the SKU.
+func (r *repository) FindBySKU(ctx context.Context, sku string) (*Product, error) {
+	var product Product
+	err := r.db.WithContext(ctx).Scopes(withAssociations).
+		Where(`products.sku = ? OR EXISTS (SELECT 1 FROM product_variants
+			WHERE product_variants.product_id = products.id AND product_variants.sku = ?
+				AND product_variants.deleted_at IS NULL)`, sku, sku).
+		First(&product).Error
+	return &product, err
+}
+
+// FindByGTIN finds the product with a barcode, given as a 14-digit GTIN.
+func (r *repository) FindByGTIN(ctx context.Context, gtin string) (*Product, error) {
+	var product Product
+	err := r.db.WithContext(ctx).Scopes(withAssociations).Where(barcodeMatch, gtin).First(&product).Error
+	return &product, err
+}
+
+// FindByIDs finds the products with the IDs, ordered by ID.
+func (r *repository) FindByIDs(ctx context.Context, ids []uint) ([]Product, error) {
+	var products []Product
+	err := r.db.WithContext(ctx).Scopes(withAssociations).Where("id IN ?", ids).Order("id").Find(&products).Error
+	return products, err
+}
+
+// Save creates the product with its barcodes, categories and unit conversions.
+func (r *repository) Save(ctx context.Context, product *Product) (*Product, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(product).Error; err != nil {
+			return err
+		}
+		if err := recordPrice(tx, product, nil, ""); err != nil {
+			return err
+		}
+		if err := replaceBarcodes(tx, product); err != nil {
+			return err
+		}
+		if err := replaceCategories(tx, product); err != nil {
+			return err
+		}
+		return replaceConversions(tx, product)
+	})
 	return product, err
 }
 
-func (r *repository) Update(product *Product) (*Product, error) {
-	err := r.db.Save(product).Error
+// Update saves the product with its barcodes, categories and unit conversions only if
+// it is still at the version it was read at, and increments its version. Otherwise it
+// returns db.ErrStaleVersion.
+func (r *repository) Update(ctx context.Context, product *Product) (*Product, error) {
+	readVersion := product.Version
+	product.Version++
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var old struct {
+			PriceMinor int64
+			Currency   string
+		}
+		if err := tx.Model(&Product{}).Where("id = ?", product.ID).Select("price_minor", "currency").Scan(&old).Error; err != nil {
+			return err
+		}
+		result := tx.Model(product).Omit(clause.Associations).Where("version = ?", readVersion).Select("*").Updates(product)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return db.ErrStaleVersion
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if old.PriceMinor != product.PriceMinor || old.Currency != product.Currency {
+			if err := recordPrice(tx, product, &old.PriceMinor, old.Currency); err != nil {
+				return err
+			}
+		}
+		if err := replaceBarcodes(tx, product); err != nil {
+			return err
+		}
+		if err := replaceCategories(tx, product); err != nil {
+			return err
+		}
+		return replaceConversions(tx, product)
+	})
+	if err != nil {
+		product.Version = readVersion
+	}
 	return product, err
 }
 
-func (r *repository) Delete(id string) error {
-	return r.db.Delete(&Product{}, id).Error
+// recordPrice adds the product's current price to its price history, attributed to
+// the user making the change.
+func recordPrice(tx *gorm.DB, product *Product, oldPriceMinor *int64, oldCurrency string) error {
+	now := time.Now()
+	change := PriceChange{
+		ProductID:     product.ID,
+		OldPriceMinor: oldPriceMinor,
+		OldCurrency:   oldCurrency,
+		NewPriceMinor: product.PriceMinor,
+		NewCurrency:   product.Currency,
+		EffectiveAt:   &now,
+	}
+	if actor, ok := audit.ActorFrom(tx.Statement.Context); ok {
+		change.UserID = &actor.UserID
+	}
+	return tx.Create(&change).Error
+}
+
+// replaceBarcodes makes product.Barcodes the product's only barcodes. Barcodes it
+// already has are kept as they are.
+func replaceBarcodes(tx *gorm.DB, product *Product) error {
+	var existing []Barcode
+	if err := tx.Where("product_id = ?", product.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+	byGTIN := make(map[string]Barcode, len(existing))
+	for _, barcode := range existing {
+		byGTIN[barcode.GTIN] = barcode
+	}
+
+	wanted := make(map[string]bool, len(product.Barcodes))
+	var added []Barcode
+	for _, barcode := range product.Barcodes {
+		wanted[barcode.GTIN] = true
+		if _, ok := byGTIN[barcode.GTIN]; !ok {
+			barcode.ProductID = product.ID
+			added = append(added, barcode)
+		}
+	}
+
+	for _, barcode := range existing {
+		if !wanted[barcode.GTIN] {
+			if err := tx.Delete(&barcode).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if len(added) > 0 {
+		if err := tx.Create(&added).Error; err != nil {
+			return err
+		}
+		for _, barcode := range added {
+			byGTIN[barcode.GTIN] = barcode
+		}
+	}
+
+	for i, barcode := range product.Barcodes {
+		product.Barcodes[i] = byGTIN[barcode.GTIN]
+	}
+	return nil
+}
+
+// replaceCategories makes product.Categories the product's only secondary categories.
+// The join table isn't tenant-scoped, so its rows are written directly rather than
+// through the association, which would try to upsert the categories themselves. That
+// bypasses the audit callbacks, so the change is recorded as an update of the product's
+// category IDs.
+func replaceCategories(tx *gorm.DB, product *Product) error {
+	old := []uint{}
+	err := tx.Raw(`SELECT category_id FROM product_categories WHERE product_id = ? ORDER BY category_id`, product.ID).
+		Scan(&old).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Exec(`DELETE FROM product_categories WHERE product_id = ?`, product.ID).Error; err != nil {
+		return err
+	}
+
+	ids := make([]uint, 0, len(product.Categories))
+	if len(product.Categories) > 0 {
+		rows := make([]map[string]interface{}, 0, len(product.Categories))
+		for _, c := range product.Categories {
+			rows = append(rows, map[string]interface{}{"product_id": product.ID, "category_id": c.ID})
+			ids = append(ids, c.ID)
+		}
+		if err := tx.Table("product_categories").Create(rows).Error; err != nil {
+			return err
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return audit.RecordUpdate(tx, Product{}.AuditEntityType(), strconv.FormatUint(uint64(product.ID), 10),
+		map[string]interface{}{"category_ids": old},
+		map[string]interface{}{"category_ids": ids})
+}
+
+// replaceConversions makes product.Conversions the product's only unit conversions.
+func replaceConversions(tx *gorm.DB, product *Product) error {
+	if err := tx.Where("product_id = ?", product.ID).Delete(&UnitConversion{}).Error; err != nil {
+		return err
+	}
+	if len(product.Conversions) == 0 {
+		return nil
+	}
+
+	for i := range product.Conversions {
+		product.Conversions[i].ID = 0
+		product.Conversions[i].ProductID = product.ID
+	}
+	return tx.Omit(clause.Associations).Create(&product.Conversions).Error
+}
+
+// Delete deletes the product and its variants only if it is still at the version it
+// was read at. Otherwise it returns db.ErrStaleVersion. Its barcodes, unit conversions,
+// bill of materials and scheduled price changes are deleted for good, so the barcodes
+// can be given to another product; its price history is kept.
+func (r *repository) Delete(ctx context.Context, product *Product) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("version = ?", product.Version).Delete(product)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return db.ErrStaleVersion
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&Variant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&UnitConversion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&BOMLine{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ? AND effective_at IS NULL", product.ID).Delete(&PriceChange{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("product_id = ?", product.ID).Delete(&Barcode{}).Error
+	})
+}
+
+// SKUsInUse reports whether any of the SKUs belongs to a product or variant other than
+// the given ones. SKUs are unique across products and variants together.
+func (r *repository) SKUsInUse(ctx context.Context, skus []string, exceptProductID, exceptVariantID uint) (bool, error) {
+	var products, variants int64
+	err := r.db.WithContext(ctx).Model(&Product{}).
+		Where("sku IN ? AND id <> ?", skus, exceptProductID).Limit(1).Count(&products).Error
+	if err != nil {
+		return false, err
+	}
+	err = r.db.WithContext(ctx).Model(&Variant{}).
+		Where("sku IN ? AND id <> ?", skus, exceptVariantID).Limit(1).Count(&variants).Error
+	return products+variants > 0, err
+}
+
+// AddVariants saves the product's options and creates the variants, only if the
+// product is still at the version it was read at; it increments the version.
+// Otherwise it returns db.ErrStaleVersion.
+func (r *repository) AddVariants(ctx context.Context, product *Product, variants []Variant) error {
+	readVersion := product.Version
+	product.Version++
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(product).Omit(clause.Associations).Where("version = ?", readVersion).Select("*").Updates(product)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return db.ErrStaleVersion
+		}
+		if result.Error != nil || len(variants) == 0 {
+			return result.Error
+		}
+		for i := range variants {
+			variants[i].ProductID = product.ID
+		}
+		return tx.Create(&variants).Error
+	})
+	if err != nil {
+		product.Version = readVersion
+		return err
+	}
+	product.Variants = append(product.Variants, variants...)
+	return nil
+}
+
+func (r *repository) FindVariant(ctx context.Context, productID uint, variantID string) (*Variant, error) {
+	var variant Variant
+	err := r.db.WithContext(ctx).Where("product_id = ?", productID).First(&variant, variantID).Error
+	return &variant, err
+}
+
+// UpdateVariant saves the variant and increments its product's version.
+func (r *repository) UpdateVariant(ctx context.Context, variant *Variant) (*Variant, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(variant).Select("*").Updates(variant).Error; err != nil {
+			return err
+		}
+		return BumpVersion(tx, variant.ProductID)
+	})
+	return variant, err
+}
+
+// DeleteVariant deletes the variant and increments its product's version.
+func (r *repository) DeleteVariant(ctx context.Context, variant *Variant) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(variant).Error; err != nil {
+			return err
+		}
+		return BumpVersion(tx, variant.ProductID)
+	})
+}
+
+// BumpVersion increments a product's version, e.g. because one of its variants or
+// attachments changed, so that its ETag changes too.
+func BumpVersion(tx *gorm.DB, productID uint) error {
+	return tx.Model(&Product{}).Where("id = ?", productID).UpdateColumn("version", gorm.Expr("version + 1")).Error
+}
+
+// FindBOM returns the lines of the product's bill of materials with their components.
+func (r *repository) FindBOM(ctx context.Context, productID uint) ([]BOMLine, error) {
+	var lines []BOMLine
+	err := r.db.WithContext(ctx).Preload("Component").Preload("Component.BaseUnit").
+		Where("product_id = ?", productID).Order("id").Find(&lines).Error
+	return lines, err
+}
+
+// BOMEdges returns the bill of materials lines of the products and of all their
+// components, down to MaxBOMDepth levels.
+func (r *repository) BOMEdges(ctx context.Context, productIDs []uint) ([]BOMEdge, error) {
+	orgID, ok := tenant.OrganizationID(ctx)
+	if !ok {
+		return nil, tenant.ErrMissingOrganization
+	}
+
+	var edges []BOMEdge
+	err := r.db.WithContext(ctx).Raw(bomQuery, map[string]interface{}{
+		"ids":   productIDs,
+		"org":   orgID,
+		"depth": MaxBOMDepth,
+	}).Scan(&edges).Error
+	return edges, err
+}
+
+// BOMDepthAbove returns how many levels of bills of materials the product is part of,
+// at most MaxBOMDepth + 1.
+func (r *repository) BOMDepthAbove(ctx context.Context, productID uint) (int, error) {
+	orgID, ok := tenant.OrganizationID(ctx)
+	if !ok {
+		return 0, tenant.ErrMissingOrganization
+	}
+
+	var depth int
+	err := r.db.WithContext(ctx).Raw(bomParentsQuery, map[string]interface{}{
+		"id":    productID,
+		"org":   orgID,
+		"depth": MaxBOMDepth + 1,
+	}).Scan(&depth).Error
+	return depth, err
+}
+
+// WithBOMLock runs fn in a transaction that holds the organization's bill of materials
+// lock, with a repository that works in the transaction. Checking a bill of materials
+// and saving it under the lock keeps two concurrent changes from creating a cycle.
+func (r *repository) WithBOMLock(ctx context.Context, fn func(repo Repository) error) error {
+	orgID, ok := tenant.OrganizationID(ctx)
+	if !ok {
+		return tenant.ErrMissingOrganization
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", bomLock, orgID).Error; err != nil {
+			return err
+		}
+		return fn(&repository{db: tx})
+	})
+}
+
+// ReplaceBOM makes the lines the product's bill of materials, only if the product is
+// still at the version it was read at; it increments the version. Otherwise it returns
+// db.ErrStaleVersion.
+func (r *repository) ReplaceBOM(ctx context.Context, product *Product, lines []BOMLine) error {
+	readVersion := product.Version
+	product.Version++
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(product).Where("version = ?", readVersion).UpdateColumn("version", product.Version)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return db.ErrStaleVersion
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&BOMLine{}).Error; err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return nil
+		}
+		for i := range lines {
+			lines[i].ProductID = product.ID
+		}
+		return tx.Omit(clause.Associations).Create(&lines).Error
+	})
+	if err != nil {
+		product.Version = readVersion
+	}
+	return err
+}
+
+// BOMUsage reports whether the product has a bill of materials and whether it is a
+// component of another product.
+func (r *repository) BOMUsage(ctx context.Context, productID uint) (hasComponents, isComponent bool, err error) {
+	var components, parents int64
+	err = r.db.WithContext(ctx).Model(&BOMLine{}).Where("product_id = ?", productID).Limit(1).Count(&components).Error
+	if err != nil {
+		return false, false, err
+	}
+	err = r.db.WithContext(ctx).Model(&BOMLine{}).Where("component_id = ?", productID).Limit(1).Count(&parents).Error
+	return components > 0, parents > 0, err
+}
+
+// PriceHistory returns all of the product's price changes: those that took effect,
+// newest first, and then the pending ones.
+func (r *repository) PriceHistory(ctx context.Context, productID uint) ([]PriceChange, error) {
+	var changes []PriceChange
+	err := r.db.WithContext(ctx).Where("product_id = ?", productID).
+		Order("effective_at DESC NULLS LAST, scheduled_for, id DESC").Find(&changes).Error
+	return changes, err
+}
+
+// PriceAt returns the price change in effect at the given time.
+func (r *repository) PriceAt(ctx context.Context, productID uint, at time.Time) (*PriceChange, error) {
+	var change PriceChange
+	err := r.db.WithContext(ctx).Where("product_id = ? AND effective_at <= ?", productID, at).
+		Order("effective_at DESC, id DESC").First(&change).Error
+	return &change, err
+}
+
+func (r *repository) SchedulePriceChange(ctx context.Context, change *PriceChange) error {
+	return r.db.WithContext(ctx).Create(change).Error
+}
+
+func (r *repository) FindPendingPriceChange(ctx context.Context, productID uint, changeID string) (*PriceChange, error) {
+	var change PriceChange
+	err := r.db.WithContext(ctx).Where("product_id = ? AND effective_at IS NULL", productID).First(&change, changeID).Error
+	return &change, err
+}
+
+func (r *repository) DeletePriceChange(ctx context.Context, change *PriceChange) error {
+	return r.db.WithContext(ctx).Delete(change).Error
+}
+
+// DuePriceChanges returns the pending price changes of all organizations that are due,
+// in the order they are due. The query bypasses the tenant scope on purpose; apply each
+// change in its own organization.
+func (r *repository) DuePriceChanges(ctx context.Context, now time.Time) ([]PriceChange, error) {
+	var changes []PriceChange
+	err := r.db.WithContext(ctx).Raw(`SELECT * FROM product_price_changes
+		WHERE effective_at IS NULL AND scheduled_for <= ?
+		ORDER BY scheduled_for, id`, now).Scan(&changes).Error
+	return changes, err
+}
+
+// ApplyPriceChange sets the product's price to that of a pending price change, records
+// the old price and marks the change as in effect, and increments the product's
+// version. It reports false if the change is no longer pending, e.g. because another
+// instance applied it first, and returns ErrOverridesInCurrency for a change of
+// currency while variants have price overrides.
+func (r *repository) ApplyPriceChange(ctx context.Context, changeID uint, now time.Time) (bool, error) {
+	applied := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var change PriceChange
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("effective_at IS NULL").Limit(1).Find(&change, changeID).Error
+		if err != nil || change.ID == 0 {
+			return err
+		}
+
+		var product Product
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, change.ProductID).Error
+		if err != nil {
+			return err
+		}
+		if change.NewCurrency != product.Currency {
+			var overrides int64
+			err := tx.Model(&Variant{}).Where("product_id = ? AND price_override_minor IS NOT NULL", product.ID).
+				Count(&overrides).Error
+			if err != nil {
+				return err
+			}
+			if overrides > 0 {
+				return ErrOverridesInCurrency
+			}
+		}
+		oldPriceMinor, oldCurrency := product.PriceMinor, product.Currency
+		err = tx.Model(&product).Updates(map[string]interface{}{
+			"price_minor": change.NewPriceMinor,
+			"currency":    change.NewCurrency,
+			"version":     gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		change.OldPriceMinor, change.OldCurrency = &oldPriceMinor, oldCurrency
+		effectiveAt := change.effectiveAt(now)
+		change.EffectiveAt = &effectiveAt
+		if err := tx.Model(&change).Select("old_price_minor", "old_currency", "effective_at").Updates(&change).Error; err != nil {
+			return err
+		}
+		applied = true
+		return nil
+	})
+	return applied, err
 }
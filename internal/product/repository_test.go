@@ -1 +1,81 @@
 package product
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/query"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestPrefixQuery(t *testing.T) {
+	tests := []struct {
+		terms string
+		want  string
+	}{
+		{"", ""},
+		{"usb", "usb:*"},
+		{"USB Cab", "usb:* & cab:*"},
+		{"  lap   pro 14 ", "lap:* & pro:* & 14:*"},
+		{"usb-c & !cable | (x):*", "usb:* & c:* & cable:* & x:*"},
+		{"Größe", "größe:*"},
+		{"'; DROP TABLE products; --", "drop:* & table:* & products:*"},
+	}
+	for _, tt := range tests {
+		if got := prefixQuery(tt.terms); got != tt.want {
+			t.Errorf("prefixQuery(%q) = %q, want %q", tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestMarkHighlight(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{"plain", "plain"},
+		{highlightStart + "USB" + highlightStop + " cable", "<mark>USB</mark> cable"},
+		{"<script>" + highlightStart + "x" + highlightStop + "</script>", "&lt;script&gt;<mark>x</mark>&lt;/script&gt;"},
+		{`"A & B"`, "&#34;A &amp; B&#34;"},
+	}
+	for _, tt := range tests {
+		if got := markHighlight(tt.headline); got != tt.want {
+			t.Errorf("markHighlight(%q) = %q, want %q", tt.headline, got, tt.want)
+		}
+	}
+}
+
+func TestListHidesArchived(t *testing.T) {
+	for _, includeArchived := range []bool{false, true} {
+		db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+			DryRun:               true,
+			DisableAutomaticPing: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var statements []string
+		err = db.Callback().Query().After("gorm:query").Register("test:record", func(tx *gorm.DB) {
+			statements = append(statements, tx.Statement.SQL.String())
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := &repository{db: db}
+		params := &query.Params{Page: 1, PageSize: 20}
+		if _, _, err := r.List(context.Background(), params, ListFilter{IncludeArchived: includeArchived}); err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		// The count and the page share their conditions. A dry run doesn't reset the
+		// statement between the two, so only the count is seen.
+		if len(statements) == 0 {
+			t.Fatal("no statements")
+		}
+		if hidden := strings.Contains(statements[0], "products.status <> $"); hidden == includeArchived {
+			t.Errorf("include archived %v: %s", includeArchived, statements[0])
+		}
+	}
+}
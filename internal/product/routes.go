@@ -5,10 +5,34 @@ import "github.com/gin-gonic/gin"
 func RegisterRoutes(router *gin.RouterGroup, h *Handler) {
 	productRoutes := router.Group("/products")
 	{
-		productRoutes.POST("", h.CreateProduct)
 		productRoutes.GET("", h.GetProducts)
+		productRoutes.GET("/search", h.SearchProducts)
+		productRoutes.GET("/by-sku/:sku", h.GetProductBySKU)
+		productRoutes.GET("/by-barcode/:code", h.GetProductByBarcode)
 		productRoutes.GET("/:id", h.GetProductByID)
+		productRoutes.GET("/:id/variants", h.GetVariants)
+		productRoutes.GET("/:id/bom", h.GetBOM)
+		productRoutes.GET("/:id/prices", h.GetPrices)
+		productRoutes.GET("/:id/prices/at", h.GetPriceAt)
+	}
+}
+
+// RegisterManagerRoutes registers the routes that change products, which only
+// managers and administrators may use.
+func RegisterManagerRoutes(router *gin.RouterGroup, h *Handler) {
+	productRoutes := router.Group("/products")
+	{
+		productRoutes.POST("", h.CreateProduct)
 		productRoutes.PUT("/:id", h.UpdateProduct)
+		productRoutes.PATCH("/:id", h.PatchProduct)
 		productRoutes.DELETE("/:id", h.DeleteProduct)
+		productRoutes.POST("/:id/archive", h.ArchiveProduct)
+		productRoutes.POST("/:id/restore", h.RestoreProduct)
+		productRoutes.POST("/:id/variants", h.GenerateVariants)
+		productRoutes.PUT("/:id/variants/:variantId", h.UpdateVariant)
+		productRoutes.DELETE("/:id/variants/:variantId", h.DeleteVariant)
+		productRoutes.PUT("/:id/bom", h.SetBOM)
+		productRoutes.POST("/:id/prices", h.SchedulePrice)
+		productRoutes.DELETE("/:id/prices/:changeId", h.CancelScheduledPrice)
 	}
 }
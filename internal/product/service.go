@@ -1,137 +1,960 @@
 package product
 
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/category"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/etag"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/patch"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/query"
+	"github.com/RezaBG/Inventory-management-api/internal/uom"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
 type InventoryStockCalculator interface {
-	CalculateStockForProduct(productID uint) (int, error)
+	CalculateStockForProduct(ctx context.Context, productID uint) (float64, error)
+	CalculateStockForVariants(ctx context.Context, productID uint) (map[uint]float64, error)
+	CalculateStockForProducts(ctx context.Context, productIDs []uint) (map[uint]float64, error)
+	HasTransactions(ctx context.Context, productID uint) (bool, error)
+}
+
+// CategoryTree looks up the categories products are assigned to.
+type CategoryTree interface {
+	CountExisting(ctx context.Context, ids []uint) (int64, error)
+	SubtreeIDs(ctx context.Context, id uint) ([]uint, error)
+	AttributeDefinitions(ctx context.Context, categoryIDs []uint) ([]category.AttributeDefinition, error)
+}
+
+// UnitRegistry looks up the units of measure products are kept in.
+type UnitRegistry interface {
+	FindByIDs(ctx context.Context, ids []uint) ([]uom.Unit, error)
 }
 
+// AttachmentIndex looks up the files attached to products.
+type AttachmentIndex interface {
+	ProductAttachments(ctx context.Context, productID uint) ([]AttachmentLink, error)
+}
+
+var ErrUnknownCategory = errors.New("category not found")
+
 type Service interface {
-	GetAllProducts() ([]ProductResponse, error)
-	GetProductByID(id string) (*ProductResponse, error)
-	CreateNewProduct(input CreateProductInput) (*ProductResponse, error)
-	UpdateExistingProduct(id string, input UpdateProductInput) (*ProductResponse, error)
-	DeleteProductByID(id string) error
+	ListProducts(ctx context.Context, params *query.Params, filter ListProductsFilter) (*ProductListResponse, error)
+	SearchProducts(ctx context.Context, input SearchProductsInput) (*ProductSearchResponse, error)
+	GetProductByID(ctx context.Context, id string, includeArchived bool) (*ProductResponse, error)
+	GetProductBySKU(ctx context.Context, sku string, includeArchived bool) (*ProductResponse, error)
+	GetProductByBarcode(ctx context.Context, code string, includeArchived bool) (*ProductResponse, error)
+	CreateNewProduct(ctx context.Context, input CreateProductInput) (*ProductResponse, error)
+	UpdateExistingProduct(ctx context.Context, id string, input UpdateProductInput, precondition etag.Precondition) (*ProductResponse, error)
+	PatchProduct(ctx context.Context, id string, p patch.Patch, precondition etag.Precondition) (*ProductResponse, error)
+	DeleteProductByID(ctx context.Context, id string, precondition etag.Precondition) error
+	ArchiveProduct(ctx context.Context, id string, precondition etag.Precondition) (*ProductResponse, error)
+	RestoreProduct(ctx context.Context, id string, precondition etag.Precondition) (*ProductResponse, error)
+	GenerateVariants(ctx context.Context, id string, input GenerateVariantsInput, precondition etag.Precondition) (*ProductResponse, error)
+	ListVariants(ctx context.Context, id string) ([]VariantResponse, error)
+	UpdateVariant(ctx context.Context, id, variantID string, input UpdateVariantInput) (*VariantResponse, error)
+	DeleteVariant(ctx context.Context, id, variantID string) error
+	GetBOM(ctx context.Context, id string) (*BOMResponse, error)
+	SetBOM(ctx context.Context, id string, input SetBOMInput, precondition etag.Precondition) (*BOMResponse, error)
+	ListPrices(ctx context.Context, id string) (*PriceHistoryResponse, error)
+	GetPriceAt(ctx context.Context, id string, at time.Time, to string) (*PriceAtResponse, error)
+	SchedulePrice(ctx context.Context, id string, input SchedulePriceInput) (*PriceChangeResponse, error)
+	CancelScheduledPrice(ctx context.Context, id, changeID string) error
+	ApplyDuePriceChanges(ctx context.Context, now time.Time) (int, error)
+	ConvertPrices(ctx context.Context, to string, products ...*ProductResponse) error
 }
 
 type service struct {
 	productRepo     Repository
 	stockCalculator InventoryStockCalculator
+	categories      CategoryTree
+	units           UnitRegistry
+	rates           ExchangeRates
+	attachments     AttachmentIndex
 }
 
-func NewService(productRepo Repository, stockCalculator InventoryStockCalculator) Service {
+func NewService(productRepo Repository, stockCalculator InventoryStockCalculator, categories CategoryTree, units UnitRegistry, rates ExchangeRates, attachments AttachmentIndex) Service {
 	return &service{
 		productRepo:     productRepo,
 		stockCalculator: stockCalculator,
+		categories:      categories,
+		units:           units,
+		rates:           rates,
+		attachments:     attachments,
 	}
 }
 
-func (s *service) GetAllProducts() ([]ProductResponse, error) {
-	products, err := s.productRepo.FindAll()
+// ListProducts lists a page of products. With a category, only products in that
+// category or one of its descendants are listed.
+func (s *service) ListProducts(ctx context.Context, params *query.Params, filter ListProductsFilter) (*ProductListResponse, error) {
+	listFilter := ListFilter{Attributes: filter.Attributes, IncludeArchived: filter.IncludeArchived}
+	if filter.CategoryID != 0 {
+		var err error
+		listFilter.CategoryIDs, err = s.categories.SubtreeIDs(ctx, filter.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		if len(listFilter.CategoryIDs) == 0 {
+			return nil, ErrUnknownCategory
+		}
+	}
+
+	products, total, err := s.productRepo.List(ctx, params, listFilter)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create a slice of our response DTO
-	var productResponses []ProductResponse
+	productResponses := make([]ProductResponse, 0, len(products))
 
 	// Loop through each product and calculate its stock
 	for _, p := range products {
-		quantity, err := s.stockCalculator.CalculateStockForProduct(p.ID)
+		response, err := s.respond(ctx, &p)
 		if err != nil {
 			return nil, err
 		}
 
-		productResponses = append(productResponses, ProductResponse{
-			ID:                 p.ID,
-			Name:               p.Name,
-			Description:        p.Description,
-			Price:              p.Price,
-			CalculatedQuantity: quantity,
-		})
+		productResponses = append(productResponses, *response)
 	}
 
-	return productResponses, nil
+	return &ProductListResponse{
+		Data:     productResponses,
+		Page:     params.Page,
+		PageSize: params.PageSize,
+		Total:    total,
+	}, nil
+}
+
+func (s *service) SearchProducts(ctx context.Context, input SearchProductsInput) (*ProductSearchResponse, error) {
+	results, total, err := s.productRepo.Search(ctx, input.Query, (input.Page-1)*input.PageSize, input.PageSize, input.IncludeArchived)
+	if err != nil {
+		return nil, err
+	}
 
+	data := make([]ProductSearchResult, 0, len(results))
+	for _, result := range results {
+		response, err := s.respond(ctx, &result.Product)
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, ProductSearchResult{
+			ProductResponse:      *response,
+			Rank:                 result.Rank,
+			NameHighlight:        result.NameHighlight,
+			DescriptionHighlight: result.DescriptionHighlight,
+		})
+	}
+
+	return &ProductSearchResponse{
+		Data:     data,
+		Page:     input.Page,
+		PageSize: input.PageSize,
+		Total:    total,
+	}, nil
 }
 
-func (s *service) GetProductByID(id string) (*ProductResponse, error) {
-	product, err := s.productRepo.FindByID(id)
+func (s *service) GetProductByID(ctx context.Context, id string, includeArchived bool) (*ProductResponse, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := visible(product, includeArchived); err != nil {
+		return nil, err
+	}
+	return s.respond(ctx, product)
+}
 
-	quantity, err := s.stockCalculator.CalculateStockForProduct(product.ID)
+func (s *service) GetProductBySKU(ctx context.Context, sku string, includeArchived bool) (*ProductResponse, error) {
+	product, err := s.productRepo.FindBySKU(ctx, sku)
 	if err != nil {
 		return nil, err
 	}
+	if err := visible(product, includeArchived); err != nil {
+		return nil, err
+	}
+	return s.respond(ctx, product)
+}
 
-	response := &ProductResponse{
-		ID:                 product.ID,
-		Name:               product.Name,
-		Description:        product.Description,
-		Price:              product.Price,
-		CalculatedQuantity: quantity,
+// GetProductByBarcode finds a product by any of the forms of one of its barcodes, e.g.
+// the UPC-A 036000291452 also finds the product registered with EAN-13 0036000291452.
+func (s *service) GetProductByBarcode(ctx context.Context, code string, includeArchived bool) (*ProductResponse, error) {
+	gtin, err := NormalizeGTIN(code)
+	if err != nil {
+		return nil, err
 	}
 
-	return response, nil
+	product, err := s.productRepo.FindByGTIN(ctx, gtin)
+	if err != nil {
+		return nil, err
+	}
+	if err := visible(product, includeArchived); err != nil {
+		return nil, err
+	}
+	return s.respond(ctx, product)
 }
 
-func (s *service) CreateNewProduct(input CreateProductInput) (*ProductResponse, error) {
+func (s *service) CreateNewProduct(ctx context.Context, input CreateProductInput) (*ProductResponse, error) {
+	if err := s.checkSKUs(ctx, []string{input.SKU}, 0, 0); err != nil {
+		return nil, err
+	}
+	barcodes, err := newBarcodes(input.Barcodes)
+	if err != nil {
+		return nil, err
+	}
+	currency, err := money.Normalize(input.Currency)
+	if err != nil {
+		return nil, err
+	}
+
 	newProduct := Product{
+		SKU:         input.SKU,
 		Name:        input.Name,
 		Description: input.Description,
-		Price:       input.Price,
+		PriceMinor:  money.ToMinor(input.Price, currency),
+		Currency:    currency,
 		Quantity:    0,
+		Type:        productType(input.Type),
+		Status:      productStatus(input.Status),
+		Barcodes:    barcodes,
+	}
+	if err := s.categorize(ctx, &newProduct, input.PrimaryCategoryID, input.CategoryIDs); err != nil {
+		return nil, err
+	}
+	if err := s.setAttributes(ctx, &newProduct, input.Attributes); err != nil {
+		return nil, err
+	}
+	if err := s.setUnits(ctx, &newProduct, input.BaseUnitID, input.PurchaseUnitID, input.SalesUnitID, input.Conversions); err != nil {
+		return nil, err
 	}
 
-	savedProduct, err := s.productRepo.Save(&newProduct)
+	savedProduct, err := s.productRepo.Save(ctx, &newProduct)
+	if err != nil {
+		return nil, translateConflict(err)
+	}
+
+	response := toProductResponse(*savedProduct, 0, nil) // Initial quantity is always 0
+	return &response, nil
+}
+
+func (s *service) UpdateExistingProduct(ctx context.Context, id string, input UpdateProductInput, precondition etag.Precondition) (*ProductResponse, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !precondition.Allows(product.Version) {
+		return nil, etag.ErrPreconditionFailed
+	}
+
+	return s.update(ctx, product, input)
+}
+
+func (s *service) PatchProduct(ctx context.Context, id string, p patch.Patch, precondition etag.Precondition) (*ProductResponse, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if !precondition.Allows(product.Version) {
+		return nil, etag.ErrPreconditionFailed
+	}
 
-	response := &ProductResponse{
-		ID:                 savedProduct.ID,
-		Name:               savedProduct.Name,
-		Description:        savedProduct.Description,
-		Price:              savedProduct.Price,
-		CalculatedQuantity: 0, // Initial quantity is always 0
+	input := UpdateProductInput{
+		SKU:               product.SKU,
+		Name:              product.Name,
+		Description:       product.Description,
+		Price:             money.ToMajor(float64(product.PriceMinor), product.Currency),
+		Currency:          product.Currency,
+		Type:              product.Type,
+		Barcodes:          barcodeCodes(product.Barcodes),
+		PrimaryCategoryID: product.PrimaryCategoryID,
+		CategoryIDs:       categoryIDs(product.Categories),
+		Attributes:        product.Attributes,
+		BaseUnitID:        product.BaseUnitID,
+		PurchaseUnitID:    product.PurchaseUnitID,
+		SalesUnitID:       product.SalesUnitID,
+		Conversions:       conversionInputs(product.Conversions),
+	}
+	// Updates can't archive, so an archived status isn't filled in; a product keeps
+	// its status unless the patch sets one.
+	if product.Status != Archived {
+		input.Status = product.Status
+	}
+	if err := p.Apply(&input); err != nil {
+		return nil, err
 	}
 
-	return response, nil
+	return s.update(ctx, product, input)
 }
 
-func (s *service) UpdateExistingProduct(id string, input UpdateProductInput) (*ProductResponse, error) {
-	product, err := s.productRepo.FindByID(id)
+func (s *service) update(ctx context.Context, product *Product, input UpdateProductInput) (*ProductResponse, error) {
+	if err := s.checkSKUs(ctx, []string{input.SKU}, product.ID, 0); err != nil {
+		return nil, err
+	}
+	barcodes, err := newBarcodes(input.Barcodes)
 	if err != nil {
 		return nil, err
 	}
+	currency := product.Currency
+	if input.Currency != "" {
+		if currency, err = money.Normalize(input.Currency); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkCurrencyChange(product, currency); err != nil {
+		return nil, err
+	}
+
+	// Archived products are only made active again by restoring them.
+	if product.Status == Archived && input.Status != "" {
+		return nil, ErrArchived
+	}
 
-	// Update fields if they are provided in the input.
+	if productType(input.Type) == Kit && product.Type != Kit {
+		// Kits have no stock of their own, so existing stock would be stranded.
+		quantity, err := s.stockCalculator.CalculateStockForProduct(ctx, product.ID)
+		if err != nil {
+			return nil, err
+		}
+		if quantity != 0 {
+			return nil, ErrKitHasStock
+		}
+	}
+
+	product.SKU = input.SKU
 	product.Name = input.Name
 	product.Description = input.Description
-	product.Price = input.Price
+	product.PriceMinor = money.ToMinor(input.Price, currency)
+	product.Currency = currency
+	product.Type = productType(input.Type)
+	if input.Status != "" {
+		product.Status = input.Status
+	}
+	product.Barcodes = barcodes
+	if err := s.categorize(ctx, product, input.PrimaryCategoryID, input.CategoryIDs); err != nil {
+		return nil, err
+	}
+	if err := s.setAttributes(ctx, product, input.Attributes); err != nil {
+		return nil, err
+	}
+	if !sameUnit(product.BaseUnitID, input.BaseUnitID) {
+		// Stock is kept in the base unit, so changing it would change the meaning of
+		// every past movement.
+		quantity, err := s.stockCalculator.CalculateStockForProduct(ctx, product.ID)
+		if err != nil {
+			return nil, err
+		}
+		if quantity != 0 {
+			return nil, ErrBaseUnitHasStock
+		}
+	}
+	if err := s.setUnits(ctx, product, input.BaseUnitID, input.PurchaseUnitID, input.SalesUnitID, input.Conversions); err != nil {
+		return nil, err
+	}
 
-	updatedProduct, err := s.productRepo.Update(product)
+	updatedProduct, err := s.productRepo.Update(ctx, product)
 	if err != nil {
-		return nil, err
+		return nil, translateConflict(err)
 	}
 
 	// after updating, we need to recalculate the stock
-	quantity, err := s.stockCalculator.CalculateStockForProduct(updatedProduct.ID)
+	return s.respond(ctx, updatedProduct)
+}
+
+// DeleteProductByID deletes a product that never moved stock. Products with stock or
+// inventory history are refused with ErrHasHistory, so the ledger keeps pointing at
+// them; they can be archived instead.
+func (s *service) DeleteProductByID(ctx context.Context, id string, precondition etag.Precondition) error {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !precondition.Allows(product.Version) {
+		return etag.ErrPreconditionFailed
+	}
+	if _, isComponent, err := s.productRepo.BOMUsage(ctx, product.ID); err != nil {
+		return err
+	} else if isComponent {
+		return ErrUsedAsComponent
+	}
+	if hasHistory, err := s.stockCalculator.HasTransactions(ctx, product.ID); err != nil {
+		return err
+	} else if hasHistory {
+		return ErrHasHistory
+	}
+
+	return s.productRepo.Delete(ctx, product)
+}
+
+// GenerateVariants replaces the product's options and creates a variant for every
+// combination of their values that doesn't have one yet. Existing variants are kept,
+// even if their combination is no longer part of the options.
+func (s *service) GenerateVariants(ctx context.Context, id string, input GenerateVariantsInput, precondition etag.Precondition) (*ProductResponse, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !precondition.Allows(product.Version) {
+		return nil, etag.ErrPreconditionFailed
+	}
+
+	hasComponents, isComponent, err := s.productRepo.BOMUsage(ctx, product.ID)
 	if err != nil {
 		return nil, err
 	}
+	if hasComponents || isComponent {
+		return nil, ErrVariantsInBOM
+	}
 
-	response := &ProductResponse{
-		ID:                 updatedProduct.ID,
-		Name:               updatedProduct.Name,
-		Description:        updatedProduct.Description,
-		Price:              updatedProduct.Price,
+	options := make([]Option, 0, len(input.Options))
+	for _, option := range input.Options {
+		options = append(options, Option{Name: option.Name, Values: option.Values})
+	}
+	if err := validateOptions(options); err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(product.Variants))
+	for _, variant := range product.Variants {
+		existing[combinationKey(options, variant.OptionValues)] = true
+	}
+	var variants []Variant
+	var skus []string
+	for _, values := range combinations(options) {
+		if existing[combinationKey(options, values)] {
+			continue
+		}
+		sku := variantSKU(product.SKU, options, values)
+		variants = append(variants, Variant{SKU: sku, OptionValues: values})
+		skus = append(skus, sku)
+	}
+	if len(skus) > 0 {
+		if err := s.checkSKUs(ctx, skus, 0, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	product.Options = options
+	if err := s.productRepo.AddVariants(ctx, product, variants); err != nil {
+		return nil, translateConflict(err)
+	}
+
+	return s.respond(ctx, product)
+}
+
+func (s *service) ListVariants(ctx context.Context, id string) ([]VariantResponse, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.respond(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+	return response.Variants, nil
+}
+
+func (s *service) UpdateVariant(ctx context.Context, id, variantID string, input UpdateVariantInput) (*VariantResponse, error) {
+	product, variant, err := s.findVariant(ctx, id, variantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkSKUs(ctx, []string{input.SKU}, 0, variant.ID); err != nil {
+		return nil, err
+	}
+
+	variant.SKU = input.SKU
+	variant.PriceOverrideMinor = nil
+	if input.PriceOverride != nil {
+		override := money.ToMinor(*input.PriceOverride, product.Currency)
+		variant.PriceOverrideMinor = &override
+	}
+	if _, err := s.productRepo.UpdateVariant(ctx, variant); err != nil {
+		return nil, translateConflict(err)
+	}
+
+	stock, err := s.stockCalculator.CalculateStockForVariants(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	response := toVariantResponse(*product, *variant, stock[variant.ID])
+	return &response, nil
+}
+
+// DeleteVariant deletes a variant that has no stock left.
+func (s *service) DeleteVariant(ctx context.Context, id, variantID string) error {
+	product, variant, err := s.findVariant(ctx, id, variantID)
+	if err != nil {
+		return err
+	}
+
+	stock, err := s.stockCalculator.CalculateStockForVariants(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	if stock[variant.ID] != 0 {
+		return ErrVariantHasStock
+	}
+
+	return s.productRepo.DeleteVariant(ctx, variant)
+}
+
+func (s *service) findVariant(ctx context.Context, id, variantID string) (*Product, *Variant, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	variant, err := s.productRepo.FindVariant(ctx, product.ID, variantID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return product, variant, nil
+}
+
+// GetBOM returns the product's bill of materials and its explosion into the
+// components that have none themselves.
+func (s *service) GetBOM(ctx context.Context, id string) (*BOMResponse, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.bomResponse(ctx, product)
+}
+
+// SetBOM replaces the product's bill of materials. Components must exist, have no
+// variants and must not contain the product, at any level, and no bill of materials
+// may become deeper than MaxBOMDepth levels.
+func (s *service) SetBOM(ctx context.Context, id string, input SetBOMInput, precondition etag.Precondition) (*BOMResponse, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !precondition.Allows(product.Version) {
+		return nil, etag.ErrPreconditionFailed
+	}
+	if len(product.Variants) > 0 {
+		return nil, ErrVariantsInBOM
+	}
+
+	seen := make(map[uint]bool, len(input.Components))
+	ids := make([]uint, 0, len(input.Components))
+	for _, component := range input.Components {
+		if component.ProductID == product.ID {
+			return nil, ErrBOMCycle
+		}
+		if seen[component.ProductID] {
+			return nil, ErrInvalidBOM
+		}
+		seen[component.ProductID] = true
+		ids = append(ids, component.ProductID)
+	}
+
+	lines := make([]BOMLine, 0, len(input.Components))
+	if len(ids) > 0 {
+		components, err := s.productRepo.FindByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		if len(components) != len(ids) {
+			return nil, ErrUnknownComponent
+		}
+		byID := make(map[uint]Product, len(components))
+		for _, component := range components {
+			byID[component.ID] = component
+		}
+
+		for _, input := range input.Components {
+			component := byID[input.ProductID]
+			if len(component.Variants) > 0 {
+				return nil, ErrVariantsInBOM
+			}
+			if _, err := component.ToBase(input.Quantity, nil); err != nil {
+				return nil, ErrInvalidBOM
+			}
+			lines = append(lines, BOMLine{ComponentID: component.ID, Quantity: input.Quantity})
+		}
+	}
+
+	// The check and the change are made under the lock, so that two changes made at
+	// the same time can't together create a cycle.
+	err = s.productRepo.WithBOMLock(ctx, func(repo Repository) error {
+		if len(ids) > 0 {
+			edges, err := repo.BOMEdges(ctx, ids)
+			if err != nil {
+				return err
+			}
+			above, err := repo.BOMDepthAbove(ctx, product.ID)
+			if err != nil {
+				return err
+			}
+			if err := checkBOMShape(product.ID, ids, edges, above); err != nil {
+				return err
+			}
+		}
+		return repo.ReplaceBOM(ctx, product, lines)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.bomResponse(ctx, product)
+}
+
+func (s *service) bomResponse(ctx context.Context, product *Product) (*BOMResponse, error) {
+	lines, err := s.productRepo.FindBOM(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	components := make([]BOMComponentResponse, 0, len(lines))
+	for _, line := range lines {
+		components = append(components, toBOMComponentResponse(line.Component, line.Quantity))
+	}
+
+	edges, err := s.productRepo.BOMEdges(ctx, []uint{product.ID})
+	if err != nil {
+		return nil, err
+	}
+	requirements := Explode(edges, product.ID, 1, nil)
+	explosion := make([]BOMComponentResponse, 0, len(requirements))
+	if len(requirements) > 0 {
+		materials, err := s.productRepo.FindByIDs(ctx, mapKeys(requirements))
+		if err != nil {
+			return nil, err
+		}
+		for _, material := range materials {
+			explosion = append(explosion, toBOMComponentResponse(material, requirements[material.ID]))
+		}
+	}
+
+	return &BOMResponse{
+		ProductID:  product.ID,
+		Type:       product.Type,
+		Components: components,
+		Explosion:  explosion,
+		Version:    product.Version,
+	}, nil
+}
+
+// checkSKUs validates the SKUs and makes sure no product or variant other than the
+// given ones has one of them.
+func (s *service) checkSKUs(ctx context.Context, skus []string, exceptProductID, exceptVariantID uint) error {
+	for _, sku := range skus {
+		if len(sku) > 64 || !skuPattern.MatchString(sku) {
+			return ErrInvalidSKU
+		}
+	}
+
+	inUse, err := s.productRepo.SKUsInUse(ctx, skus, exceptProductID, exceptVariantID)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrSKUInUse
+	}
+	return nil
+}
+
+// categorize assigns the product to its primary and secondary categories, which must
+// exist. The primary category is never also a secondary one.
+func (s *service) categorize(ctx context.Context, product *Product, primaryID *uint, secondaryIDs []uint) error {
+	seen := map[uint]bool{}
+	if primaryID != nil {
+		seen[*primaryID] = true
+	}
+	categories := make([]category.Category, 0, len(secondaryIDs))
+	for _, id := range secondaryIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		categories = append(categories, category.Category{Model: gorm.Model{ID: id}})
+	}
+
+	if len(seen) > 0 {
+		ids := make([]uint, 0, len(seen))
+		for id := range seen {
+			ids = append(ids, id)
+		}
+		count, err := s.categories.CountExisting(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if count != int64(len(ids)) {
+			return ErrUnknownCategory
+		}
+	}
+
+	product.PrimaryCategoryID = primaryID
+	product.Categories = categories
+	return nil
+}
+
+// setAttributes validates the attributes against the definitions of the product's
+// categories and sets them. Attributes set to null are dropped.
+func (s *service) setAttributes(ctx context.Context, product *Product, attributes map[string]interface{}) error {
+	ids := categoryIDs(product.Categories)
+	if product.PrimaryCategoryID != nil {
+		ids = append(ids, *product.PrimaryCategoryID)
+	}
+	definitions, err := s.categories.AttributeDefinitions(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if err := category.ValidateAttributes(definitions, attributes); err != nil {
+		return err
+	}
+
+	product.Attributes = make(map[string]interface{}, len(attributes))
+	for key, value := range attributes {
+		if value != nil {
+			product.Attributes[key] = value
+		}
+	}
+	return nil
+}
+
+// quantity returns the product's stock or, for a kit, how many kits can be made from
+// the stock of its components.
+func (s *service) quantity(ctx context.Context, product *Product) (float64, error) {
+	if product.Type != Kit {
+		return s.stockCalculator.CalculateStockForProduct(ctx, product.ID)
+	}
+
+	edges, err := s.productRepo.BOMEdges(ctx, []uint{product.ID})
+	if err != nil {
+		return 0, err
+	}
+	requirements := Explode(edges, product.ID, 1, ThroughKits)
+	if len(requirements) == 0 {
+		return 0, nil
+	}
+	stock, err := s.stockCalculator.CalculateStockForProducts(ctx, mapKeys(requirements))
+	if err != nil {
+		return 0, err
+	}
+	return Available(requirements, stock), nil
+}
+
+// setUnits sets the product's base, purchase and sales units and its unit conversions.
+// The units must exist, and the purchase and sales units must convert to the base unit.
+func (s *service) setUnits(ctx context.Context, product *Product, baseID, purchaseID, salesID *uint, conversions []ConversionInput) error {
+	if baseID == nil {
+		if purchaseID != nil || salesID != nil || len(conversions) > 0 {
+			return ErrBaseUnitRequired
+		}
+		product.BaseUnitID, product.BaseUnit = nil, nil
+		product.PurchaseUnitID, product.SalesUnitID = nil, nil
+		product.Conversions = nil
+		return nil
+	}
+
+	ids := []uint{*baseID}
+	for _, id := range []*uint{purchaseID, salesID} {
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+	for _, conversion := range conversions {
+		ids = append(ids, conversion.UnitID)
+	}
+	units, err := s.units.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint]uom.Unit, len(units))
+	for _, unit := range units {
+		byID[unit.ID] = unit
+	}
+	for _, id := range ids {
+		if _, ok := byID[id]; !ok {
+			return ErrUnknownUnit
+		}
+	}
+
+	seen := map[uint]bool{*baseID: true}
+	converted := make([]UnitConversion, 0, len(conversions))
+	for _, conversion := range conversions {
+		if seen[conversion.UnitID] || conversion.Factor <= 0 {
+			return ErrInvalidConversion
+		}
+		seen[conversion.UnitID] = true
+		converted = append(converted, UnitConversion{
+			UnitID: conversion.UnitID,
+			Unit:   byID[conversion.UnitID],
+			Factor: conversion.Factor,
+		})
+	}
+
+	base := byID[*baseID]
+	product.BaseUnitID, product.BaseUnit = baseID, &base
+	product.Conversions = converted
+	for _, id := range []*uint{purchaseID, salesID} {
+		if id == nil {
+			continue
+		}
+		if _, ok := product.conversionFactor(byID[*id]); !ok {
+			return ErrNoConversion
+		}
+	}
+	product.PurchaseUnitID, product.SalesUnitID = purchaseID, salesID
+	return nil
+}
+
+// respond returns the product with its current stock and attachments. The stock of
+// a product with variants is the total stock of its variants; that of a kit is how
+// many kits its components' stock makes up.
+func (s *service) respond(ctx context.Context, product *Product) (*ProductResponse, error) {
+	quantity, err := s.quantity(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+
+	var variantStock map[uint]float64
+	if len(product.Variants) > 0 {
+		variantStock, err = s.stockCalculator.CalculateStockForVariants(ctx, product.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response := toProductResponse(*product, quantity, variantStock)
+	response.Attachments, err = s.attachments.ProductAttachments(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func toProductResponse(product Product, quantity float64, variantStock map[uint]float64) ProductResponse {
+	variants := make([]VariantResponse, 0, len(product.Variants))
+	for _, variant := range product.Variants {
+		variants = append(variants, toVariantResponse(product, variant, variantStock[variant.ID]))
+	}
+	conversions := make([]ConversionResponse, 0, len(product.Conversions))
+	for _, conversion := range product.Conversions {
+		conversions = append(conversions, ConversionResponse{
+			UnitID: conversion.UnitID,
+			Unit:   conversion.Unit.Code,
+			Factor: conversion.Factor,
+		})
+	}
+	var baseUnit string
+	if product.BaseUnit != nil {
+		baseUnit = product.BaseUnit.Code
+	}
+
+	return ProductResponse{
+		ID:                 product.ID,
+		SKU:                product.SKU,
+		Name:               product.Name,
+		Description:        product.Description,
+		Price:              money.ToMajor(float64(product.PriceMinor), product.Currency),
+		PriceMinor:         product.PriceMinor,
+		Currency:           product.Currency,
+		Type:               product.Type,
+		Status:             product.Status,
+		Barcodes:           barcodeCodes(product.Barcodes),
+		PrimaryCategoryID:  product.PrimaryCategoryID,
+		CategoryIDs:        categoryIDs(product.Categories),
+		Attributes:         product.Attributes,
+		Options:            product.Options,
+		Variants:           variants,
+		BaseUnitID:         product.BaseUnitID,
+		BaseUnit:           baseUnit,
+		PurchaseUnitID:     product.PurchaseUnitID,
+		SalesUnitID:        product.SalesUnitID,
+		Conversions:        conversions,
 		CalculatedQuantity: quantity,
+		Attachments:        []AttachmentLink{},
+		Version:            product.Version,
+	}
+}
+
+func toBOMComponentResponse(component Product, quantity float64) BOMComponentResponse {
+	response := BOMComponentResponse{
+		ProductID: component.ID,
+		SKU:       component.SKU,
+		Name:      component.Name,
+		Type:      component.Type,
+		Quantity:  quantity,
+	}
+	if component.BaseUnit != nil {
+		response.Unit = component.BaseUnit.Code
 	}
+	return response
+}
+
+func toVariantResponse(product Product, variant Variant, quantity float64) VariantResponse {
+	var override *float64
+	if variant.PriceOverrideMinor != nil {
+		price := money.ToMajor(float64(*variant.PriceOverrideMinor), product.Currency)
+		override = &price
+	}
+	return VariantResponse{
+		ID:            variant.ID,
+		SKU:           variant.SKU,
+		OptionValues:  variant.OptionValues,
+		PriceOverride: override,
+		Price:         money.ToMajor(float64(variant.PriceMinor(product)), product.Currency),
+		PriceMinor:    variant.PriceMinor(product),
+		Quantity:      quantity,
+	}
+}
+
+// translateConflict turns a violation of a unique SKU or barcode index into
+// ErrSKUInUse or ErrBarcodeInUse.
+func translateConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		switch pgErr.ConstraintName {
+		case "idx_products_org_sku", "idx_product_variants_org_sku":
+			return ErrSKUInUse
+		case "idx_product_barcodes_org_gtin":
+			return ErrBarcodeInUse
+		}
+	}
+	return err
+}
+
+func categoryIDs(categories []category.Category) []uint {
+	ids := make([]uint, 0, len(categories))
+	for _, c := range categories {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func conversionInputs(conversions []UnitConversion) []ConversionInput {
+	inputs := make([]ConversionInput, 0, len(conversions))
+	for _, conversion := range conversions {
+		inputs = append(inputs, ConversionInput{UnitID: conversion.UnitID, Factor: conversion.Factor})
+	}
+	return inputs
+}
 
-	return response, nil
+func sameUnit(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
 
-func (s *service) DeleteProductByID(id string) error {
-	return s.productRepo.Delete(id)
+func productType(t ProductType) ProductType {
+	if t == "" {
+		return Standard
+	}
+	return t
+}
+
+func mapKeys(m map[uint]float64) []uint {
+	keys := make([]uint, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
 }
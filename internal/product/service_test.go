@@ -1 +1,144 @@
 package product
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeProducts keeps one product and the price changes scheduled for it in memory.
+// Methods the tests don't need panic through the embedded nil interface.
+type fakeProducts struct {
+	Repository
+	product     Product
+	changes     []PriceChange
+	isComponent bool
+	deleted     bool
+}
+
+func (r *fakeProducts) FindByID(ctx context.Context, id string) (*Product, error) {
+	product := r.product
+	return &product, nil
+}
+
+// PriceAt returns the latest change in effect at the time, like the query does.
+func (r *fakeProducts) PriceAt(ctx context.Context, productID uint, at time.Time) (*PriceChange, error) {
+	var found *PriceChange
+	for i, change := range r.changes {
+		if change.EffectiveAt != nil && !change.EffectiveAt.After(at) &&
+			(found == nil || change.EffectiveAt.After(*found.EffectiveAt)) {
+			found = &r.changes[i]
+		}
+	}
+	if found == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return found, nil
+}
+
+func (r *fakeProducts) SchedulePriceChange(ctx context.Context, change *PriceChange) error {
+	r.changes = append(r.changes, *change)
+	return nil
+}
+
+func TestSchedulePriceCurrencyWithOverrides(t *testing.T) {
+	override := int64(1499)
+	tests := []struct {
+		name     string
+		variants []Variant
+		currency string
+		wantErr  error
+	}{
+		{"same currency with overrides", []Variant{{PriceOverrideMinor: &override}}, "EUR", nil},
+		{"other currency without overrides", []Variant{{}}, "USD", nil},
+		{"other currency with overrides", []Variant{{}, {PriceOverrideMinor: &override}}, "USD", ErrOverridesInCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := &fakeProducts{product: Product{
+				Model:      gorm.Model{ID: 1},
+				PriceMinor: 1999,
+				Currency:   "EUR",
+				Variants:   tt.variants,
+			}}
+			s := &service{productRepo: products}
+
+			_, err := s.SchedulePrice(context.Background(), "1", SchedulePriceInput{
+				Price:       24.99,
+				Currency:    tt.currency,
+				EffectiveAt: time.Now().Add(time.Hour),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if scheduled := len(products.changes) > 0; scheduled != (tt.wantErr == nil) {
+				t.Errorf("change scheduled = %v, want %v", scheduled, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestGetPriceAtScheduledBoundary(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	due := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	oldPrice := int64(1999)
+	scheduled := PriceChange{NewPriceMinor: 2499, NewCurrency: "EUR", ScheduledFor: &due}
+	// The scheduler runs a while after the change is due.
+	effectiveAt := scheduled.effectiveAt(due.Add(45 * time.Second))
+	scheduled.OldPriceMinor, scheduled.EffectiveAt = &oldPrice, &effectiveAt
+
+	products := &fakeProducts{
+		product: Product{Model: gorm.Model{ID: 1}, PriceMinor: 2499, Currency: "EUR"},
+		changes: []PriceChange{
+			{NewPriceMinor: oldPrice, NewCurrency: "EUR", EffectiveAt: &created},
+			scheduled,
+		},
+	}
+	s := &service{productRepo: products}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want float64
+	}{
+		{"just before it was due", due.Add(-time.Nanosecond), 19.99},
+		{"exactly when it was due", due, 24.99},
+		{"after it was due", due.Add(time.Minute), 24.99},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, err := s.GetPriceAt(context.Background(), "1", tt.at, "")
+			if err != nil {
+				t.Fatalf("GetPriceAt: %v", err)
+			}
+			if price.Price != tt.want {
+				t.Errorf("price = %v, want %v", price.Price, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceChangeEffectiveAt(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	earlier, later := now.Add(-time.Minute), now.Add(time.Minute)
+	tests := []struct {
+		name   string
+		change PriceChange
+		want   time.Time
+	}{
+		{"not scheduled", PriceChange{}, now},
+		{"applied after it was due", PriceChange{ScheduledFor: &earlier}, earlier},
+		{"applied exactly when due", PriceChange{ScheduledFor: &now}, now},
+		{"applied before it was due", PriceChange{ScheduledFor: &later}, now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.change.effectiveAt(now); !got.Equal(tt.want) {
+				t.Errorf("effectiveAt = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
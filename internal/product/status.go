@@ -0,0 +1,86 @@
+package product
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/etag"
+	"gorm.io/gorm"
+)
+
+type ProductStatus string
+
+const (
+	// Draft products are being set up and not yet sold.
+	Draft ProductStatus = "draft"
+	// Active products are sold and restocked.
+	Active ProductStatus = "active"
+	// Discontinued products are sold off but no longer restocked.
+	Discontinued ProductStatus = "discontinued"
+	// Archived products are kept for their history only: they are hidden unless asked
+	// for and their stock doesn't move.
+	Archived ProductStatus = "archived"
+)
+
+var (
+	ErrHasHistory  = errors.New("the product has stock or inventory history and can't be deleted; archive it instead")
+	ErrArchived    = errors.New("the product is archived; restore it first")
+	ErrNotArchived = errors.New("the product is not archived")
+)
+
+// ArchiveProduct hides a product without losing its history. Archiving an archived
+// product changes nothing.
+func (s *service) ArchiveProduct(ctx context.Context, id string, precondition etag.Precondition) (*ProductResponse, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !precondition.Allows(product.Version) {
+		return nil, etag.ErrPreconditionFailed
+	}
+	if product.Status == Archived {
+		return s.respond(ctx, product)
+	}
+
+	return s.setStatus(ctx, product, Archived)
+}
+
+// RestoreProduct makes an archived product active again.
+func (s *service) RestoreProduct(ctx context.Context, id string, precondition etag.Precondition) (*ProductResponse, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !precondition.Allows(product.Version) {
+		return nil, etag.ErrPreconditionFailed
+	}
+	if product.Status != Archived {
+		return nil, ErrNotArchived
+	}
+
+	return s.setStatus(ctx, product, Active)
+}
+
+func (s *service) setStatus(ctx context.Context, product *Product, status ProductStatus) (*ProductResponse, error) {
+	product.Status = status
+	updatedProduct, err := s.productRepo.Update(ctx, product)
+	if err != nil {
+		return nil, translateConflict(err)
+	}
+	return s.respond(ctx, updatedProduct)
+}
+
+// visible hides archived products from lookups that don't include them.
+func visible(product *Product, includeArchived bool) error {
+	if product.Status == Archived && !includeArchived {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func productStatus(status ProductStatus) ProductStatus {
+	if status == "" {
+		return Active
+	}
+	return status
+}
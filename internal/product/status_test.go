@@ -0,0 +1,131 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func (r *fakeProducts) BOMUsage(ctx context.Context, productID uint) (bool, bool, error) {
+	return false, r.isComponent, nil
+}
+
+func (r *fakeProducts) Update(ctx context.Context, product *Product) (*Product, error) {
+	r.product = *product
+	return product, nil
+}
+
+func (r *fakeProducts) Delete(ctx context.Context, product *Product) error {
+	r.deleted = true
+	return nil
+}
+
+// fakeStock reports no stock, and inventory history as set.
+type fakeStock struct {
+	InventoryStockCalculator
+	hasHistory bool
+}
+
+func (s fakeStock) CalculateStockForProduct(ctx context.Context, productID uint) (float64, error) {
+	return 0, nil
+}
+
+func (s fakeStock) HasTransactions(ctx context.Context, productID uint) (bool, error) {
+	return s.hasHistory, nil
+}
+
+type fakeAttachments struct{}
+
+func (fakeAttachments) ProductAttachments(ctx context.Context, productID uint) ([]AttachmentLink, error) {
+	return nil, nil
+}
+
+func newStatusService(status ProductStatus, hasHistory bool) (*service, *fakeProducts) {
+	products := &fakeProducts{product: Product{Model: gorm.Model{ID: 1}, Status: status, Currency: "EUR"}}
+	return &service{
+		productRepo:     products,
+		stockCalculator: fakeStock{hasHistory: hasHistory},
+		attachments:     fakeAttachments{},
+	}, products
+}
+
+func TestDeleteProductByID(t *testing.T) {
+	tests := []struct {
+		name        string
+		hasHistory  bool
+		isComponent bool
+		wantErr     error
+	}{
+		{"without history", false, false, nil},
+		{"with inventory history", true, false, ErrHasHistory},
+		{"component of another product", false, true, ErrUsedAsComponent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, products := newStatusService(Active, tt.hasHistory)
+			products.isComponent = tt.isComponent
+
+			err := s.DeleteProductByID(context.Background(), "1", "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if products.deleted != (tt.wantErr == nil) {
+				t.Errorf("deleted = %v, want %v", products.deleted, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestRestoreProduct(t *testing.T) {
+	tests := []struct {
+		status  ProductStatus
+		wantErr error
+	}{
+		{Archived, nil},
+		{Active, ErrNotArchived},
+		{Draft, ErrNotArchived},
+		{Discontinued, ErrNotArchived},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			s, products := newStatusService(tt.status, true)
+
+			_, err := s.RestoreProduct(context.Background(), "1", "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			want := tt.status
+			if tt.wantErr == nil {
+				want = Active
+			}
+			if products.product.Status != want {
+				t.Errorf("status = %s, want %s", products.product.Status, want)
+			}
+		})
+	}
+}
+
+func TestGetProductByIDHidesArchived(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          ProductStatus
+		includeArchived bool
+		wantErr         error
+	}{
+		{"active", Active, false, nil},
+		{"archived", Archived, false, gorm.ErrRecordNotFound},
+		{"archived with include_archived", Archived, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newStatusService(tt.status, false)
+
+			_, err := s.GetProductByID(context.Background(), "1", tt.includeArchived)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
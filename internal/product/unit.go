@@ -0,0 +1,71 @@
+package product
+
+import (
+	"errors"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/uom"
+)
+
+var (
+	ErrUnknownUnit        = errors.New("unit not found")
+	ErrBaseUnitRequired   = errors.New("purchase and sales units and conversions need a base unit")
+	ErrInvalidConversion  = errors.New("conversions need a factor above zero and a unit other than the base unit, at most once each")
+	ErrNoConversion       = errors.New("the unit can't be converted to the product's base unit")
+	ErrFractionalQuantity = errors.New("the product is counted in whole units; the quantity must be a whole number of its base unit")
+	ErrBaseUnitHasStock   = errors.New("the base unit can't change while the product has stock")
+)
+
+// UnitConversion is how many of a product's base unit one of another unit holds, e.g.
+// a case of 24 bottles. It applies to this product only and takes precedence over the
+// units' standard factors.
+type UnitConversion struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	OrgID     uint     `gorm:"not null;index"`
+	ProductID uint     `gorm:"not null;uniqueIndex:idx_product_unit_conversions_product_unit"`
+	UnitID    uint     `gorm:"not null;uniqueIndex:idx_product_unit_conversions_product_unit"`
+	Unit      uom.Unit `gorm:"foreignKey:UnitID"`
+	Factor    float64  `gorm:"type:numeric(18,6);not null"`
+}
+
+func (UnitConversion) TableName() string {
+	return "product_unit_conversions"
+}
+
+func (UnitConversion) AuditEntityType() string {
+	return "product_unit_conversion"
+}
+
+// ToBase converts a quantity in a unit to the product's base unit: by the product's
+// own conversion for the unit, or else by the units' standard factors. A product
+// without a base unit is counted in whole, unnamed units and takes no unit.
+func (p Product) ToBase(quantity float64, unit *uom.Unit) (float64, error) {
+	if unit != nil {
+		factor, ok := p.conversionFactor(*unit)
+		if !ok {
+			return 0, ErrNoConversion
+		}
+		quantity *= factor
+	}
+
+	if p.BaseUnit == nil || !p.BaseUnit.Decimal {
+		if !uom.IsWhole(quantity) {
+			return 0, ErrFractionalQuantity
+		}
+	}
+	return quantity, nil
+}
+
+// conversionFactor returns how many of the product's base unit one of the unit is.
+func (p Product) conversionFactor(unit uom.Unit) (float64, bool) {
+	if p.BaseUnit == nil {
+		return 0, false
+	}
+	for _, conversion := range p.Conversions {
+		if conversion.UnitID == unit.ID {
+			return conversion.Factor, true
+		}
+	}
+	return uom.Factor(unit, *p.BaseUnit)
+}
@@ -0,0 +1,111 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/RezaBG/Inventory-management-api/internal/uom"
+	"gorm.io/gorm"
+)
+
+var (
+	testKg     = uom.Unit{Model: gorm.Model{ID: 1}, Code: "kg", Dimension: uom.Mass, StandardFactor: 1, Decimal: true}
+	testGram   = uom.Unit{Model: gorm.Model{ID: 2}, Code: "g", Dimension: uom.Mass, StandardFactor: 0.001}
+	testEach   = uom.Unit{Model: gorm.Model{ID: 3}, Code: "ea", Dimension: uom.Count, StandardFactor: 1}
+	testDozen  = uom.Unit{Model: gorm.Model{ID: 4}, Code: "dz", Dimension: uom.Count, StandardFactor: 12}
+	testCase   = uom.Unit{Model: gorm.Model{ID: 5}, Code: "case", Dimension: uom.Package, StandardFactor: 1}
+	testPallet = uom.Unit{Model: gorm.Model{ID: 6}, Code: "pallet", Dimension: uom.Package, StandardFactor: 1}
+	testDag    = uom.Unit{Model: gorm.Model{ID: 7}, Code: "dag", Dimension: uom.Mass, StandardFactor: 0.01}
+	testHg     = uom.Unit{Model: gorm.Model{ID: 8}, Code: "hg", Dimension: uom.Mass, StandardFactor: 0.1}
+)
+
+func TestProductToBase(t *testing.T) {
+	flour := Product{BaseUnit: &testKg}
+	bottles := Product{BaseUnit: &testEach, Conversions: []UnitConversion{{UnitID: testCase.ID, Factor: 24}}}
+	// Sweets are sold in whole 100 g bags.
+	sweets := Product{BaseUnit: &testHg}
+
+	tests := []struct {
+		name     string
+		product  Product
+		quantity float64
+		unit     *uom.Unit
+		want     float64
+		wantErr  error
+	}{
+		{"base unit", flour, 1.25, &testKg, 1.25, nil},
+		{"standard factor", flour, 250, &testGram, 0.25, nil},
+		{"no unit", bottles, 3, nil, 3, nil},
+		{"product conversion", bottles, 2, &testCase, 48, nil},
+		{"standard factor to whole units", bottles, 2, &testDozen, 24, nil},
+		// 30 × (0.01 / 0.1) is 2.9999999999999996 in floating point, but three bags.
+		{"rounding error of a conversion", sweets, 30, &testDag, 3, nil},
+		{"fraction of a whole unit", sweets, 25, &testDag, 0, ErrFractionalQuantity},
+		{"fraction without a unit", bottles, 1.5, nil, 0, ErrFractionalQuantity},
+		{"fraction without a base unit", Product{}, 0.5, nil, 0, ErrFractionalQuantity},
+		{"other dimension", flour, 1, &testEach, 0, ErrNoConversion},
+		{"package unit without a conversion", bottles, 1, &testPallet, 0, ErrNoConversion},
+		{"unit without a base unit", Product{}, 1, &testEach, 0, ErrNoConversion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.product.ToBase(tt.quantity, tt.unit)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ToBase = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeUnits knows the test units.
+type fakeUnits struct{}
+
+func (fakeUnits) FindByIDs(ctx context.Context, ids []uint) ([]uom.Unit, error) {
+	var units []uom.Unit
+	for _, unit := range []uom.Unit{testKg, testGram, testEach, testDozen, testCase, testPallet, testDag, testHg} {
+		for _, id := range ids {
+			if unit.ID == id {
+				units = append(units, unit)
+				break
+			}
+		}
+	}
+	return units, nil
+}
+
+func TestSetUnits(t *testing.T) {
+	id := func(unit uom.Unit) *uint { return &unit.ID }
+	unknown := uint(99)
+
+	tests := []struct {
+		name                  string
+		base, purchase, sales *uint
+		conversions           []ConversionInput
+		wantErr               error
+	}{
+		{"base unit only", id(testEach), nil, nil, nil, nil},
+		{"convertible units", id(testEach), id(testCase), id(testDozen), []ConversionInput{{UnitID: testCase.ID, Factor: 24}}, nil},
+		{"unknown base unit", &unknown, nil, nil, nil, ErrUnknownUnit},
+		{"unknown sales unit", id(testEach), nil, &unknown, nil, ErrUnknownUnit},
+		{"unknown conversion unit", id(testEach), nil, nil, []ConversionInput{{UnitID: unknown, Factor: 2}}, ErrUnknownUnit},
+		{"sales unit without a base unit", nil, nil, id(testEach), nil, ErrBaseUnitRequired},
+		{"purchase unit that doesn't convert", id(testEach), id(testPallet), nil, nil, ErrNoConversion},
+		{"conversion of the base unit", id(testEach), nil, nil, []ConversionInput{{UnitID: testEach.ID, Factor: 2}}, ErrInvalidConversion},
+		{"conversion without a factor", id(testEach), nil, nil, []ConversionInput{{UnitID: testCase.ID}}, ErrInvalidConversion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &service{units: fakeUnits{}}
+			var product Product
+			err := s.setUnits(context.Background(), &product, tt.base, tt.purchase, tt.sales, tt.conversions)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
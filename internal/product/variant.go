@@ -0,0 +1,153 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// MaxVariants limits the number of variants an option matrix may generate.
+const MaxVariants = 500
+
+var (
+	ErrInvalidOptions  = errors.New("options need unique, non-empty names and values")
+	ErrTooManyVariants = fmt.Errorf("an option matrix can generate at most %d variants", MaxVariants)
+	ErrVariantNotFound = errors.New("variant not found")
+	ErrVariantRequired = errors.New("this product has variants; give the variant")
+	ErrVariantHasStock = errors.New("variant still has stock; adjust it to zero first")
+	// Price overrides are in the product's currency, so changing it would change them.
+	ErrOverridesInCurrency = errors.New("variants have price overrides in the product's currency; remove them before changing the currency")
+)
+
+// Option is a dimension a product varies in, e.g. size with the values S, M and L.
+type Option struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// Variant is one combination of a product's option values, e.g. size M in red. It has
+// its own SKU and stock; its price is the product's unless it overrides it, in the
+// product's currency.
+type Variant struct {
+	gorm.Model
+	// SKU is unique among an organization's variants that haven't been deleted.
+	OrgID              uint              `gorm:"not null;index;uniqueIndex:idx_product_variants_org_sku,where:deleted_at IS NULL"`
+	ProductID          uint              `gorm:"not null;index"`
+	SKU                string            `gorm:"not null;size:64;uniqueIndex:idx_product_variants_org_sku"`
+	PriceOverrideMinor *int64            `gorm:"column:price_override_minor"`
+	OptionValues       map[string]string `gorm:"type:jsonb;serializer:json"`
+}
+
+func (Variant) TableName() string {
+	return "product_variants"
+}
+
+func (Variant) AuditEntityType() string {
+	return "product_variant"
+}
+
+// PriceMinor is the variant's price in minor units of the product's currency: its
+// override, or else the product's price.
+func (v Variant) PriceMinor(product Product) int64 {
+	if v.PriceOverrideMinor != nil {
+		return *v.PriceOverrideMinor
+	}
+	return product.PriceMinor
+}
+
+// checkCurrencyChange reports whether the product may be switched to the currency.
+func checkCurrencyChange(product *Product, currency string) error {
+	if currency == product.Currency {
+		return nil
+	}
+	for _, variant := range product.Variants {
+		if variant.PriceOverrideMinor != nil {
+			return ErrOverridesInCurrency
+		}
+	}
+	return nil
+}
+
+func validateOptions(options []Option) error {
+	names := map[string]bool{}
+	combinations := 1
+	for _, option := range options {
+		if option.Name == "" || names[option.Name] || len(option.Values) == 0 {
+			return ErrInvalidOptions
+		}
+		names[option.Name] = true
+
+		values := map[string]bool{}
+		for _, value := range option.Values {
+			if value == "" || values[value] {
+				return ErrInvalidOptions
+			}
+			values[value] = true
+		}
+
+		combinations *= len(option.Values)
+		if combinations > MaxVariants {
+			return ErrTooManyVariants
+		}
+	}
+	return nil
+}
+
+// combinations returns every combination of the option values, varying the last
+// option fastest.
+func combinations(options []Option) []map[string]string {
+	result := []map[string]string{{}}
+	for _, option := range options {
+		next := make([]map[string]string, 0, len(result)*len(option.Values))
+		for _, combination := range result {
+			for _, value := range option.Values {
+				values := make(map[string]string, len(combination)+1)
+				for name, v := range combination {
+					values[name] = v
+				}
+				values[option.Name] = value
+				next = append(next, values)
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+// combinationKey identifies a combination of option values within a product's options.
+func combinationKey(options []Option, values map[string]string) string {
+	parts := make([]string, 0, len(options))
+	for _, option := range options {
+		parts = append(parts, option.Name+"="+values[option.Name])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// variantSKU derives a variant's SKU from the product's and the option values, e.g.
+// TSHIRT-M-NAVYBLUE. Characters that aren't allowed in SKUs are dropped; a value
+// without any allowed characters is replaced by its position among the option's values.
+func variantSKU(productSKU string, options []Option, values map[string]string) string {
+	parts := []string{productSKU}
+	for _, option := range options {
+		value := values[option.Name]
+		part := strings.Map(func(r rune) rune {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_') {
+				return unicode.ToUpper(r)
+			}
+			return -1
+		}, value)
+		if part == "" {
+			for i, v := range option.Values {
+				if v == value {
+					part = strconv.Itoa(i + 1)
+				}
+			}
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "-")
+}
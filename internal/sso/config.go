@@ -0,0 +1,53 @@
+package sso
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// Config configures login through an external OpenID Connect provider.
+type Config struct {
+	// IssuerURL is used for discovery (<issuer>/.well-known/openid-configuration).
+	// For local development it can point at a mock IdP.
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL must point at GET /auth/oidc/callback and be registered with the IdP.
+	RedirectURL string
+	Scopes      []string
+	// DefaultRole is given to auto-provisioned users; empty means DEFAULT_USER_ROLE.
+	DefaultRole   string
+	StateLifetime time.Duration
+}
+
+// ConfigFromEnv reads the OIDC_* settings. It reports false when OIDC_ISSUER_URL is
+// not set, in which case single sign-on is disabled.
+func ConfigFromEnv() (Config, bool) {
+	cfg := Config{
+		IssuerURL:    os.Getenv("OIDC_ISSUER_URL"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:       strings.Fields(os.Getenv("OIDC_SCOPES")),
+		DefaultRole:  os.Getenv("OIDC_DEFAULT_ROLE"),
+	}
+	if cfg.IssuerURL == "" {
+		return cfg, false
+	}
+
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+
+	stateMinutes, _ := strconv.Atoi(os.Getenv("OIDC_STATE_EXPIRATION_MINUTES"))
+	if stateMinutes == 0 {
+		stateMinutes = 10
+	}
+	cfg.StateLifetime = time.Minute * time.Duration(stateMinutes)
+
+	return cfg, true
+}
@@ -0,0 +1,9 @@
+package sso
+
+// CallbackInput is the query string the identity provider redirects back with.
+type CallbackInput struct {
+	Code             string `form:"code"`
+	State            string `form:"state"`
+	Error            string `form:"error"`
+	ErrorDescription string `form:"error_description"`
+}
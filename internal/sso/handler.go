@@ -0,0 +1,87 @@
+package sso
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/RezaBG/Inventory-management-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// Login starts single sign-on.
+// @Summary      Start single sign-on
+// @Description  Redirects the browser to the OpenID Connect identity provider (authorization code flow with PKCE). Only available when OIDC is configured.
+// @Tags         Auth
+// @Success      302  "Redirect to the identity provider"
+// @Failure      502  {object}  map[string]interface{}
+// @Router       /auth/oidc/login [get]
+func (h *Handler) Login(c *gin.Context) {
+	authURL, err := h.svc.BeginLogin(c.Request.Context())
+	if err != nil {
+		log.Printf("could not start OIDC login: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider is unavailable"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback completes single sign-on.
+// @Summary      Complete single sign-on
+// @Description  The identity provider redirects here. Users are matched by IdP subject, then by verified email, and otherwise provisioned with the default role. Returns the same tokens as /login, or an MFA challenge if 2FA is enabled.
+// @Tags         Auth
+// @Produce      json
+// @Param        code               query     string  false  "Authorization code"
+// @Param        state              query     string  true   "State from /auth/oidc/login"
+// @Param        error              query     string  false  "Error code from the identity provider"
+// @Param        error_description  query     string  false  "Error description from the identity provider"
+// @Success      200  {object}  user.LoginResponse  "Tokens, or an MFAChallengeResponse when 2FA is enabled"
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /auth/oidc/callback [get]
+func (h *Handler) Callback(c *gin.Context) {
+	var input CallbackInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loginResponse, challenge, err := h.svc.CompleteLogin(c.Request.Context(), input)
+	if err != nil {
+		var providerErr *ProviderError
+		switch {
+		case errors.As(err, &providerErr):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": providerErr.Error()})
+		case errors.Is(err, ErrInvalidState), errors.Is(err, user.ErrExternalEmailRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, user.ErrExternalEmailInUse):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, user.ErrAccountDeactivated):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			if !errors.Is(err, ErrLoginFailed) {
+				log.Printf("OIDC login failed: %v", err)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": ErrLoginFailed.Error()})
+		}
+		return
+	}
+
+	if challenge != nil {
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": loginResponse})
+}
@@ -0,0 +1,19 @@
+package sso
+
+import "time"
+
+// LoginState holds what is needed to complete one authorization code flow: the nonce
+// expected in the ID token and the PKCE code verifier. It is looked up by the hash of
+// the state parameter and deleted when the callback arrives.
+type LoginState struct {
+	ID           uint   `gorm:"primarykey"`
+	StateHash    string `gorm:"uniqueIndex;not null"`
+	Nonce        string `gorm:"not null"`
+	CodeVerifier string `gorm:"not null"`
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+func (LoginState) TableName() string {
+	return "oidc_login_states"
+}
@@ -0,0 +1,45 @@
+package sso
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StateRepository interface {
+	Create(state *LoginState) error
+	Consume(stateHash string) (*LoginState, error)
+	DeleteExpired(now time.Time) error
+}
+
+type stateRepository struct {
+	db *gorm.DB
+}
+
+func NewStateRepository(db *gorm.DB) StateRepository {
+	return &stateRepository{db: db}
+}
+
+func (r *stateRepository) Create(state *LoginState) error {
+	return r.db.Create(state).Error
+}
+
+// Consume deletes and returns the state, so each state can complete at most one login.
+func (r *stateRepository) Consume(stateHash string) (*LoginState, error) {
+	var states []LoginState
+	err := r.db.Clauses(clause.Returning{}).
+		Where("state_hash = ?", stateHash).
+		Delete(&states).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &states[0], nil
+}
+
+func (r *stateRepository) DeleteExpired(now time.Time) error {
+	return r.db.Where("expires_at < ?", now).Delete(&LoginState{}).Error
+}
@@ -0,0 +1,8 @@
+package sso
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.Engine, h *Handler) {
+	router.GET("/auth/oidc/login", h.Login)
+	router.GET("/auth/oidc/callback", h.Callback)
+}
@@ -0,0 +1,213 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/user"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrInvalidState = errors.New("invalid or expired login state")
+	ErrLoginFailed  = errors.New("single sign-on failed")
+)
+
+// ProviderError is an error the identity provider sent back to the callback.
+type ProviderError struct {
+	Code        string
+	Description string
+}
+
+func (e *ProviderError) Error() string {
+	if e.Description == "" {
+		return "identity provider returned " + e.Code
+	}
+	return "identity provider returned " + e.Code + ": " + e.Description
+}
+
+type Service interface {
+	// BeginLogin starts an authorization code flow and returns the IdP URL to redirect to.
+	BeginLogin(ctx context.Context) (string, error)
+	// CompleteLogin handles the IdP's redirect back and signs the user in.
+	CompleteLogin(ctx context.Context, input CallbackInput) (*user.LoginResponse, *user.MFAChallengeResponse, error)
+}
+
+type service struct {
+	cfg       Config
+	stateRepo StateRepository
+	userSvc   user.Service
+
+	// The provider is discovered on first use, so the API can start while the IdP is down.
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func NewService(cfg Config, stateRepo StateRepository, userSvc user.Service) Service {
+	return &service{cfg: cfg, stateRepo: stateRepo, userSvc: userSvc}
+}
+
+func (s *service) BeginLogin(ctx context.Context) (string, error) {
+	provider, err := s.getProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := generateSecureRandomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("could not generate state: %w", err)
+	}
+	nonce, err := generateSecureRandomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("could not generate nonce: %w", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	now := time.Now()
+	if err := s.stateRepo.DeleteExpired(now); err != nil {
+		log.Printf("could not delete expired OIDC login states: %v", err)
+	}
+
+	err = s.stateRepo.Create(&LoginState{
+		StateHash:    hashState(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    now.Add(s.cfg.StateLifetime),
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not save login state: %w", err)
+	}
+
+	return s.oauth2Config(provider).AuthCodeURL(
+		state,
+		oidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(verifier),
+	), nil
+}
+
+func (s *service) CompleteLogin(ctx context.Context, input CallbackInput) (*user.LoginResponse, *user.MFAChallengeResponse, error) {
+	if input.Error != "" {
+		return nil, nil, &ProviderError{Code: input.Error, Description: input.ErrorDescription}
+	}
+	if input.State == "" || input.Code == "" {
+		return nil, nil, ErrInvalidState
+	}
+
+	state, err := s.stateRepo.Consume(hashState(input.State))
+	if err != nil || time.Now().After(state.ExpiresAt) {
+		return nil, nil, ErrInvalidState
+	}
+
+	provider, err := s.getProvider(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	oauthToken, err := s.oauth2Config(provider).Exchange(ctx, input.Code, oauth2.VerifierOption(state.CodeVerifier))
+	if err != nil {
+		log.Printf("OIDC code exchange failed: %v", err)
+		return nil, nil, ErrLoginFailed
+	}
+
+	rawIDToken, ok := oauthToken.Extra("id_token").(string)
+	if !ok {
+		log.Printf("OIDC token response did not contain an id_token")
+		return nil, nil, ErrLoginFailed
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: s.cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		log.Printf("OIDC ID token verification failed: %v", err)
+		return nil, nil, ErrLoginFailed
+	}
+	if idToken.Nonce != state.Nonce {
+		log.Printf("OIDC ID token nonce mismatch")
+		return nil, nil, ErrLoginFailed
+	}
+
+	var claims identityClaims
+	if err := idToken.Claims(&claims); err != nil {
+		log.Printf("could not parse OIDC ID token claims: %v", err)
+		return nil, nil, ErrLoginFailed
+	}
+
+	// Some providers only put the email in the userinfo response.
+	if claims.Email == "" {
+		if info, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(oauthToken)); err == nil && info.Subject == idToken.Subject {
+			claims.Email = info.Email
+			claims.EmailVerified = claimBool(info.EmailVerified)
+		}
+	}
+
+	return s.userSvc.LoginWithExternalIdentity(user.ExternalIdentity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, s.cfg.DefaultRole)
+}
+
+type identityClaims struct {
+	Email         string    `json:"email"`
+	EmailVerified claimBool `json:"email_verified"`
+	Name          string    `json:"name"`
+}
+
+// claimBool accepts booleans sent as strings, which some providers do for email_verified.
+type claimBool bool
+
+func (b *claimBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", `"true"`:
+		*b = true
+	default:
+		*b = false
+	}
+	return nil
+}
+
+func (s *service) getProvider(ctx context.Context) (*oidc.Provider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider == nil {
+		provider, err := oidc.NewProvider(ctx, s.cfg.IssuerURL)
+		if err != nil {
+			return nil, fmt.Errorf("could not discover identity provider: %w", err)
+		}
+		s.provider = provider
+	}
+	return s.provider, nil
+}
+
+func (s *service) oauth2Config(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     s.cfg.ClientID,
+		ClientSecret: s.cfg.ClientSecret,
+		RedirectURL:  s.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       s.cfg.Scopes,
+	}
+}
+
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateSecureRandomToken(length int) (string, error) {
+	bytes := make([]byte, length)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,390 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/user"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	testClientID     = "inventory-api"
+	testClientSecret = "secret"
+	testRedirectURL  = "http://localhost:8080/auth/oidc/callback"
+)
+
+// authorization is what the provider remembers about a code it issued.
+type authorization struct {
+	nonce     string
+	challenge string
+	claims    jwt.MapClaims
+}
+
+// fakeProvider is an OpenID Connect provider that signs ID tokens with its own key and
+// checks client credentials and PKCE at the token endpoint.
+type fakeProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authorization
+	// userInfo is returned by the userinfo endpoint.
+	userInfo map[string]interface{}
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &fakeProvider{t: t, key: key, codes: map[string]authorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/authorize",
+			"token_endpoint":                        p.server.URL + "/token",
+			"userinfo_endpoint":                     p.server.URL + "/userinfo",
+			"jwks_uri":                              p.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", p.token)
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-token" {
+			http.Error(w, "invalid_token", http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, p.userInfo)
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// authorize plays the user signing in at the provider: it checks the authorization
+// request and returns the code the provider redirects back with. claims are added to
+// the ID token.
+func (p *fakeProvider) authorize(authURL string, claims jwt.MapClaims) (code, state string) {
+	p.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	query := u.Query()
+	if u.Path != "/authorize" || query.Get("client_id") != testClientID || query.Get("redirect_uri") != testRedirectURL ||
+		query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		p.t.Fatalf("unexpected authorization request %s", authURL)
+	}
+	for _, param := range []string{"state", "nonce", "code_challenge"} {
+		if query.Get(param) == "" {
+			p.t.Fatalf("authorization request without %s: %s", param, authURL)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	code = "code-" + query.Get("state")[:8]
+	p.codes[code] = authorization{nonce: query.Get("nonce"), challenge: query.Get("code_challenge"), claims: claims}
+	return code, query.Get("state")
+}
+
+func (p *fakeProvider) token(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	if clientID != testClientID || secret != testClientSecret {
+		tokenError(w, "invalid_client")
+		return
+	}
+
+	p.mu.Lock()
+	auth, ok := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mu.Unlock()
+	if !ok || r.PostFormValue("redirect_uri") != testRedirectURL {
+		tokenError(w, "invalid_grant")
+		return
+	}
+	verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(verifier[:]) != auth.challenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   p.server.URL,
+		"aud":   testClientID,
+		"sub":   "subject-1",
+		"nonce": auth.nonce,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Minute).Unix(),
+	}
+	for name, value := range auth.claims {
+		claims[name] = value
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = "test"
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     signed,
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// memoryStates is a StateRepository in memory.
+type memoryStates struct {
+	mu     sync.Mutex
+	states map[string]LoginState
+}
+
+func (r *memoryStates) Create(state *LoginState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[state.StateHash] = *state
+	return nil
+}
+
+func (r *memoryStates) Consume(stateHash string) (*LoginState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.states[stateHash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	delete(r.states, stateHash)
+	return &state, nil
+}
+
+func (r *memoryStates) DeleteExpired(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, state := range r.states {
+		if state.ExpiresAt.Before(now) {
+			delete(r.states, hash)
+		}
+	}
+	return nil
+}
+
+// recordingUsers stands in for the user service and records the identities that
+// logged in.
+type recordingUsers struct {
+	user.Service
+	identities []user.ExternalIdentity
+}
+
+func (s *recordingUsers) LoginWithExternalIdentity(identity user.ExternalIdentity, defaultRole string) (*user.LoginResponse, *user.MFAChallengeResponse, error) {
+	s.identities = append(s.identities, identity)
+	return &user.LoginResponse{AccessToken: "api-access-token"}, nil, nil
+}
+
+func newTestService(t *testing.T, lifetime time.Duration) (Service, *fakeProvider, *recordingUsers) {
+	t.Helper()
+	provider := newFakeProvider(t)
+	users := &recordingUsers{}
+	svc := NewService(Config{
+		IssuerURL:     provider.server.URL,
+		ClientID:      testClientID,
+		ClientSecret:  testClientSecret,
+		RedirectURL:   testRedirectURL,
+		Scopes:        []string{"openid", "email", "profile"},
+		StateLifetime: lifetime,
+	}, &memoryStates{states: map[string]LoginState{}}, users)
+	return svc, provider, users
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		userInfo map[string]interface{}
+		want     user.ExternalIdentity
+	}{
+		{
+			name:   "verified email",
+			claims: jwt.MapClaims{"email": "ada@example.com", "email_verified": true, "name": "Ada"},
+			want:   user.ExternalIdentity{Subject: "subject-1", Email: "ada@example.com", EmailVerified: true, Name: "Ada"},
+		},
+		{
+			name:   "unverified email",
+			claims: jwt.MapClaims{"email": "ada@example.com", "email_verified": false},
+			want:   user.ExternalIdentity{Subject: "subject-1", Email: "ada@example.com"},
+		},
+		{
+			name:   "email_verified as a string",
+			claims: jwt.MapClaims{"email": "ada@example.com", "email_verified": "true"},
+			want:   user.ExternalIdentity{Subject: "subject-1", Email: "ada@example.com", EmailVerified: true},
+		},
+		{
+			name:     "email from userinfo",
+			claims:   jwt.MapClaims{},
+			userInfo: map[string]interface{}{"sub": "subject-1", "email": "ada@example.com", "email_verified": true},
+			want:     user.ExternalIdentity{Subject: "subject-1", Email: "ada@example.com", EmailVerified: true},
+		},
+		{
+			name:     "userinfo of another subject is ignored",
+			claims:   jwt.MapClaims{},
+			userInfo: map[string]interface{}{"sub": "subject-2", "email": "eve@example.com", "email_verified": true},
+			want:     user.ExternalIdentity{Subject: "subject-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, provider, users := newTestService(t, time.Minute)
+			provider.userInfo = tt.userInfo
+			ctx := context.Background()
+
+			authURL, err := svc.BeginLogin(ctx)
+			if err != nil {
+				t.Fatalf("BeginLogin: %v", err)
+			}
+			code, state := provider.authorize(authURL, tt.claims)
+
+			tokens, _, err := svc.CompleteLogin(ctx, CallbackInput{Code: code, State: state})
+			if err != nil {
+				t.Fatalf("CompleteLogin: %v", err)
+			}
+			if tokens.AccessToken != "api-access-token" {
+				t.Errorf("tokens = %+v", tokens)
+			}
+
+			tt.want.Issuer = provider.server.URL
+			if len(users.identities) != 1 || users.identities[0] != tt.want {
+				t.Errorf("identities = %+v, want %+v", users.identities, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginRejects(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("provider error", func(t *testing.T) {
+		svc, _, _ := newTestService(t, time.Minute)
+		_, _, err := svc.CompleteLogin(ctx, CallbackInput{Error: "access_denied", State: "x"})
+		var providerErr *ProviderError
+		if !errors.As(err, &providerErr) || providerErr.Code != "access_denied" {
+			t.Errorf("err = %v, want a ProviderError", err)
+		}
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		svc, provider, _ := newTestService(t, time.Minute)
+		authURL, _ := svc.BeginLogin(ctx)
+		code, _ := provider.authorize(authURL, nil)
+		if _, _, err := svc.CompleteLogin(ctx, CallbackInput{Code: code, State: "forged"}); !errors.Is(err, ErrInvalidState) {
+			t.Errorf("err = %v, want ErrInvalidState", err)
+		}
+	})
+
+	t.Run("state used twice", func(t *testing.T) {
+		svc, provider, users := newTestService(t, time.Minute)
+		authURL, _ := svc.BeginLogin(ctx)
+		code, state := provider.authorize(authURL, nil)
+		if _, _, err := svc.CompleteLogin(ctx, CallbackInput{Code: code, State: state}); err != nil {
+			t.Fatalf("first CompleteLogin: %v", err)
+		}
+		if _, _, err := svc.CompleteLogin(ctx, CallbackInput{Code: code, State: state}); !errors.Is(err, ErrInvalidState) {
+			t.Errorf("err = %v, want ErrInvalidState", err)
+		}
+		if len(users.identities) != 1 {
+			t.Errorf("%d logins, want 1", len(users.identities))
+		}
+	})
+
+	t.Run("expired state", func(t *testing.T) {
+		svc, provider, _ := newTestService(t, -time.Second)
+		authURL, _ := svc.BeginLogin(ctx)
+		code, state := provider.authorize(authURL, nil)
+		if _, _, err := svc.CompleteLogin(ctx, CallbackInput{Code: code, State: state}); !errors.Is(err, ErrInvalidState) {
+			t.Errorf("err = %v, want ErrInvalidState", err)
+		}
+	})
+
+	t.Run("code of another login", func(t *testing.T) {
+		// The code was issued for the first login's PKCE challenge, so the second
+		// login's verifier doesn't redeem it.
+		svc, provider, _ := newTestService(t, time.Minute)
+		firstURL, _ := svc.BeginLogin(ctx)
+		secondURL, _ := svc.BeginLogin(ctx)
+		code, _ := provider.authorize(firstURL, nil)
+		_, secondState := provider.authorize(secondURL, nil)
+		if _, _, err := svc.CompleteLogin(ctx, CallbackInput{Code: code, State: secondState}); !errors.Is(err, ErrLoginFailed) {
+			t.Errorf("err = %v, want ErrLoginFailed", err)
+		}
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		svc, provider, _ := newTestService(t, time.Minute)
+		authURL, _ := svc.BeginLogin(ctx)
+		code, state := provider.authorize(authURL, jwt.MapClaims{"nonce": "replayed"})
+		if _, _, err := svc.CompleteLogin(ctx, CallbackInput{Code: code, State: state}); !errors.Is(err, ErrLoginFailed) {
+			t.Errorf("err = %v, want ErrLoginFailed", err)
+		}
+	})
+
+	t.Run("ID token for another client", func(t *testing.T) {
+		svc, provider, _ := newTestService(t, time.Minute)
+		authURL, _ := svc.BeginLogin(ctx)
+		code, state := provider.authorize(authURL, jwt.MapClaims{"aud": "other-client"})
+		if _, _, err := svc.CompleteLogin(ctx, CallbackInput{Code: code, State: state}); !errors.Is(err, ErrLoginFailed) {
+			t.Errorf("err = %v, want ErrLoginFailed", err)
+		}
+	})
+
+	t.Run("ID token from another issuer", func(t *testing.T) {
+		svc, provider, _ := newTestService(t, time.Minute)
+		authURL, _ := svc.BeginLogin(ctx)
+		code, state := provider.authorize(authURL, jwt.MapClaims{"iss": "https://evil.example.com"})
+		if _, _, err := svc.CompleteLogin(ctx, CallbackInput{Code: code, State: state}); !errors.Is(err, ErrLoginFailed) {
+			t.Errorf("err = %v, want ErrLoginFailed", err)
+		}
+	})
+}
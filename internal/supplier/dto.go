@@ -2,18 +2,24 @@ package supplier
 
 import "time"
 
+// CreateSupplierInput is a new supplier. The currency it invoices in defaults to
+// DEFAULT_CURRENCY.
 type CreateSupplierInput struct {
 	Name          string `json:"name" binding:"required"`
 	ContactPerson string `json:"contactPerson"`
 	Email         string `json:"email" binding:"required,email"`
 	Phone         string `json:"phone"`
+	Currency      string `json:"currency" binding:"omitempty,len=3"`
 }
 
+// UpdateSupplierInput holds a supplier's editable fields. PUT replaces all of them;
+// PATCH applies a patch to their current values.
 type UpdateSupplierInput struct {
 	Name          string `json:"name" binding:"required"`
 	ContactPerson string `json:"contactPerson"`
 	Email         string `json:"email" binding:"email"`
 	Phone         string `json:"phone"`
+	Currency      string `json:"currency" binding:"omitempty,len=3"`
 }
 
 type SupplierResponse struct {
@@ -23,4 +29,13 @@ type SupplierResponse struct {
 	ContactPerson string    `json:"contactPerson"`
 	Email         string    `json:"email"`
 	Phone         string    `json:"phone"`
+	Currency      string    `json:"currency"`
+	Version       uint      `json:"version"`
+}
+
+type SupplierListResponse struct {
+	Data     []SupplierResponse `json:"data"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"pageSize"`
+	Total    int64              `json:"total"`
 }
@@ -5,6 +5,11 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/RezaBG/Inventory-management-api/internal/platform/db"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/etag"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/patch"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -23,8 +28,10 @@ func NewHandler(svc Service) *Handler {
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Param        supplier body CreateSupplierInput true "Supplier Information"
 // @Success      201  {object}  SupplierResponse // <-- FIXED
+// @Failure      403  {object}  map[string]interface{}
 // @Router       /suppliers [post]
 func (h *Handler) CreateSupplier(c *gin.Context) {
 	var input CreateSupplierInput
@@ -33,48 +40,72 @@ func (h *Handler) CreateSupplier(c *gin.Context) {
 		return
 	}
 
-	supplier, err := h.svc.CreateNewSupplier(input)
+	supplier, err := h.svc.CreateNewSupplier(c.Request.Context(), input)
 	if err != nil {
 		// Check if the error message is our new specific message from the service
 		if strings.Contains(err.Error(), "already exists") {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, money.ErrUnknownCurrency) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		// For all other errors, return a generic 500
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create supplier"})
 		return
 	}
 
+	c.Header("ETag", etag.Format(supplier.Version))
 	c.JSON(http.StatusCreated, supplier)
 }
 
-// GetAllSuppliers retrieves a list of all suppliers.
-// @Summary      Get all suppliers
+// GetAllSuppliers lists suppliers a page at a time.
+// @Summary      List suppliers
+// @Description  Filter with field[operator]=value, e.g. name[contains]=acme or createdAt[gte]=2024-01-01; operators are eq (the default), ne, lt, lte, gt, gte and contains. Sort with e.g. sort=-createdAt. The Link header points to the first, previous, next and last pages.
 // @Tags         Suppliers
 // @Produce      json
 // @Security     BearerAuth
-// @Success      200  {array}   SupplierResponse // <-- FIXED
+// @Security     ApiKeyAuth
+// @Param        page      query     int     false  "Page number (default 1)"
+// @Param        pageSize  query     int     false  "Page size (default 20, max 100); limit is an alias"
+// @Param        sort      query     string  false  "Comma-separated fields, prefixed with - for descending: id, name, contactPerson, email, phone, currency, createdAt, updatedAt"
+// @Success      200  {object}  SupplierListResponse
+// @Failure      400  {object}  map[string]interface{}
 // @Router       /suppliers [get]
 func (h *Handler) GetAllSuppliers(c *gin.Context) {
-	suppliers, err := h.svc.GetAllSuppliers()
+	params, err := query.Parse(c.Request.URL.Query(), listFields, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	suppliers, err := h.svc.ListSuppliers(c.Request.Context(), params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suppliers"})
 		return
 	}
+
+	c.Header("Link", params.Links(c.Request.URL, suppliers.Total))
 	c.JSON(http.StatusOK, suppliers)
 }
 
 // GetSupplierByID retrieves a single supplier by its ID.
 // @Summary      Get a single supplier
+// @Description  The response carries the supplier's version as ETag; send it back in If-None-Match to get 304 while the supplier is unchanged.
 // @Tags         Suppliers
 // @Produce      json
 // @Security     BearerAuth
-// @Param        id   path      int  true  "Supplier ID"
+// @Security     ApiKeyAuth
+// @Param        id             path      int     true   "Supplier ID"
+// @Param        If-None-Match  header    string  false  "ETag of a cached copy"
 // @Success      200  {object}  SupplierResponse
+// @Success      304  "Not Modified"
+// @Failure      404  {object}  map[string]interface{}
 // @Router       /suppliers/{id} [get]
 func (h *Handler) GetSupplierByID(c *gin.Context) {
 	id := c.Param("id")
-	supplier, err := h.svc.GetSupplierByID(id)
+	supplier, err := h.svc.GetSupplierByID(c.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
@@ -83,6 +114,12 @@ func (h *Handler) GetSupplierByID(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch supplier"})
 		return
 	}
+
+	c.Header("ETag", etag.Format(supplier.Version))
+	if etag.NotModified(c.GetHeader("If-None-Match"), supplier.Version) {
+		c.Status(http.StatusNotModified)
+		return
+	}
 	c.JSON(http.StatusOK, supplier)
 }
 
@@ -92,27 +129,82 @@ func (h *Handler) GetSupplierByID(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Param        id   path      int  true  "Supplier ID"
+// @Param        If-Match  header  string  false  "ETag the update is based on (required if REQUIRE_IF_MATCH is set)"
 // @Param        supplier body UpdateSupplierInput true "Supplier Update Information"
 // @Success      200  {object}  SupplierResponse
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
 // @Router       /suppliers/{id} [put]
 func (h *Handler) UpdateSupplier(c *gin.Context) {
 	id := c.Param("id")
+	precondition, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+		return
+	}
+
 	var input UpdateSupplierInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	supplier, err := h.svc.UpdateExistingSupplier(id, input)
+	supplier, err := h.svc.UpdateExistingSupplier(c.Request.Context(), id, input, precondition)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update supplier"})
+		respondWriteError(c, err, "Failed to update supplier")
 		return
 	}
+
+	c.Header("ETag", etag.Format(supplier.Version))
+	c.JSON(http.StatusOK, supplier)
+}
+
+// PatchSupplier changes some of a supplier's details.
+// @Summary      Partially update a supplier
+// @Description  Applies a JSON Merge Patch (application/merge-patch+json, e.g. {"phone": "..."}) or a JSON Patch (application/json-patch+json) to the supplier's name, contact person, email and phone. The patched supplier must be valid as a whole.
+// @Tags         Suppliers
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id        path    int     true   "Supplier ID"
+// @Param        If-Match  header  string  false  "ETag the update is based on (required if REQUIRE_IF_MATCH is set)"
+// @Param        patch     body    object  true   "Merge patch or JSON Patch"
+// @Success      200  {object}  SupplierResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      415  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
+// @Router       /suppliers/{id} [patch]
+func (h *Handler) PatchSupplier(c *gin.Context) {
+	id := c.Param("id")
+	precondition, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	p := patch.Patch{ContentType: c.ContentType(), Body: body}
+	supplier, err := h.svc.PatchSupplier(c.Request.Context(), id, p, precondition)
+	if err != nil {
+		respondWriteError(c, err, "Failed to update supplier")
+		return
+	}
+
+	c.Header("ETag", etag.Format(supplier.Version))
 	c.JSON(http.StatusOK, supplier)
 }
 
@@ -120,15 +212,43 @@ func (h *Handler) UpdateSupplier(c *gin.Context) {
 // @Summary      Delete a supplier
 // @Tags         Suppliers
 // @Security     BearerAuth
-// @Param        id   path      int  true  "Supplier ID"
+// @Security     ApiKeyAuth
+// @Param        id        path    int     true   "Supplier ID"
+// @Param        If-Match  header  string  false  "ETag the deletion is based on (required if REQUIRE_IF_MATCH is set)"
 // @Success      204  "No Content"
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      412  {object}  map[string]interface{}
+// @Failure      428  {object}  map[string]interface{}
 // @Router       /suppliers/{id} [delete]
 func (h *Handler) DeleteSupplier(c *gin.Context) {
 	id := c.Param("id")
-	err := h.svc.DeleteSupplierByID(id)
+	precondition, err := etag.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.svc.DeleteSupplierByID(c.Request.Context(), id, precondition)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete supplier"})
+		respondWriteError(c, err, "Failed to delete supplier")
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// respondWriteError maps the errors of updates and deletes to a response.
+func respondWriteError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
+	case errors.Is(err, etag.ErrPreconditionFailed), errors.Is(err, db.ErrStaleVersion):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": etag.ErrPreconditionFailed.Error()})
+	case errors.Is(err, patch.ErrUnsupportedMediaType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, patch.ErrInvalidPatch), errors.Is(err, money.ErrUnknownCurrency):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
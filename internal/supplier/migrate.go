@@ -0,0 +1,43 @@
+package supplier
+
+import (
+	"fmt"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+	"gorm.io/gorm"
+)
+
+// Migrate creates or updates the suppliers table.
+func Migrate(db *gorm.DB) error {
+	// Supplier emails used to be unique across all organizations; they are now unique
+	// per organization (idx_suppliers_org_email).
+	if db.Migrator().HasTable(&Supplier{}) {
+		for _, constraint := range []string{"uni_suppliers_email", "suppliers_email_key"} {
+			if err := db.Exec(`ALTER TABLE suppliers DROP CONSTRAINT IF EXISTS ` + constraint).Error; err != nil {
+				return err
+			}
+		}
+		if err := db.Exec(`DROP INDEX IF EXISTS idx_suppliers_email`).Error; err != nil {
+			return err
+		}
+	}
+
+	// Suppliers created before currencies were introduced invoice in the default
+	// currency.
+	if db.Migrator().HasTable(&Supplier{}) && !db.Migrator().HasColumn(&Supplier{}, "Currency") {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(`ALTER TABLE suppliers ADD COLUMN currency varchar(3)`).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(`UPDATE suppliers SET currency = ?`, money.DefaultCurrency()).Error; err != nil {
+				return err
+			}
+			return tx.Exec(`ALTER TABLE suppliers ALTER COLUMN currency SET NOT NULL`).Error
+		})
+		if err != nil {
+			return fmt.Errorf("could not add currencies to existing suppliers: %w", err)
+		}
+	}
+
+	return db.AutoMigrate(&Supplier{})
+}
@@ -4,8 +4,18 @@ import "gorm.io/gorm"
 
 type Supplier struct {
 	gorm.Model
+	// Email is unique within an organization.
+	OrgID         uint   `json:"-" gorm:"not null;uniqueIndex:idx_suppliers_org_email"`
 	Name          string `json:"name"`
 	ContactPerson string `json:"contactPerson"`
-	Email         string `json:"email" gorm:"unique"`
+	Email         string `json:"email" gorm:"uniqueIndex:idx_suppliers_org_email"`
 	Phone         string `json:"phone"`
+	// Currency is the ISO 4217 currency the supplier invoices in.
+	Currency string `json:"currency" gorm:"not null;size:3"`
+	// Version is incremented on every change; see etag.
+	Version uint `json:"version" gorm:"not null;default:1"`
+}
+
+func (Supplier) AuditEntityType() string {
+	return "supplier"
 }
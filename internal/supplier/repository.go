@@ -1,15 +1,31 @@
 package supplier
 
 import (
+	"context"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/db"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/query"
 	"gorm.io/gorm"
 )
 
+// listFields are the fields supplier lists can be sorted and filtered by.
+var listFields = query.Fields{
+	"id":            {Column: "suppliers.id", Kind: query.Number},
+	"name":          {Column: "suppliers.name", Kind: query.String},
+	"contactPerson": {Column: "suppliers.contact_person", Kind: query.String},
+	"email":         {Column: "suppliers.email", Kind: query.String},
+	"phone":         {Column: "suppliers.phone", Kind: query.String},
+	"currency":      {Column: "suppliers.currency", Kind: query.String},
+	"createdAt":     {Column: "suppliers.created_at", Kind: query.Time},
+	"updatedAt":     {Column: "suppliers.updated_at", Kind: query.Time},
+}
+
 type Repository interface {
-	Save(supplier *Supplier) (*Supplier, error)
-	FindAll() ([]Supplier, error)
-	FindByID(id string) (*Supplier, error)
-	Update(supplier *Supplier) (*Supplier, error)
-	Delete(id string) error
+	Save(ctx context.Context, supplier *Supplier) (*Supplier, error)
+	List(ctx context.Context, params *query.Params) ([]Supplier, int64, error)
+	FindByID(ctx context.Context, id string) (*Supplier, error)
+	Update(ctx context.Context, supplier *Supplier) (*Supplier, error)
+	Delete(ctx context.Context, supplier *Supplier) error
 }
 
 type repository struct {
@@ -20,28 +36,51 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) Save(supplier *Supplier) (*Supplier, error) {
-	err := r.db.Create(supplier).Error
+func (r *repository) Save(ctx context.Context, supplier *Supplier) (*Supplier, error) {
+	err := r.db.WithContext(ctx).Create(supplier).Error
 	return supplier, err
 }
 
-func (r *repository) FindAll() ([]Supplier, error) {
+func (r *repository) List(ctx context.Context, params *query.Params) ([]Supplier, int64, error) {
+	db := r.db.WithContext(ctx).Model(&Supplier{}).Scopes(params.Filter)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
 	var suppliers []Supplier
-	err := r.db.Find(&suppliers).Error
-	return suppliers, err
+	err := db.Scopes(params.Sort, params.Paginate).Find(&suppliers).Error
+	return suppliers, total, err
 }
 
-func (r *repository) FindByID(id string) (*Supplier, error) {
+func (r *repository) FindByID(ctx context.Context, id string) (*Supplier, error) {
 	var supplier Supplier
-	err := r.db.First(&supplier, id).Error
+	err := r.db.WithContext(ctx).First(&supplier, id).Error
 	return &supplier, err
 }
 
-func (r *repository) Update(supplier *Supplier) (*Supplier, error) {
-	err := r.db.Save(supplier).Error
-	return supplier, err
+// Update saves the supplier only if it is still at the version it was read at, and
+// increments its version. Otherwise it returns db.ErrStaleVersion.
+func (r *repository) Update(ctx context.Context, supplier *Supplier) (*Supplier, error) {
+	readVersion := supplier.Version
+	supplier.Version++
+	result := r.db.WithContext(ctx).Model(supplier).Where("version = ?", readVersion).Select("*").Updates(supplier)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = db.ErrStaleVersion
+	}
+	if result.Error != nil {
+		supplier.Version = readVersion
+	}
+	return supplier, result.Error
 }
 
-func (r *repository) Delete(id string) error {
-	return r.db.Unscoped().Delete(&Supplier{}, id).Error
+// Delete deletes the supplier only if it is still at the version it was read at.
+// Otherwise it returns db.ErrStaleVersion.
+func (r *repository) Delete(ctx context.Context, supplier *Supplier) error {
+	result := r.db.WithContext(ctx).Unscoped().Where("version = ?", supplier.Version).Delete(supplier)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return db.ErrStaleVersion
+	}
+	return result.Error
 }
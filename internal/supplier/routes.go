@@ -5,10 +5,19 @@ import "github.com/gin-gonic/gin"
 func RegisterRoutes(router *gin.RouterGroup, h *Handler) {
 	supplierRoutes := router.Group("/suppliers")
 	{
-		supplierRoutes.POST("/", h.CreateSupplier)
 		supplierRoutes.GET("/", h.GetAllSuppliers)
 		supplierRoutes.GET("/:id", h.GetSupplierByID)
+	}
+}
+
+// RegisterManagerRoutes registers the routes that change suppliers, which only
+// managers and administrators may use.
+func RegisterManagerRoutes(router *gin.RouterGroup, h *Handler) {
+	supplierRoutes := router.Group("/suppliers")
+	{
+		supplierRoutes.POST("/", h.CreateSupplier)
 		supplierRoutes.PUT("/:id", h.UpdateSupplier)
+		supplierRoutes.PATCH("/:id", h.PatchSupplier)
 		supplierRoutes.DELETE("/:id", h.DeleteSupplier)
 	}
 }
@@ -1,19 +1,25 @@
 package supplier
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
+	"github.com/RezaBG/Inventory-management-api/internal/platform/etag"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/money"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/patch"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/query"
 	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 )
 
 type Service interface {
-	CreateNewSupplier(input CreateSupplierInput) (*SupplierResponse, error)
-	GetAllSuppliers() ([]SupplierResponse, error)
-	GetSupplierByID(id string) (*SupplierResponse, error)
-	UpdateExistingSupplier(id string, input UpdateSupplierInput) (*SupplierResponse, error)
-	DeleteSupplierByID(id string) error
+	CreateNewSupplier(ctx context.Context, input CreateSupplierInput) (*SupplierResponse, error)
+	ListSuppliers(ctx context.Context, params *query.Params) (*SupplierListResponse, error)
+	GetSupplierByID(ctx context.Context, id string) (*SupplierResponse, error)
+	UpdateExistingSupplier(ctx context.Context, id string, input UpdateSupplierInput, precondition etag.Precondition) (*SupplierResponse, error)
+	PatchSupplier(ctx context.Context, id string, p patch.Patch, precondition etag.Precondition) (*SupplierResponse, error)
+	DeleteSupplierByID(ctx context.Context, id string, precondition etag.Precondition) error
 }
 
 type service struct {
@@ -32,19 +38,27 @@ func toSupplierResponse(supplier Supplier) SupplierResponse {
 		ContactPerson: supplier.ContactPerson,
 		Email:         supplier.Email,
 		Phone:         supplier.Phone,
+		Currency:      supplier.Currency,
+		Version:       supplier.Version,
 	}
 }
 
-func (s *service) CreateNewSupplier(input CreateSupplierInput) (*SupplierResponse, error) {
+func (s *service) CreateNewSupplier(ctx context.Context, input CreateSupplierInput) (*SupplierResponse, error) {
+	currency, err := money.Normalize(input.Currency)
+	if err != nil {
+		return nil, err
+	}
+
 	newSupplier := Supplier{
 		Name:          input.Name,
 		ContactPerson: input.ContactPerson,
 		Email:         input.Email,
 		Phone:         input.Phone,
+		Currency:      currency,
 	}
 
 	// the service calls the repository to save data
-	savedSupplier, err := s.repo.Save(&newSupplier)
+	savedSupplier, err := s.repo.Save(ctx, &newSupplier)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		// errors.As checks if the error from GORM can be converted to a PgError
@@ -64,21 +78,26 @@ func (s *service) CreateNewSupplier(input CreateSupplierInput) (*SupplierRespons
 
 }
 
-func (s *service) GetAllSuppliers() ([]SupplierResponse, error) {
-	suppliers, err := s.repo.FindAll()
+func (s *service) ListSuppliers(ctx context.Context, params *query.Params) (*SupplierListResponse, error) {
+	suppliers, total, err := s.repo.List(ctx, params)
 	if err != nil {
 		return nil, err
 	}
 
-	var responses []SupplierResponse
+	responses := make([]SupplierResponse, 0, len(suppliers))
 	for _, supplier := range suppliers {
 		responses = append(responses, toSupplierResponse(supplier))
 	}
-	return responses, nil
+	return &SupplierListResponse{
+		Data:     responses,
+		Page:     params.Page,
+		PageSize: params.PageSize,
+		Total:    total,
+	}, nil
 }
 
-func (s *service) GetSupplierByID(id string) (*SupplierResponse, error) {
-	supplier, err := s.repo.FindByID(id)
+func (s *service) GetSupplierByID(ctx context.Context, id string) (*SupplierResponse, error) {
+	supplier, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -86,11 +105,50 @@ func (s *service) GetSupplierByID(id string) (*SupplierResponse, error) {
 	return &response, nil
 }
 
-func (s *service) UpdateExistingSupplier(id string, input UpdateSupplierInput) (*SupplierResponse, error) {
-	supplier, err := s.repo.FindByID(id)
+func (s *service) UpdateExistingSupplier(ctx context.Context, id string, input UpdateSupplierInput, precondition etag.Precondition) (*SupplierResponse, error) {
+	supplier, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if !precondition.Allows(supplier.Version) {
+		return nil, etag.ErrPreconditionFailed
+	}
+
+	return s.update(ctx, supplier, input)
+}
+
+func (s *service) PatchSupplier(ctx context.Context, id string, p patch.Patch, precondition etag.Precondition) (*SupplierResponse, error) {
+	supplier, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !precondition.Allows(supplier.Version) {
+		return nil, etag.ErrPreconditionFailed
+	}
+
+	input := UpdateSupplierInput{
+		Name:          supplier.Name,
+		ContactPerson: supplier.ContactPerson,
+		Email:         supplier.Email,
+		Phone:         supplier.Phone,
+		Currency:      supplier.Currency,
+	}
+	if err := p.Apply(&input); err != nil {
+		return nil, err
+	}
+
+	return s.update(ctx, supplier, input)
+}
+
+func (s *service) update(ctx context.Context, supplier *Supplier, input UpdateSupplierInput) (*SupplierResponse, error) {
+	// Without a currency, the supplier keeps the one it invoices in.
+	if input.Currency != "" {
+		currency, err := money.Normalize(input.Currency)
+		if err != nil {
+			return nil, err
+		}
+		supplier.Currency = currency
+	}
 
 	// Update the fields
 	supplier.Name = input.Name
@@ -98,7 +156,7 @@ func (s *service) UpdateExistingSupplier(id string, input UpdateSupplierInput) (
 	supplier.Email = input.Email
 	supplier.Phone = input.Phone
 
-	updatedSupplier, err := s.repo.Update(supplier)
+	updatedSupplier, err := s.repo.Update(ctx, supplier)
 	if err != nil {
 		return nil, err
 	}
@@ -107,14 +165,17 @@ func (s *service) UpdateExistingSupplier(id string, input UpdateSupplierInput) (
 	return &response, nil
 }
 
-func (s *service) DeleteSupplierByID(id string) error {
-	_, err := s.repo.FindByID(id)
+func (s *service) DeleteSupplierByID(ctx context.Context, id string, precondition etag.Precondition) error {
+	supplier, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("supplier with ID %s not found", id)
+			return fmt.Errorf("supplier with ID %s not found: %w", id, err)
 		}
 		return err
 	}
+	if !precondition.Allows(supplier.Version) {
+		return etag.ErrPreconditionFailed
+	}
 
-	return s.repo.Delete(id)
+	return s.repo.Delete(ctx, supplier)
 }
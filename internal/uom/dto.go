@@ -0,0 +1,21 @@
+package uom
+
+// UnitInput is a unit of measure. Units of the count, mass, volume and length
+// dimensions convert into each other by their standard factors; package units only
+// convert with a product's own factors.
+type UnitInput struct {
+	Code           string    `json:"code" binding:"required,max=16"`
+	Name           string    `json:"name" binding:"required"`
+	Dimension      Dimension `json:"dimension" binding:"required,oneof=count mass volume length package"`
+	StandardFactor float64   `json:"standardFactor" binding:"required,gt=0"`
+	Decimal        bool      `json:"decimal"`
+}
+
+type UnitResponse struct {
+	ID             uint      `json:"id"`
+	Code           string    `json:"code"`
+	Name           string    `json:"name"`
+	Dimension      Dimension `json:"dimension"`
+	StandardFactor float64   `json:"standardFactor"`
+	Decimal        bool      `json:"decimal"`
+}
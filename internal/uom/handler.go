@@ -0,0 +1,107 @@
+package uom
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// GetUnits lists the units of measure.
+// @Summary      List units of measure
+// @Tags         Units
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Success      200  {array}   UnitResponse
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /units [get]
+func (h *Handler) GetUnits(c *gin.Context) {
+	units, err := h.svc.ListUnits(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch units"})
+		return
+	}
+
+	c.JSON(http.StatusOK, units)
+}
+
+// CreateUnit adds a unit of measure.
+// @Summary      Create a unit of measure
+// @Description  Adds a unit, e.g. {"code": "g", "name": "gram", "dimension": "mass", "standardFactor": 0.001, "decimal": true}. The standard factor is the unit in each, kg, l or m; package units such as cases use 1 and convert with each product's own factors.
+// @Tags         Units
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        unit  body      UnitInput  true  "Unit"
+// @Success      201  {object}  UnitResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /units [post]
+func (h *Handler) CreateUnit(c *gin.Context) {
+	var input UnitInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	unit, err := h.svc.CreateUnit(c.Request.Context(), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, unit)
+}
+
+// UpdateUnit changes a unit of measure.
+// @Summary      Update a unit of measure
+// @Description  Replaces a unit's details. Stock levels are kept in each product's base unit and don't change.
+// @Tags         Units
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id    path      int        true  "Unit ID"
+// @Param        unit  body      UnitInput  true  "Unit"
+// @Success      200  {object}  UnitResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /units/{id} [put]
+func (h *Handler) UpdateUnit(c *gin.Context) {
+	var input UnitInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	unit, err := h.svc.UpdateUnit(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, unit)
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unit not found"})
+	case errors.Is(err, ErrCodeInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
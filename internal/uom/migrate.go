@@ -0,0 +1,9 @@
+package uom
+
+import "gorm.io/gorm"
+
+// Migrate creates or updates the units table. Run it before product.Migrate, which
+// links products to units.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Unit{})
+}
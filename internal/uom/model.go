@@ -0,0 +1,56 @@
+package uom
+
+import (
+	"math"
+
+	"gorm.io/gorm"
+)
+
+type Dimension string
+
+const (
+	Count  Dimension = "count"
+	Mass   Dimension = "mass"
+	Volume Dimension = "volume"
+	Length Dimension = "length"
+	// Package units, such as cases and pallets, hold a different amount of every
+	// product, so they only convert with a product's own conversion factors.
+	Package Dimension = "package"
+)
+
+// Unit is a unit of measure of an organization, e.g. kg or case.
+type Unit struct {
+	gorm.Model
+	// Code is unique among an organization's units that haven't been deleted.
+	OrgID     uint      `json:"-" gorm:"not null;uniqueIndex:idx_units_org_code,where:deleted_at IS NULL"`
+	Code      string    `json:"code" gorm:"not null;size:16;uniqueIndex:idx_units_org_code"`
+	Name      string    `json:"name"`
+	Dimension Dimension `json:"dimension" gorm:"not null"`
+	// StandardFactor is the unit in the standard unit of its dimension: each, kg, l or
+	// m. For example, g has 0.001 and dozen 12.
+	StandardFactor float64 `json:"standardFactor" gorm:"type:numeric(18,6);not null;default:1"`
+	// Decimal allows quantities with decimals, e.g. 1.25 kg.
+	Decimal bool `json:"decimal" gorm:"not null;default:false"`
+}
+
+func (Unit) AuditEntityType() string {
+	return "unit"
+}
+
+// Factor returns how many of unit to one of unit from is, if the units are of the same
+// dimension and the dimension has standard conversions.
+func Factor(from, to Unit) (float64, bool) {
+	if from.ID == to.ID {
+		return 1, true
+	}
+	if from.Dimension != to.Dimension || from.Dimension == Package || to.StandardFactor == 0 {
+		return 0, false
+	}
+	return from.StandardFactor / to.StandardFactor, true
+}
+
+// IsWhole reports whether a quantity is a whole number, allowing for the rounding
+// errors of conversions.
+func IsWhole(quantity float64) bool {
+	return math.Abs(quantity-math.Round(quantity)) < 1e-9
+}
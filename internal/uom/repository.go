@@ -0,0 +1,58 @@
+package uom
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	List(ctx context.Context) ([]Unit, error)
+	FindByID(ctx context.Context, id string) (*Unit, error)
+	FindByCode(ctx context.Context, code string) (*Unit, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]Unit, error)
+	Save(ctx context.Context, unit *Unit) (*Unit, error)
+	Update(ctx context.Context, unit *Unit) (*Unit, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) List(ctx context.Context) ([]Unit, error) {
+	var units []Unit
+	err := r.db.WithContext(ctx).Order("dimension, standard_factor, code").Find(&units).Error
+	return units, err
+}
+
+func (r *repository) FindByID(ctx context.Context, id string) (*Unit, error) {
+	var unit Unit
+	err := r.db.WithContext(ctx).First(&unit, id).Error
+	return &unit, err
+}
+
+func (r *repository) FindByCode(ctx context.Context, code string) (*Unit, error) {
+	var unit Unit
+	err := r.db.WithContext(ctx).Where("code = ?", code).First(&unit).Error
+	return &unit, err
+}
+
+func (r *repository) FindByIDs(ctx context.Context, ids []uint) ([]Unit, error) {
+	var units []Unit
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&units).Error
+	return units, err
+}
+
+func (r *repository) Save(ctx context.Context, unit *Unit) (*Unit, error) {
+	err := r.db.WithContext(ctx).Create(unit).Error
+	return unit, err
+}
+
+func (r *repository) Update(ctx context.Context, unit *Unit) (*Unit, error) {
+	err := r.db.WithContext(ctx).Model(unit).Select("*").Updates(unit).Error
+	return unit, err
+}
@@ -0,0 +1,12 @@
+package uom
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, h *Handler) {
+	unitRoutes := router.Group("/units")
+	{
+		unitRoutes.GET("", h.GetUnits)
+		unitRoutes.POST("", h.CreateUnit)
+		unitRoutes.PUT("/:id", h.UpdateUnit)
+	}
+}
@@ -0,0 +1,95 @@
+package uom
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var ErrCodeInUse = errors.New("another unit already has this code")
+
+type Service interface {
+	ListUnits(ctx context.Context) ([]UnitResponse, error)
+	CreateUnit(ctx context.Context, input UnitInput) (*UnitResponse, error)
+	UpdateUnit(ctx context.Context, id string, input UnitInput) (*UnitResponse, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func toUnitResponse(unit Unit) UnitResponse {
+	return UnitResponse{
+		ID:             unit.ID,
+		Code:           unit.Code,
+		Name:           unit.Name,
+		Dimension:      unit.Dimension,
+		StandardFactor: unit.StandardFactor,
+		Decimal:        unit.Decimal,
+	}
+}
+
+func (s *service) ListUnits(ctx context.Context) ([]UnitResponse, error) {
+	units, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]UnitResponse, 0, len(units))
+	for _, unit := range units {
+		responses = append(responses, toUnitResponse(unit))
+	}
+	return responses, nil
+}
+
+func (s *service) CreateUnit(ctx context.Context, input UnitInput) (*UnitResponse, error) {
+	unit := Unit{
+		Code:           input.Code,
+		Name:           input.Name,
+		Dimension:      input.Dimension,
+		StandardFactor: input.StandardFactor,
+		Decimal:        input.Decimal,
+	}
+	saved, err := s.repo.Save(ctx, &unit)
+	if err != nil {
+		return nil, translateConflict(err)
+	}
+
+	response := toUnitResponse(*saved)
+	return &response, nil
+}
+
+// UpdateUnit changes a unit. Stock is kept in base units, so this doesn't change any
+// stock level, only conversions from now on.
+func (s *service) UpdateUnit(ctx context.Context, id string, input UnitInput) (*UnitResponse, error) {
+	unit, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	unit.Code = input.Code
+	unit.Name = input.Name
+	unit.Dimension = input.Dimension
+	unit.StandardFactor = input.StandardFactor
+	unit.Decimal = input.Decimal
+	updated, err := s.repo.Update(ctx, unit)
+	if err != nil {
+		return nil, translateConflict(err)
+	}
+
+	response := toUnitResponse(*updated)
+	return &response, nil
+}
+
+func translateConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrCodeInUse
+	}
+	return err
+}
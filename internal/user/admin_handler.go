@@ -0,0 +1,338 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetRoles lists all roles and their two-factor requirement.
+// @Summary      List roles
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   RoleResponse
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/roles [get]
+func (h *Handler) GetRoles(c *gin.Context) {
+	roles, err := h.svc.GetAllRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch roles"})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// UpdateRole changes whether a role requires two-factor authentication.
+// @Summary      Update a role
+// @Description  Requires (or stops requiring) two-factor authentication for every member of the role. Only roles that can post stock adjustments (admin and manager) can require it.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        name path      string           true "Role Name"
+// @Param        role body      UpdateRoleInput  true "Role Settings"
+// @Success      200  {object}  RoleResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/roles/{name} [put]
+func (h *Handler) UpdateRole(c *gin.Context) {
+	var input UpdateRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.svc.UpdateRole(c.Param("name"), input)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+			return
+		}
+		if errors.Is(err, ErrMFANotForRole) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// GetLockouts lists accounts and IP addresses that are currently locked out.
+// @Summary      List active login lockouts
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   LockoutEventResponse
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/lockouts [get]
+func (h *Handler) GetLockouts(c *gin.Context) {
+	lockouts, err := h.svc.GetActiveLockouts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lockouts"})
+		return
+	}
+	c.JSON(http.StatusOK, lockouts)
+}
+
+// UnlockUser lifts a login lockout on a user's account.
+// @Summary      Unlock a user account
+// @Description  Clears the failed login counter and any active lockout for the account.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/unlock [post]
+func (h *Handler) UnlockUser(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.UnlockUser(id, admin.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListUsers lists users, optionally filtered by name or email.
+// @Summary      List users
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        q         query     string  false  "Search in name and email"
+// @Param        page      query     int     false  "Page number (default 1)"
+// @Param        pageSize  query     int     false  "Page size (default 20, max 100)"
+// @Success      200  {object}  UserListResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/users [get]
+func (h *Handler) ListUsers(c *gin.Context) {
+	var input ListUsersInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, err := h.svc.ListUsers(input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// GetUser retrieves a single user.
+// @Summary      Get a user
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  UserResponse
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/users/{id} [get]
+func (h *Handler) GetUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.svc.GetUser(id)
+	if err != nil {
+		respondAdminError(c, err, "Failed to fetch user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUserRoles replaces a user's roles.
+// @Summary      Change a user's roles
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path      int                   true  "User ID"
+// @Param        roles  body      UpdateUserRolesInput  true  "New Roles"
+// @Success      200  {object}  UserResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/roles [put]
+func (h *Handler) UpdateUserRoles(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var input UpdateUserRolesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.svc.UpdateUserRoles(id, input, admin.ID)
+	if err != nil {
+		respondAdminError(c, err, "Failed to update roles")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeactivateUser blocks a user from logging in or using existing tokens.
+// @Summary      Deactivate a user
+// @Description  Takes effect immediately: existing access tokens are rejected and refresh tokens are revoked.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  UserResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/deactivate [post]
+func (h *Handler) DeactivateUser(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.svc.DeactivateUser(id, admin.ID)
+	if err != nil {
+		respondAdminError(c, err, "Failed to deactivate user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ReactivateUser restores access for a deactivated user.
+// @Summary      Reactivate a user
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  UserResponse
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/reactivate [post]
+func (h *Handler) ReactivateUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.svc.ReactivateUser(id)
+	if err != nil {
+		respondAdminError(c, err, "Failed to reactivate user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ForcePasswordReset requires a user to choose a new password.
+// @Summary      Force a password reset
+// @Description  Signs the user out everywhere (refresh tokens and API keys are revoked, access tokens are rejected), blocks password logins and emails them a reset link.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      202  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/password-reset [post]
+func (h *Handler) ForcePasswordReset(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.ForcePasswordReset(id); err != nil {
+		respondAdminError(c, err, "Failed to force password reset")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Password reset email sent"})
+}
+
+// DeleteUser deletes a user account.
+// @Summary      Delete a user
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/users/{id} [delete]
+func (h *Handler) DeleteUser(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeleteUser(id, admin.ID); err != nil {
+		respondAdminError(c, err, "Failed to delete user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func userIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondAdminError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, ErrCannotModifySelf), errors.Is(err, ErrUnknownRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
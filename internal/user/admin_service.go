@@ -0,0 +1,204 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	ErrAccountDeactivated    = errors.New("account has been deactivated")
+	ErrPasswordResetRequired = errors.New("a password reset is required before you can log in; check your email for a reset link")
+	ErrCannotModifySelf      = errors.New("administrators cannot deactivate, delete or remove the admin role from their own account")
+	ErrUnknownRole           = errors.New("one or more roles do not exist")
+)
+
+func toUserResponse(user User) UserResponse {
+	roles := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		roles = append(roles, role.Name)
+	}
+
+	return UserResponse{
+		ID:                    user.ID,
+		CreatedAt:             user.CreatedAt,
+		Name:                  user.Name,
+		Email:                 user.Email,
+		EmailVerifiedAt:       user.EmailVerifiedAt,
+		Roles:                 roles,
+		TOTPEnabled:           user.IsTOTPEnabled(),
+		Active:                user.IsActive(),
+		IsServiceAccount:      user.IsServiceAccount,
+		DeactivatedAt:         user.DeactivatedAt,
+		PasswordResetRequired: user.PasswordResetRequired,
+	}
+}
+
+func (s *service) ListUsers(input ListUsersInput) (*UserListResponse, error) {
+	users, total, err := s.userRepo.Search(input.Query, (input.Page-1)*input.PageSize, input.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]UserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, toUserResponse(user))
+	}
+
+	return &UserListResponse{
+		Data:     responses,
+		Page:     input.Page,
+		PageSize: input.PageSize,
+		Total:    total,
+	}, nil
+}
+
+func (s *service) GetUser(id uint) (*UserResponse, error) {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toUserResponse(*user)
+	return &response, nil
+}
+
+// UpdateUserRoles replaces the user's roles with the given set.
+func (s *service) UpdateUserRoles(id uint, input UpdateUserRolesInput, adminID uint) (*UserResponse, error) {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := s.roleRepo.FindByNames(input.Roles)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if len(roles) != len(uniqueStrings(input.Roles)) {
+		return nil, ErrUnknownRole
+	}
+
+	// Stop admins from locking themselves out of the admin endpoints.
+	if user.ID == adminID && user.HasRole(RoleAdmin) && !containsRole(roles, RoleAdmin) {
+		return nil, ErrCannotModifySelf
+	}
+
+	if err := s.userRepo.ReplaceRoles(user, roles); err != nil {
+		return nil, fmt.Errorf("could not update roles: %w", err)
+	}
+	user.Roles = roles
+
+	response := toUserResponse(*user)
+	return &response, nil
+}
+
+// DeactivateUser blocks the account immediately: AuthMiddleware rejects its access
+// tokens on the next request and all refresh tokens are revoked.
+func (s *service) DeactivateUser(id uint, adminID uint) (*UserResponse, error) {
+	if id == adminID {
+		return nil, ErrCannotModifySelf
+	}
+
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsActive() {
+		now := time.Now()
+		user.DeactivatedAt = &now
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, fmt.Errorf("could not deactivate user: %w", err)
+		}
+	}
+
+	if err := s.rtRepo.DeleteByUserID(user.ID); err != nil {
+		log.Printf("could not revoke refresh tokens for user %d: %v", user.ID, err)
+	}
+
+	response := toUserResponse(*user)
+	return &response, nil
+}
+
+func (s *service) ReactivateUser(id uint) (*UserResponse, error) {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive() {
+		user.DeactivatedAt = nil
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, fmt.Errorf("could not reactivate user: %w", err)
+		}
+	}
+
+	response := toUserResponse(*user)
+	return &response, nil
+}
+
+// ForcePasswordReset signs the user out everywhere, blocks password logins and emails
+// them a reset link. Refresh tokens and API keys are revoked; access tokens that are
+// still valid are rejected by the auth middleware until the user has set a new
+// password, which lifts the block.
+func (s *service) ForcePasswordReset(id uint) error {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordResetRequired = true
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("could not update user: %w", err)
+	}
+
+	if err := s.rtRepo.DeleteByUserID(user.ID); err != nil {
+		return fmt.Errorf("could not revoke refresh tokens: %w", err)
+	}
+	if err := s.apiKeyRepo.RevokeByUserID(user.ID); err != nil {
+		return fmt.Errorf("could not revoke API keys: %w", err)
+	}
+
+	return s.sendPasswordResetEmail(user, "An administrator has required you to choose a new password.")
+}
+
+func (s *service) DeleteUser(id uint, adminID uint) error {
+	if id == adminID {
+		return ErrCannotModifySelf
+	}
+
+	if _, err := s.userRepo.FindByID(id); err != nil {
+		return err
+	}
+
+	if err := s.rtRepo.DeleteByUserID(id); err != nil {
+		return fmt.Errorf("could not revoke refresh tokens: %w", err)
+	}
+	if err := s.apiKeyRepo.RevokeByUserID(id); err != nil {
+		return fmt.Errorf("could not revoke API keys: %w", err)
+	}
+
+	return s.userRepo.Delete(id)
+}
+
+func containsRole(roles []Role, name string) bool {
+	for _, role := range roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,40 @@
+package user
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func (r *fakeUsers) Delete(id uint) error {
+	r.users = slices.DeleteFunc(r.users, func(user User) bool { return user.ID == id })
+	return nil
+}
+
+func TestDeleteUserRevokesCredentials(t *testing.T) {
+	s, keys := newAPIKeyService()
+	refreshTokens := &fakeRefreshTokens{}
+	s.rtRepo = refreshTokens
+	owner, _ := s.userRepo.FindByID(1)
+	created, err := s.CreateAPIKey(owner, CreateAPIKeyInput{Name: "scanner", Scopes: []string{"products:read"}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	if err := s.DeleteUser(1, 1); !errors.Is(err, ErrCannotModifySelf) {
+		t.Errorf("deleting oneself: err = %v, want ErrCannotModifySelf", err)
+	}
+	if err := s.DeleteUser(1, 2); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+
+	if keys.keys[0].RevokedAt == nil {
+		t.Errorf("API key not revoked")
+	}
+	if _, err := s.AuthenticateAPIKey(created.Key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("deleted user's key: err = %v, want ErrInvalidAPIKey", err)
+	}
+	if !slices.Contains(refreshTokens.revoked, 1) {
+		t.Errorf("refresh tokens not revoked")
+	}
+}
@@ -0,0 +1,234 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateAPIKey issues an API key for the current user.
+// @Summary      Create an API key
+// @Description  Issues a scoped API key for the current user, acting in the current organization unless organizationId is given. The full key is only returned in this response; send it in the X-API-Key header.
+// @Tags         API Keys
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        key  body      CreateAPIKeyInput  true  "API Key Details"
+// @Success      201  {object}  CreatedAPIKeyResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /api-keys [post]
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.OrganizationID == nil {
+		if orgID := c.GetUint("orgID"); orgID != 0 {
+			input.OrganizationID = &orgID
+		}
+	}
+
+	key, err := h.svc.CreateAPIKey(user, input)
+	if err != nil {
+		if errors.Is(err, ErrNotOrganizationMember) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// ListAPIKeys lists the current user's API keys.
+// @Summary      List my API keys
+// @Tags         API Keys
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   APIKeyResponse
+// @Router       /api-keys [get]
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.svc.ListAPIKeys(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey revokes one of the current user's API keys.
+// @Summary      Revoke an API key
+// @Tags         API Keys
+// @Security     BearerAuth
+// @Param        id   path      int  true  "API Key ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /api-keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	keyID, ok := apiKeyIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.RevokeAPIKey(user.ID, keyID); err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateServiceAccount creates a user for machine clients.
+// @Summary      Create a service account
+// @Description  Creates a non-human user that cannot log in with a password and authenticates with API keys.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        account  body      CreateServiceAccountInput  true  "Service Account Details"
+// @Success      201  {object}  UserResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/service-accounts [post]
+func (h *Handler) CreateServiceAccount(c *gin.Context) {
+	var input CreateServiceAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.svc.CreateServiceAccount(input)
+	if err != nil {
+		if errors.Is(err, ErrUnknownRole) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create service account"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// CreateUserAPIKey issues an API key for a service account.
+// @Summary      Create an API key for a service account
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int                true  "Service Account User ID"
+// @Param        key  body      CreateAPIKeyInput  true  "API Key Details"
+// @Success      201  {object}  CreatedAPIKeyResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/api-keys [post]
+func (h *Handler) CreateUserAPIKey(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var input CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := h.svc.CreateAPIKeyForServiceAccount(id, input)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// ListUserAPIKeys lists a user's API keys.
+// @Summary      List a user's API keys
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {array}   APIKeyResponse
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/users/{id}/api-keys [get]
+func (h *Handler) ListUserAPIKeys(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.svc.ListAPIKeys(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAnyAPIKey revokes any user's API key.
+// @Summary      Revoke any API key
+// @Tags         Admin
+// @Security     BearerAuth
+// @Param        id   path      int  true  "API Key ID"
+// @Success      204  "No Content"
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /admin/api-keys/{id} [delete]
+func (h *Handler) RevokeAnyAPIKey(c *gin.Context) {
+	keyID, ok := apiKeyIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.RevokeAnyAPIKey(keyID); err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func apiKeyIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondAPIKeyError(c *gin.Context, err error) {
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+}
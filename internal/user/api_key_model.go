@@ -0,0 +1,38 @@
+package user
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey is a long-lived credential for machine clients, sent in the X-API-Key header.
+// The key has the form "ima_<prefix>_<secret>"; the prefix is stored in clear text to
+// look the key up, the secret only as a SHA-256 hash.
+type APIKey struct {
+	gorm.Model
+	UserID     uint `gorm:"not null;index"`
+	User       User
+	Name       string `gorm:"not null"`
+	Prefix     string `gorm:"uniqueIndex;not null"`
+	SecretHash string `json:"-" gorm:"not null"`
+	// Scopes is a space-separated list, see KnownScopes.
+	Scopes string `gorm:"not null"`
+	// OrganizationID is the organization the key acts in; nil means the owner's
+	// default organization.
+	OrganizationID *uint
+	ExpiresAt      *time.Time
+	LastUsedAt     *time.Time
+	RevokedAt      *time.Time
+}
+
+func (APIKey) AuditEntityType() string {
+	return "api_key"
+}
+
+func (k *APIKey) IsUsable(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
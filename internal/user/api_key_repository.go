@@ -0,0 +1,64 @@
+package user
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(key *APIKey) error
+	FindByPrefix(prefix string) (*APIKey, error)
+	FindByID(id uint) (*APIKey, error)
+	FindByUserID(userID uint) ([]APIKey, error)
+	Revoke(id uint) error
+	RevokeByUserID(userID uint) error
+	TouchLastUsed(id uint, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(key *APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepository) FindByPrefix(prefix string) (*APIKey, error) {
+	var key APIKey
+	err := r.db.Where("prefix = ?", prefix).First(&key).Error
+	return &key, err
+}
+
+func (r *apiKeyRepository) FindByID(id uint) (*APIKey, error) {
+	var key APIKey
+	err := r.db.First(&key, id).Error
+	return &key, err
+}
+
+func (r *apiKeyRepository) FindByUserID(userID uint) ([]APIKey, error) {
+	var keys []APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) Revoke(id uint) error {
+	return r.db.Model(&APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeByUserID revokes all of the user's keys that aren't revoked yet.
+func (r *apiKeyRepository) RevokeByUserID(userID uint) error {
+	return r.db.Model(&APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *apiKeyRepository) TouchLastUsed(id uint, at time.Time) error {
+	return r.db.Model(&APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
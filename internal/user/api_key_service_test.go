@@ -0,0 +1,185 @@
+package user
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeAPIKeys keeps API keys in memory.
+type fakeAPIKeys struct {
+	APIKeyRepository
+	keys []APIKey
+}
+
+func (r *fakeAPIKeys) Create(key *APIKey) error {
+	key.ID = uint(len(r.keys) + 1)
+	r.keys = append(r.keys, *key)
+	return nil
+}
+
+func (r *fakeAPIKeys) FindByPrefix(prefix string) (*APIKey, error) {
+	for i := range r.keys {
+		if r.keys[i].Prefix == prefix {
+			key := r.keys[i]
+			return &key, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeAPIKeys) FindByID(id uint) (*APIKey, error) {
+	for i := range r.keys {
+		if r.keys[i].ID == id {
+			key := r.keys[i]
+			return &key, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeAPIKeys) Revoke(id uint) error {
+	now := time.Now()
+	for i := range r.keys {
+		if r.keys[i].ID == id && r.keys[i].RevokedAt == nil {
+			r.keys[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeAPIKeys) RevokeByUserID(userID uint) error {
+	now := time.Now()
+	for i := range r.keys {
+		if r.keys[i].UserID == userID && r.keys[i].RevokedAt == nil {
+			r.keys[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeAPIKeys) TouchLastUsed(id uint, at time.Time) error {
+	for i := range r.keys {
+		if r.keys[i].ID == id {
+			r.keys[i].LastUsedAt = &at
+		}
+	}
+	return nil
+}
+
+// fakeOrganizations puts every user in organization 7.
+type fakeOrganizations struct {
+	OrganizationDirectory
+}
+
+func (fakeOrganizations) DefaultOrganizationID(userID uint) (uint, error) {
+	return 7, nil
+}
+
+func newAPIKeyService() (*service, *fakeAPIKeys) {
+	keys := &fakeAPIKeys{}
+	users := &fakeUsers{users: []User{
+		{Model: gorm.Model{ID: 1}, Email: "scanner@example.com", IsServiceAccount: true},
+		{Model: gorm.Model{ID: 2}, Email: "ada@example.com"},
+	}}
+	return &service{userRepo: users, apiKeyRepo: keys, orgs: fakeOrganizations{}}, keys
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	s, keys := newAPIKeyService()
+	owner, _ := s.userRepo.FindByID(1)
+	created, err := s.CreateAPIKey(owner, CreateAPIKeyInput{Name: "scanner", Scopes: []string{"products:read", "inventory:write"}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if keys.keys[0].SecretHash == "" || strings.Contains(created.Key, keys.keys[0].SecretHash) {
+		t.Errorf("secret not stored as a hash: %+v", keys.keys[0])
+	}
+
+	prefix, secret, _ := strings.Cut(strings.TrimPrefix(created.Key, apiKeyTokenPrefix), "_")
+	tests := []struct {
+		name string
+		key  string
+		ok   bool
+	}{
+		{"valid key", created.Key, true},
+		{"wrong secret", apiKeyTokenPrefix + prefix + "_" + strings.Repeat("x", len(secret)), false},
+		{"unknown prefix", apiKeyTokenPrefix + "nope_" + secret, false},
+		{"missing token prefix", prefix + "_" + secret, false},
+		{"missing secret", apiKeyTokenPrefix + prefix, false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := s.AuthenticateAPIKey(tt.key)
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalidAPIKey) {
+					t.Errorf("err = %v, want ErrInvalidAPIKey", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AuthenticateAPIKey: %v", err)
+			}
+			if principal.User.ID != 1 || principal.OrganizationID != 7 {
+				t.Errorf("principal = user %d in organization %d, want user 1 in 7", principal.User.ID, principal.OrganizationID)
+			}
+			if want := []string{"products:read", "inventory:write"}; !reflect.DeepEqual(principal.Scopes, want) {
+				t.Errorf("scopes = %v, want %v", principal.Scopes, want)
+			}
+		})
+	}
+
+	if keys.keys[0].LastUsedAt == nil {
+		t.Errorf("last use not recorded")
+	}
+}
+
+func TestAuthenticateAPIKeyRejectsUnusableKeys(t *testing.T) {
+	s, keys := newAPIKeyService()
+	owner, _ := s.userRepo.FindByID(1)
+	created, err := s.CreateAPIKey(owner, CreateAPIKeyInput{Name: "scanner", Scopes: []string{"products:read"}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	// Another user can't revoke the key.
+	if err := s.RevokeAPIKey(2, keys.keys[0].ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("RevokeAPIKey by another user: err = %v, want ErrAPIKeyNotFound", err)
+	}
+	if _, err := s.AuthenticateAPIKey(created.Key); err != nil {
+		t.Fatalf("key unusable before it was revoked: %v", err)
+	}
+
+	if err := s.RevokeAPIKey(1, keys.keys[0].ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+	if _, err := s.AuthenticateAPIKey(created.Key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("revoked key: err = %v, want ErrInvalidAPIKey", err)
+	}
+
+	expired, err := s.CreateAPIKey(owner, CreateAPIKeyInput{Name: "old", Scopes: []string{"products:read"}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	keys.keys[1].ExpiresAt = &past
+	if _, err := s.AuthenticateAPIKey(expired.Key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expired key: err = %v, want ErrInvalidAPIKey", err)
+	}
+}
+
+func TestCreateAPIKeyRejectsUnknownScopes(t *testing.T) {
+	s, keys := newAPIKeyService()
+	owner, _ := s.userRepo.FindByID(1)
+	if _, err := s.CreateAPIKey(owner, CreateAPIKeyInput{Name: "scanner", Scopes: []string{"admin"}}); err == nil {
+		t.Errorf("key created with an unknown scope")
+	}
+	if len(keys.keys) != 0 {
+		t.Errorf("keys = %+v, want none", keys.keys)
+	}
+}
@@ -1,5 +1,7 @@
 package user
 
+import "time"
+
 type CreateUserInput struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
@@ -23,3 +25,155 @@ type RefreshTokenInput struct {
 type AccessTokenResponse struct {
 	AccessToken string `json:"accessToken"`
 }
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+type ResendVerificationInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
+	ExpiresIn   int    `json:"expiresIn"`
+}
+
+type VerifyMFAInput struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	// Code is either a current TOTP code or one of the user's recovery codes.
+	Code string `json:"code" binding:"required"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauthUrl"`
+	// QRCodePNG is a base64-encoded PNG of the otpauth:// URL.
+	QRCodePNG string `json:"qrCodePng"`
+}
+
+type MFACodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type UpdateRoleInput struct {
+	RequireMFA *bool `json:"requireMfa" binding:"required"`
+}
+
+type RoleResponse struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	RequireMFA  bool   `json:"requireMfa"`
+}
+
+type LockoutEventResponse struct {
+	ID          uint      `json:"id"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UserID      *uint     `json:"userID,omitempty"`
+	Email       string    `json:"email"`
+	IPAddress   string    `json:"ipAddress"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+type UserResponse struct {
+	ID                    uint       `json:"id"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	Name                  string     `json:"name"`
+	Email                 string     `json:"email"`
+	EmailVerifiedAt       *time.Time `json:"emailVerifiedAt"`
+	Roles                 []string   `json:"roles"`
+	TOTPEnabled           bool       `json:"totpEnabled"`
+	Active                bool       `json:"active"`
+	IsServiceAccount      bool       `json:"isServiceAccount"`
+	DeactivatedAt         *time.Time `json:"deactivatedAt,omitempty"`
+	PasswordResetRequired bool       `json:"passwordResetRequired"`
+}
+
+type ListUsersInput struct {
+	// Query matches against name and email.
+	Query    string `form:"q"`
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"pageSize,default=20" binding:"min=1,max=100"`
+}
+
+type UserListResponse struct {
+	Data     []UserResponse `json:"data"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"pageSize"`
+	Total    int64          `json:"total"`
+}
+
+type UpdateUserRolesInput struct {
+	Roles []string `json:"roles" binding:"required,min=1,dive,required"`
+}
+
+type UpdateProfileInput struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8"`
+}
+
+type CreateAPIKeyInput struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays *int     `json:"expiresInDays" binding:"omitempty,min=1"`
+	// OrganizationID defaults to the caller's active organization for their own keys,
+	// and to the owner's default organization for service account keys.
+	OrganizationID *uint `json:"organizationId"`
+}
+
+type APIKeyResponse struct {
+	ID             uint       `json:"id"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UserID         uint       `json:"userID"`
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix"`
+	Scopes         []string   `json:"scopes"`
+	OrganizationID *uint      `json:"organizationId,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+	LastUsedAt     *time.Time `json:"lastUsedAt"`
+	RevokedAt      *time.Time `json:"revokedAt,omitempty"`
+}
+
+// CreatedAPIKeyResponse includes the full key, which is shown only once.
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+type SwitchOrganizationInput struct {
+	OrganizationID uint `json:"organizationId" binding:"required"`
+}
+
+type CreateServiceAccountInput struct {
+	Name  string   `json:"name" binding:"required"`
+	Roles []string `json:"roles"`
+}
+
+// ExternalIdentity is a user authenticated by an external identity provider.
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
@@ -1,7 +1,9 @@
 package user
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,7 +18,7 @@ func NewHandler(svc Service) *Handler {
 
 // CreateUser handles the API request to create a new user.
 // @Summary      Register a new user
-// @Description  Creates a new user account with the provided details.
+// @Description  Creates a new, unverified user account and emails a verification link.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -38,21 +40,23 @@ func (h *Handler) CreateUser(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "User created successfully",
+		"message": "User created successfully. Please check your email to verify your account.",
 		"userID":  createdUser.ID,
 	})
 }
 
 // Login handles the API request for user login.
 // @Summary      Log in a user
-// @Description  Authenticates a user and returns an access token and refresh token.
+// @Description  Authenticates a user and returns an access token and refresh token. If two-factor authentication is enabled, an MFA challenge is returned instead.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        credentials body LoginInput true "User Login Credentials"
-// @Success      200  {object}  LoginResponse
+// @Success      200  {object}  LoginResponse  "Tokens, or an MFAChallengeResponse when 2FA is enabled"
 // @Failure      400  {object}  map[string]interface{}
 // @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}  "Account deactivated or password reset required"
+// @Failure      429  {object}  map[string]interface{}  "Too many failed attempts; see the Retry-After header"
 // @Router       /login [post]
 func (h *Handler) Login(c *gin.Context) {
 	var input LoginInput
@@ -61,12 +65,28 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	loginResponse, err := h.svc.Login(input)
+	loginResponse, challenge, err := h.svc.Login(input, c.ClientIP())
 	if err != nil {
+		var lockedErr *LoginLockedError
+		if errors.As(err, &lockedErr) {
+			c.Header("Retry-After", strconv.Itoa(lockedErr.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": lockedErr.Error()})
+			return
+		}
+		if errors.Is(err, ErrAccountDeactivated) || errors.Is(err, ErrPasswordResetRequired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
+	// Two-factor users get a challenge token to exchange at /login/mfa instead.
+	if challenge != nil {
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
 	// On success, we return the token
 	c.JSON(http.StatusOK, gin.H{"token": loginResponse})
 }
@@ -97,3 +117,112 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// ForgotPassword handles the API request to start a password reset.
+// @Summary      Request a password reset
+// @Description  Emails a single-use password reset link if an account with the given email exists.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body ForgotPasswordInput true "Account Email"
+// @Success      202  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /password/forgot [post]
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var input ForgotPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.RequestPasswordReset(input); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password reset request"})
+		return
+	}
+
+	// Same response whether or not the email exists, so accounts can't be enumerated.
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If an account with that email exists, a password reset link has been sent",
+	})
+}
+
+// ResetPassword handles the API request to set a new password using a reset token.
+// @Summary      Reset password
+// @Description  Sets a new password using a reset token and revokes all of the user's refresh tokens.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body ResetPasswordInput true "Reset Token and New Password"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /password/reset [post]
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var input ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.ResetPassword(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset successfully"})
+}
+
+// VerifyEmail handles the API request to confirm a user's email address.
+// @Summary      Verify email address
+// @Description  Marks the account as verified using the token from the verification email. The token may be sent as a query parameter or in a JSON body.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        token query string false "Verification Token"
+// @Param        request body VerifyEmailInput false "Verification Token"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /email/verify [get]
+// @Router       /email/verify [post]
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var input VerifyEmailInput
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.VerifyEmail(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email address verified successfully"})
+}
+
+// ResendVerification handles the API request to send a new verification email.
+// @Summary      Resend verification email
+// @Description  Sends a new verification link if an unverified account with the given email exists.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body ResendVerificationInput true "Account Email"
+// @Success      202  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /email/verify/resend [post]
+func (h *Handler) ResendVerification(c *gin.Context) {
+	var input ResendVerificationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.ResendVerificationEmail(input); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If an unverified account with that email exists, a verification link has been sent",
+	})
+}
@@ -0,0 +1,14 @@
+package user
+
+import "gorm.io/gorm"
+
+// UserIdentity links a user to an account at an external identity provider.
+// The provider's issuer and subject identify the account; the email can change.
+type UserIdentity struct {
+	gorm.Model
+	UserID  uint `gorm:"not null;index"`
+	User    User
+	Issuer  string `gorm:"not null;uniqueIndex:idx_user_identities_subject"`
+	Subject string `gorm:"not null;uniqueIndex:idx_user_identities_subject"`
+	Email   string
+}
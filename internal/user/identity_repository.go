@@ -0,0 +1,31 @@
+package user
+
+import "gorm.io/gorm"
+
+type IdentityRepository interface {
+	Create(identity *UserIdentity) error
+	FindBySubject(issuer, subject string) (*UserIdentity, error)
+	UpdateEmail(id uint, email string) error
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) Create(identity *UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+func (r *identityRepository) FindBySubject(issuer, subject string) (*UserIdentity, error) {
+	var identity UserIdentity
+	err := r.db.Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error
+	return &identity, err
+}
+
+func (r *identityRepository) UpdateEmail(id uint, email string) error {
+	return r.db.Model(&UserIdentity{}).Where("id = ?", id).Update("email", email).Error
+}
@@ -0,0 +1,159 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExternalEmailRequired = errors.New("the identity provider did not return an email address")
+	ErrExternalEmailInUse    = errors.New("an account with this email already exists; the identity provider must confirm the email before it can be linked")
+)
+
+// LoginWithExternalIdentity signs in a user authenticated by an external identity
+// provider. Known identities log in their linked user; otherwise the identity is linked
+// to the user with the same (provider-verified) email, or a new user is provisioned
+// with defaultRole. The result is the same as a password login.
+func (s *service) LoginWithExternalIdentity(identity ExternalIdentity, defaultRole string) (*LoginResponse, *MFAChallengeResponse, error) {
+	user, err := s.userForExternalIdentity(identity, defaultRole)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !user.IsActive() {
+		return nil, nil, ErrAccountDeactivated
+	}
+
+	// The identity provider replaces the password, not the second factor.
+	if user.IsTOTPEnabled() {
+		challenge, err := s.issueMFAChallenge(user)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, challenge, nil
+	}
+
+	tokens, err := s.issueTokenPair(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, nil, nil
+}
+
+func (s *service) userForExternalIdentity(identity ExternalIdentity, defaultRole string) (*User, error) {
+	linked, err := s.identityRepo.FindBySubject(identity.Issuer, identity.Subject)
+	if err == nil {
+		if identity.Email != "" && linked.Email != identity.Email {
+			if err := s.identityRepo.UpdateEmail(linked.ID, identity.Email); err != nil {
+				log.Printf("could not update email of identity %d: %v", linked.ID, err)
+			}
+		}
+		return s.userRepo.FindByID(linked.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	if identity.Email == "" {
+		return nil, ErrExternalEmailRequired
+	}
+
+	user, err := s.userRepo.FindByEmail(identity.Email)
+	switch {
+	case err == nil:
+		// Linking on an unverified email would let anyone who controls an IdP account
+		// with that address take over the local account.
+		if !identity.EmailVerified || user.IsServiceAccount {
+			return nil, ErrExternalEmailInUse
+		}
+		if !user.IsEmailVerified() {
+			if err := s.claimUnverifiedUser(user); err != nil {
+				return nil, err
+			}
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		user, err = s.provisionExternalUser(identity, defaultRole)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	err = s.identityRepo.Create(&UserIdentity{
+		UserID:  user.ID,
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		Email:   identity.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not link identity: %w", err)
+	}
+
+	return user, nil
+}
+
+// claimUnverifiedUser hands an unverified account to the owner of its email, as
+// confirmed by the identity provider. Whoever registered it may not have owned the
+// address, so their password and sessions stop working.
+func (s *service) claimUnverifiedUser(user *User) error {
+	if err := s.rtRepo.DeleteByUserID(user.ID); err != nil {
+		return fmt.Errorf("could not revoke refresh tokens: %w", err)
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	user.Password = ""
+	user.PasswordResetRequired = false
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("could not verify email: %w", err)
+	}
+	return nil
+}
+
+// provisionExternalUser creates a user without a password. They can set one later
+// through the password reset flow.
+func (s *service) provisionExternalUser(identity ExternalIdentity, defaultRole string) (*User, error) {
+	if defaultRole == "" {
+		defaultRole = defaultRoleName()
+	}
+	roles, err := s.rolesForNewUser(identity.Email, defaultRole)
+	if err != nil {
+		return nil, err
+	}
+
+	name := identity.Name
+	if name == "" {
+		name = identity.Email
+	}
+
+	newUser := User{
+		Name:  name,
+		Email: identity.Email,
+		Roles: roles,
+	}
+	if identity.EmailVerified {
+		now := time.Now()
+		newUser.EmailVerifiedAt = &now
+	}
+
+	if err := s.userRepo.Save(&newUser); err != nil {
+		return nil, fmt.Errorf("could not create user: %w", err)
+	}
+
+	if err := s.orgs.AddNewUser(newUser.ID); err != nil {
+		log.Printf("could not add user %d to an organization: %v", newUser.ID, err)
+	}
+
+	if !newUser.IsEmailVerified() {
+		if err := s.sendVerificationEmail(&newUser); err != nil {
+			log.Printf("could not send verification email to user %d: %v", newUser.ID, err)
+		}
+	}
+
+	return &newUser, nil
+}
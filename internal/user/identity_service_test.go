@@ -0,0 +1,197 @@
+package user
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeUsers and fakeIdentities keep users and identities in memory. Methods the tests
+// don't need panic through the embedded nil interfaces.
+type fakeUsers struct {
+	Repository
+	users []User
+}
+
+func (r *fakeUsers) FindByEmail(email string) (*User, error) {
+	for i := range r.users {
+		if r.users[i].Email == email {
+			return &r.users[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUsers) FindByID(id uint) (*User, error) {
+	for i := range r.users {
+		if r.users[i].ID == id {
+			return &r.users[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUsers) Update(user *User) error {
+	for i := range r.users {
+		if r.users[i].ID == user.ID {
+			r.users[i] = *user
+		}
+	}
+	return nil
+}
+
+// fakeRefreshTokens records whose refresh tokens were revoked and forgets new ones.
+type fakeRefreshTokens struct {
+	RefreshTokenRepository
+	revoked []uint
+}
+
+func (r *fakeRefreshTokens) Create(token *RefreshToken) error {
+	return nil
+}
+
+func (r *fakeRefreshTokens) DeleteByUserID(userID uint) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+type fakeIdentities struct {
+	IdentityRepository
+	identities []UserIdentity
+}
+
+func (r *fakeIdentities) Create(identity *UserIdentity) error {
+	identity.ID = uint(len(r.identities) + 1)
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+func (r *fakeIdentities) FindBySubject(issuer, subject string) (*UserIdentity, error) {
+	for i := range r.identities {
+		if r.identities[i].Issuer == issuer && r.identities[i].Subject == subject {
+			return &r.identities[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeIdentities) UpdateEmail(id uint, email string) error {
+	for i := range r.identities {
+		if r.identities[i].ID == id {
+			r.identities[i].Email = email
+		}
+	}
+	return nil
+}
+
+func TestUserForExternalIdentity(t *testing.T) {
+	const issuer = "https://idp.example.com"
+	verifiedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	newUsers := func() *fakeUsers {
+		return &fakeUsers{users: []User{
+			{Model: gorm.Model{ID: 1}, Email: "ada@example.com", Password: "hash", EmailVerifiedAt: &verifiedAt},
+			{Model: gorm.Model{ID: 2}, Email: "scanner@example.com", IsServiceAccount: true},
+			{Model: gorm.Model{ID: 3}, Email: "grace@example.com", Password: "squatter's hash"},
+		}}
+	}
+
+	tests := []struct {
+		name       string
+		identities []UserIdentity
+		identity   ExternalIdentity
+		wantUser   uint
+		wantErr    error
+		wantLinked bool
+		// wantClaimed is set when an unverified account is linked, which verifies it
+		// and signs out whoever registered it.
+		wantClaimed bool
+	}{
+		{
+			name:       "verified email links the existing user",
+			identity:   ExternalIdentity{Issuer: issuer, Subject: "s-1", Email: "ada@example.com", EmailVerified: true},
+			wantUser:   1,
+			wantLinked: true,
+		},
+		{
+			name:        "verified email claims an unverified user",
+			identity:    ExternalIdentity{Issuer: issuer, Subject: "s-4", Email: "grace@example.com", EmailVerified: true},
+			wantUser:    3,
+			wantLinked:  true,
+			wantClaimed: true,
+		},
+		{
+			name:     "unverified email doesn't link",
+			identity: ExternalIdentity{Issuer: issuer, Subject: "s-1", Email: "ada@example.com"},
+			wantErr:  ErrExternalEmailInUse,
+		},
+		{
+			name:     "service accounts are never linked",
+			identity: ExternalIdentity{Issuer: issuer, Subject: "s-2", Email: "scanner@example.com", EmailVerified: true},
+			wantErr:  ErrExternalEmailInUse,
+		},
+		{
+			name:     "email is required for unknown identities",
+			identity: ExternalIdentity{Issuer: issuer, Subject: "s-3"},
+			wantErr:  ErrExternalEmailRequired,
+		},
+		{
+			name:       "known identity logs in its user whatever the email",
+			identities: []UserIdentity{{Model: gorm.Model{ID: 1}, UserID: 1, Issuer: issuer, Subject: "s-1", Email: "ada@example.com"}},
+			identity:   ExternalIdentity{Issuer: issuer, Subject: "s-1", Email: "ada@work.example.com"},
+			wantUser:   1,
+		},
+		{
+			name:       "same subject from another issuer is another identity",
+			identities: []UserIdentity{{Model: gorm.Model{ID: 1}, UserID: 1, Issuer: issuer, Subject: "s-1", Email: "ada@example.com"}},
+			identity:   ExternalIdentity{Issuer: "https://other.example.com", Subject: "s-1", Email: "ada@example.com"},
+			wantErr:    ErrExternalEmailInUse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newUsers()
+			refreshTokens := &fakeRefreshTokens{}
+			identities := &fakeIdentities{identities: tt.identities}
+			s := &service{userRepo: users, rtRepo: refreshTokens, identityRepo: identities}
+
+			user, err := s.userForExternalIdentity(tt.identity, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(identities.identities) != len(tt.identities) {
+					t.Errorf("identity linked although the login failed: %+v", identities.identities)
+				}
+				return
+			}
+			if user.ID != tt.wantUser {
+				t.Errorf("user = %d, want %d", user.ID, tt.wantUser)
+			}
+
+			linked, err := identities.FindBySubject(tt.identity.Issuer, tt.identity.Subject)
+			if err != nil {
+				t.Fatalf("identity not linked: %v", err)
+			}
+			if linked.UserID != tt.wantUser || linked.Email != tt.identity.Email {
+				t.Errorf("identity = %+v, want user %d with email %q", linked, tt.wantUser, tt.identity.Email)
+			}
+			if created := len(identities.identities) > len(tt.identities); created != tt.wantLinked {
+				t.Errorf("new identity created = %v, want %v", created, tt.wantLinked)
+			}
+
+			stored, _ := users.FindByID(tt.wantUser)
+			if !stored.IsEmailVerified() {
+				t.Errorf("linked user's email is not verified")
+			}
+			if claimed := stored.Password == ""; claimed != tt.wantClaimed {
+				t.Errorf("password cleared = %v, want %v", claimed, tt.wantClaimed)
+			}
+			if revoked := slices.Contains(refreshTokens.revoked, tt.wantUser); revoked != tt.wantClaimed {
+				t.Errorf("refresh tokens revoked = %v, want %v", revoked, tt.wantClaimed)
+			}
+		})
+	}
+}
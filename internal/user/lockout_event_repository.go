@@ -0,0 +1,41 @@
+package user
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LockoutEventRepository interface {
+	Create(event *LockoutEvent) error
+	FindActive() ([]LockoutEvent, error)
+	MarkUnlocked(key string, unlockedByID uint) error
+}
+
+type lockoutEventRepository struct {
+	db *gorm.DB
+}
+
+func NewLockoutEventRepository(db *gorm.DB) LockoutEventRepository {
+	return &lockoutEventRepository{db: db}
+}
+
+func (r *lockoutEventRepository) Create(event *LockoutEvent) error {
+	return r.db.Create(event).Error
+}
+
+// FindActive returns lockouts that have not expired and have not been lifted.
+func (r *lockoutEventRepository) FindActive() ([]LockoutEvent, error) {
+	var events []LockoutEvent
+	err := r.db.
+		Where("locked_until > ? AND unlocked_at IS NULL", time.Now()).
+		Order("created_at DESC").
+		Find(&events).Error
+	return events, err
+}
+
+func (r *lockoutEventRepository) MarkUnlocked(key string, unlockedByID uint) error {
+	return r.db.Model(&LockoutEvent{}).
+		Where("key = ? AND locked_until > ? AND unlocked_at IS NULL", key, time.Now()).
+		Updates(map[string]interface{}{"unlocked_at": time.Now(), "unlocked_by_id": unlockedByID}).Error
+}
@@ -0,0 +1,221 @@
+package user
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxLoginDelay caps the progressive delay between failed attempts.
+const maxLoginDelay = 30 * time.Second
+
+// LoginLockedError is returned by Login while an account or client IP is throttled.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %d seconds", retryAfterSeconds(e.RetryAfter))
+}
+
+// RetryAfterSeconds rounds the wait up to whole seconds for the Retry-After header.
+func (e *LoginLockedError) RetryAfterSeconds() int {
+	return retryAfterSeconds(e.RetryAfter)
+}
+
+func retryAfterSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+type loginPolicy struct {
+	maxAccountFailures int
+	maxIPFailures      int
+	lockout            time.Duration
+	window             time.Duration
+	baseDelay          time.Duration
+}
+
+func currentLoginPolicy() loginPolicy {
+	maxAccountFailures, _ := strconv.Atoi(os.Getenv("LOGIN_MAX_FAILED_ATTEMPTS"))
+	if maxAccountFailures == 0 {
+		maxAccountFailures = 5
+	}
+
+	maxIPFailures, _ := strconv.Atoi(os.Getenv("LOGIN_MAX_FAILED_ATTEMPTS_PER_IP"))
+	if maxIPFailures == 0 {
+		maxIPFailures = 20
+	}
+
+	lockoutMinutes, _ := strconv.Atoi(os.Getenv("LOGIN_LOCKOUT_MINUTES"))
+	if lockoutMinutes == 0 {
+		lockoutMinutes = 15
+	}
+
+	windowMinutes, _ := strconv.Atoi(os.Getenv("LOGIN_ATTEMPT_WINDOW_MINUTES"))
+	if windowMinutes == 0 {
+		windowMinutes = 15
+	}
+
+	baseDelaySeconds, _ := strconv.Atoi(os.Getenv("LOGIN_DELAY_BASE_SECONDS"))
+	if baseDelaySeconds == 0 {
+		baseDelaySeconds = 1
+	}
+
+	return loginPolicy{
+		maxAccountFailures: maxAccountFailures,
+		maxIPFailures:      maxIPFailures,
+		lockout:            time.Minute * time.Duration(lockoutMinutes),
+		window:             time.Minute * time.Duration(windowMinutes),
+		baseDelay:          time.Second * time.Duration(baseDelaySeconds),
+	}
+}
+
+// retryAfter reports how long a throttled key must wait before its next attempt:
+// either until its lockout ends, or until a delay that doubles with each recent failure.
+func (p loginPolicy) retryAfter(t LoginThrottle, now time.Time) time.Duration {
+	if t.LockedUntil != nil && now.Before(*t.LockedUntil) {
+		return t.LockedUntil.Sub(now)
+	}
+
+	if t.FailedAttempts == 0 || now.Sub(t.LastFailedAt) > p.window {
+		return 0
+	}
+
+	delay := p.baseDelay << (t.FailedAttempts - 1)
+	if delay <= 0 || delay > maxLoginDelay {
+		delay = maxLoginDelay
+	}
+
+	return t.LastFailedAt.Add(delay).Sub(now)
+}
+
+// checkLoginThrottle returns a LoginLockedError if either the account or the IP must wait.
+func (s *service) checkLoginThrottle(policy loginPolicy, email, clientIP string) error {
+	throttles, err := s.throttleRepo.FindByKeys(accountThrottleKey(email), ipThrottleKey(clientIP))
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+
+	now := time.Now()
+	var wait time.Duration
+	for _, t := range throttles {
+		if d := policy.retryAfter(t, now); d > wait {
+			wait = d
+		}
+	}
+
+	if wait > 0 {
+		return &LoginLockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed attempt against the account and the IP, and
+// locks whichever of them crossed its threshold.
+func (s *service) recordLoginFailure(policy loginPolicy, email, clientIP string, user *User) {
+	windowStart := time.Now().Add(-policy.window)
+
+	limits := map[string]int{
+		accountThrottleKey(email): policy.maxAccountFailures,
+		ipThrottleKey(clientIP):   policy.maxIPFailures,
+	}
+
+	for key, limit := range limits {
+		throttle, err := s.throttleRepo.RecordFailure(key, windowStart)
+		if err != nil {
+			log.Printf("could not record failed login for %s: %v", key, err)
+			continue
+		}
+		if throttle.FailedAttempts < limit {
+			continue
+		}
+
+		lockedUntil := time.Now().Add(policy.lockout)
+		if err := s.throttleRepo.Lock(key, lockedUntil); err != nil {
+			log.Printf("could not lock %s: %v", key, err)
+			continue
+		}
+
+		event := &LockoutEvent{
+			Key:         key,
+			Email:       normalizeEmail(email),
+			IPAddress:   clientIP,
+			LockedUntil: lockedUntil,
+		}
+		if user != nil && strings.HasPrefix(key, "account:") {
+			event.UserID = &user.ID
+		}
+		if err := s.lockoutRepo.Create(event); err != nil {
+			log.Printf("could not record lockout for %s: %v", key, err)
+		}
+	}
+}
+
+// clearLoginFailures clears the account's counter once the user has fully logged in.
+// The IP counter is left alone, otherwise an attacker could reset it by logging into
+// an account of their own.
+func (s *service) clearLoginFailures(user *User) {
+	if err := s.throttleRepo.Reset(accountThrottleKey(user.Email)); err != nil {
+		log.Printf("could not reset login throttle for user %d: %v", user.ID, err)
+	}
+}
+
+// UnlockUser lifts any lockout on the user's account and clears its failure counter.
+func (s *service) UnlockUser(userID uint, adminID uint) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	key := accountThrottleKey(user.Email)
+	if err := s.throttleRepo.Reset(key); err != nil {
+		return fmt.Errorf("could not unlock account: %w", err)
+	}
+
+	return s.lockoutRepo.MarkUnlocked(key, adminID)
+}
+
+func (s *service) GetActiveLockouts() ([]LockoutEventResponse, error) {
+	events, err := s.lockoutRepo.FindActive()
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []LockoutEventResponse
+	for _, event := range events {
+		responses = append(responses, LockoutEventResponse{
+			ID:          event.ID,
+			CreatedAt:   event.CreatedAt,
+			UserID:      event.UserID,
+			Email:       event.Email,
+			IPAddress:   event.IPAddress,
+			LockedUntil: event.LockedUntil,
+		})
+	}
+	return responses, nil
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// compareDummyPassword spends the same time as a real bcrypt check, so responses for
+// unknown emails can't be told apart from wrong passwords by timing.
+func compareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
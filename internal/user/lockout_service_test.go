@@ -0,0 +1,187 @@
+package user
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// fakeThrottles keeps login throttles in memory and counts failures like the upsert.
+type fakeThrottles struct {
+	LoginThrottleRepository
+	throttles map[string]LoginThrottle
+}
+
+func (r *fakeThrottles) FindByKeys(keys ...string) ([]LoginThrottle, error) {
+	var found []LoginThrottle
+	for _, key := range keys {
+		if throttle, ok := r.throttles[key]; ok {
+			found = append(found, throttle)
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeThrottles) RecordFailure(key string, windowStart time.Time) (*LoginThrottle, error) {
+	throttle, ok := r.throttles[key]
+	if !ok || throttle.LastFailedAt.Before(windowStart) {
+		throttle.FailedAttempts = 0
+	}
+	throttle.Key = key
+	throttle.FailedAttempts++
+	throttle.LastFailedAt = time.Now()
+	r.throttles[key] = throttle
+	return &throttle, nil
+}
+
+func (r *fakeThrottles) Lock(key string, until time.Time) error {
+	throttle := r.throttles[key]
+	throttle.LockedUntil, throttle.FailedAttempts = &until, 0
+	r.throttles[key] = throttle
+	return nil
+}
+
+func (r *fakeThrottles) Reset(key string) error {
+	delete(r.throttles, key)
+	return nil
+}
+
+// age moves every throttle's last failure back in time, as if the client had waited.
+func (r *fakeThrottles) age(d time.Duration) {
+	for key, throttle := range r.throttles {
+		throttle.LastFailedAt = throttle.LastFailedAt.Add(-d)
+		r.throttles[key] = throttle
+	}
+}
+
+type fakeLockouts struct {
+	LockoutEventRepository
+	events []LockoutEvent
+}
+
+func (r *fakeLockouts) Create(event *LockoutEvent) error {
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func newLoginService(t *testing.T) (*service, *fakeThrottles, *fakeLockouts) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("LOGIN_MAX_FAILED_ATTEMPTS", "3")
+	hash, err := bcrypt.GenerateFromPassword([]byte("C0rrect-Password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	throttles := &fakeThrottles{throttles: map[string]LoginThrottle{}}
+	lockouts := &fakeLockouts{}
+	return &service{
+		userRepo:     &fakeUsers{users: []User{{Model: gorm.Model{ID: 1}, Email: "ada@example.com", Password: string(hash)}}},
+		rtRepo:       &fakeRefreshTokens{},
+		orgs:         fakeOrganizations{},
+		throttleRepo: throttles,
+		lockoutRepo:  lockouts,
+	}, throttles, lockouts
+}
+
+func TestLoginPolicyRetryAfter(t *testing.T) {
+	policy := loginPolicy{lockout: 15 * time.Minute, window: 15 * time.Minute, baseDelay: time.Second}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	lockedUntil, lockEnded := now.Add(10*time.Minute), now.Add(-time.Second)
+
+	tests := []struct {
+		name     string
+		throttle LoginThrottle
+		want     time.Duration
+	}{
+		{"no failures", LoginThrottle{}, 0},
+		{"first failure", LoginThrottle{FailedAttempts: 1, LastFailedAt: now}, time.Second},
+		{"delay doubles", LoginThrottle{FailedAttempts: 3, LastFailedAt: now}, 4 * time.Second},
+		{"delay partly waited", LoginThrottle{FailedAttempts: 3, LastFailedAt: now.Add(-3 * time.Second)}, time.Second},
+		// Nothing is left to wait.
+		{"delay waited", LoginThrottle{FailedAttempts: 3, LastFailedAt: now.Add(-5 * time.Second)}, -time.Second},
+		{"delay capped", LoginThrottle{FailedAttempts: 40, LastFailedAt: now}, maxLoginDelay},
+		{"failures outside the window", LoginThrottle{FailedAttempts: 4, LastFailedAt: now.Add(-16 * time.Minute)}, 0},
+		{"locked", LoginThrottle{LockedUntil: &lockedUntil}, 10 * time.Minute},
+		{"lockout over", LoginThrottle{LockedUntil: &lockEnded}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.retryAfter(tt.throttle, now); got != tt.want {
+				t.Errorf("retryAfter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginLocksAccount(t *testing.T) {
+	s, throttles, lockouts := newLoginService(t)
+	wrong := LoginInput{Email: "ada@example.com", Password: "Wr0ng-Password"}
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := s.Login(wrong, "203.0.113.7"); err == nil || errors.As(err, new(*LoginLockedError)) {
+			t.Fatalf("attempt %d: err = %v, want invalid credentials", i+1, err)
+		}
+		// Wait out the delay, but stay within the window.
+		throttles.age(time.Minute)
+	}
+
+	_, _, err := s.Login(LoginInput{Email: "ada@example.com", Password: "C0rrect-Password"}, "198.51.100.1")
+	var locked *LoginLockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("err = %v, want LoginLockedError", err)
+	}
+	if locked.RetryAfter < 14*time.Minute || locked.RetryAfter > 15*time.Minute {
+		t.Errorf("retry after %v, want the 15 minute lockout", locked.RetryAfter)
+	}
+	if len(lockouts.events) != 1 || lockouts.events[0].Key != "account:ada@example.com" ||
+		lockouts.events[0].UserID == nil || *lockouts.events[0].UserID != 1 {
+		t.Errorf("lockout events = %+v, want one of user 1's account", lockouts.events)
+	}
+}
+
+func TestLoginFailuresOutsideWindowAreForgotten(t *testing.T) {
+	s, throttles, lockouts := newLoginService(t)
+	wrong := LoginInput{Email: "ada@example.com", Password: "Wr0ng-Password"}
+
+	for i := 0; i < 5; i++ {
+		if _, _, err := s.Login(wrong, "203.0.113.7"); errors.As(err, new(*LoginLockedError)) {
+			t.Fatalf("attempt %d locked out, although earlier failures were outside the window", i+1)
+		}
+		throttles.age(16 * time.Minute)
+	}
+	if len(lockouts.events) > 0 {
+		t.Errorf("lockout events = %+v, want none", lockouts.events)
+	}
+}
+
+func TestLoginResetsAccountFailures(t *testing.T) {
+	s, throttles, lockouts := newLoginService(t)
+	wrong := LoginInput{Email: "ada@example.com", Password: "Wr0ng-Password"}
+	right := LoginInput{Email: "ada@example.com", Password: "C0rrect-Password"}
+
+	// Two failures, a success, and two more failures stay below three in a row.
+	for _, input := range []LoginInput{wrong, wrong, right, wrong, wrong} {
+		_, _, err := s.Login(input, "203.0.113.7")
+		if errors.As(err, new(*LoginLockedError)) {
+			t.Fatalf("locked out: %v", err)
+		}
+		if (err == nil) != (input == right) {
+			t.Fatalf("login with %q: err = %v", input.Password, err)
+		}
+		if input == right {
+			if _, ok := throttles.throttles["account:ada@example.com"]; ok {
+				t.Errorf("account failures not reset on success")
+			}
+			if throttles.throttles["ip:203.0.113.7"].FailedAttempts != 2 {
+				t.Errorf("IP failures = %d, want 2: logging in mustn't reset them", throttles.throttles["ip:203.0.113.7"].FailedAttempts)
+			}
+		}
+		throttles.age(time.Minute)
+	}
+	if len(lockouts.events) > 0 {
+		t.Errorf("lockout events = %+v, want none", lockouts.events)
+	}
+}
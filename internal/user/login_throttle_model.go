@@ -0,0 +1,37 @@
+package user
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginThrottle counts recent failed logins for one key: either an account
+// ("account:<email>") or a client IP address ("ip:<address>").
+type LoginThrottle struct {
+	Key            string `gorm:"primaryKey"`
+	FailedAttempts int    `gorm:"not null;default:0"`
+	LastFailedAt   time.Time
+	LockedUntil    *time.Time
+}
+
+// LockoutEvent records each time an account or IP address was locked out,
+// and whether an administrator lifted the lockout early.
+type LockoutEvent struct {
+	gorm.Model
+	Key          string `gorm:"not null;index"`
+	UserID       *uint
+	Email        string
+	IPAddress    string
+	LockedUntil  time.Time
+	UnlockedAt   *time.Time
+	UnlockedByID *uint
+}
+
+func accountThrottleKey(email string) string {
+	return "account:" + normalizeEmail(email)
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
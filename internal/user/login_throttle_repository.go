@@ -0,0 +1,58 @@
+package user
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LoginThrottleRepository interface {
+	FindByKeys(keys ...string) ([]LoginThrottle, error)
+	RecordFailure(key string, windowStart time.Time) (*LoginThrottle, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+}
+
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) LoginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+func (r *loginThrottleRepository) FindByKeys(keys ...string) ([]LoginThrottle, error) {
+	var throttles []LoginThrottle
+	err := r.db.Where("key IN ?", keys).Find(&throttles).Error
+	return throttles, err
+}
+
+// RecordFailure atomically increments the failure counter for key. Failures older
+// than windowStart are forgotten, so the counter starts again from one.
+func (r *loginThrottleRepository) RecordFailure(key string, windowStart time.Time) (*LoginThrottle, error) {
+	var throttle LoginThrottle
+	err := r.db.Raw(`
+		INSERT INTO login_throttles (key, failed_attempts, last_failed_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failed_attempts = CASE
+				WHEN login_throttles.last_failed_at < ? THEN 1
+				ELSE login_throttles.failed_attempts + 1
+			END,
+			last_failed_at = EXCLUDED.last_failed_at
+		RETURNING *`,
+		key, time.Now(), windowStart,
+	).Scan(&throttle).Error
+	return &throttle, err
+}
+
+// Lock locks the key until the given time and clears its failure counter.
+func (r *loginThrottleRepository) Lock(key string, until time.Time) error {
+	return r.db.Model(&LoginThrottle{}).
+		Where("key = ?", key).
+		Updates(map[string]interface{}{"locked_until": until, "failed_attempts": 0}).Error
+}
+
+func (r *loginThrottleRepository) Reset(key string) error {
+	return r.db.Where("key = ?", key).Delete(&LoginThrottle{}).Error
+}
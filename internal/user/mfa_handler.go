@@ -0,0 +1,194 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyMFA handles the second step of a two-factor login.
+// @Summary      Complete a two-factor login
+// @Description  Exchanges the MFA token from /login and a TOTP or recovery code for an access token and refresh token.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body VerifyMFAInput true "MFA Token and Code"
+// @Success      200  {object}  LoginResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      429  {object}  map[string]interface{}
+// @Router       /login/mfa [post]
+func (h *Handler) VerifyMFA(c *gin.Context) {
+	var input VerifyMFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loginResponse, err := h.svc.VerifyMFALogin(input, c.ClientIP())
+	if err != nil {
+		var lockedErr *LoginLockedError
+		if errors.As(err, &lockedErr) {
+			c.Header("Retry-After", strconv.Itoa(lockedErr.RetryAfterSeconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": lockedErr.Error()})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": loginResponse})
+}
+
+// EnrollTOTP starts TOTP enrolment for the current user.
+// @Summary      Start TOTP enrolment
+// @Description  Generates a new TOTP secret and returns it as an otpauth:// URI and a base64-encoded QR code PNG. Enrolment must be confirmed with a first code.
+// @Tags         MFA
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  TOTPEnrollmentResponse
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /mfa/totp/enroll [post]
+func (h *Handler) EnrollTOTP(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.svc.EnrollTOTP(user)
+	if err != nil {
+		if errors.Is(err, ErrTOTPAlreadyEnabled) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrMFANotAvailable) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start TOTP enrolment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ConfirmTOTP finishes TOTP enrolment for the current user.
+// @Summary      Confirm TOTP enrolment
+// @Description  Enables two-factor authentication after verifying a first code, and returns single-use recovery codes. The codes are only shown once.
+// @Tags         MFA
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body MFACodeInput true "TOTP Code"
+// @Success      200  {object}  RecoveryCodesResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /mfa/totp/confirm [post]
+func (h *Handler) ConfirmTOTP(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.svc.ConfirmTOTP(user, input)
+	if err != nil {
+		if errors.Is(err, ErrTOTPAlreadyEnabled) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DisableTOTP turns off two-factor authentication for the current user.
+// @Summary      Disable TOTP
+// @Description  Disables two-factor authentication. Requires a current TOTP or recovery code, and is refused if one of the user's roles requires 2FA.
+// @Tags         MFA
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body MFACodeInput true "TOTP or Recovery Code"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /mfa/totp/disable [post]
+func (h *Handler) DisableTOTP(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.DisableTOTP(user, input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes.
+// @Summary      Regenerate recovery codes
+// @Description  Invalidates all existing recovery codes and returns a new set. Requires a current TOTP code.
+// @Tags         MFA
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body MFACodeInput true "TOTP Code"
+// @Success      200  {object}  RecoveryCodesResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /mfa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.svc.RegenerateRecoveryCodes(user, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// currentUser returns the user set by AuthMiddleware, writing an error response if it is missing.
+func currentUser(c *gin.Context) (*User, bool) {
+	value, exists := c.Get("currentUser")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return nil, false
+	}
+
+	user, ok := value.(*User)
+	if !ok || user == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		return nil, false
+	}
+
+	return user, true
+}
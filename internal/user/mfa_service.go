@@ -0,0 +1,292 @@
+package user
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpPeriod           = 30
+	recoveryCodeCount    = 10
+	maxMFAChallengeTries = 5
+)
+
+var (
+	ErrTOTPAlreadyEnabled = fmt.Errorf("two-factor authentication is already enabled")
+	ErrTOTPNotEnabled     = fmt.Errorf("two-factor authentication is not enabled")
+	ErrInvalidMFACode     = fmt.Errorf("invalid two-factor authentication code")
+	// Two-factor authentication guards users who can post stock adjustments.
+	ErrMFANotAvailable = fmt.Errorf("two-factor authentication is only available to users who can post stock adjustments")
+	ErrMFANotForRole   = fmt.Errorf("two-factor authentication can only be required for roles that can post stock adjustments")
+)
+
+// issueMFAChallenge creates the short-lived token that stands in for the password
+// between the two steps of a two-factor login.
+func (s *service) issueMFAChallenge(user *User) (*MFAChallengeResponse, error) {
+	expirationMinutes, _ := strconv.Atoi(os.Getenv("MFA_CHALLENGE_EXPIRATION_MINUTES"))
+	if expirationMinutes == 0 {
+		expirationMinutes = 5
+	}
+
+	tokenString, err := s.issueOneTimeToken(user.ID, PurposeMFAChallenge, time.Minute*time.Duration(expirationMinutes))
+	if err != nil {
+		return nil, err
+	}
+
+	return &MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    tokenString,
+		ExpiresIn:   expirationMinutes * 60,
+	}, nil
+}
+
+// VerifyMFALogin completes a two-factor login and issues the normal token pair. Wrong
+// codes count as failed logins of the account and the client IP, exactly like wrong
+// passwords, so the same lockout applies to both factors.
+func (s *service) VerifyMFALogin(input VerifyMFAInput, clientIP string) (*LoginResponse, error) {
+	challenge, err := s.ottRepo.FindByHash(hashToken(input.MFAToken), PurposeMFAChallenge)
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired MFA token")
+	}
+	if challenge.UsedAt != nil || time.Now().After(challenge.ExpiresAt) {
+		return nil, fmt.Errorf("invalid or expired MFA token")
+	}
+
+	user, err := s.userRepo.FindByID(challenge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired MFA token")
+	}
+	if !user.IsActive() {
+		return nil, ErrAccountDeactivated
+	}
+
+	policy := currentLoginPolicy()
+	if err := s.checkLoginThrottle(policy, user.Email, clientIP); err != nil {
+		return nil, err
+	}
+
+	// The attempt is taken before the code is checked, so parallel requests can't
+	// get past the limit.
+	allowed, err := s.ottRepo.ConsumeAttempt(challenge.ID, maxMFAChallengeTries)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if !allowed {
+		return nil, fmt.Errorf("invalid or expired MFA token")
+	}
+
+	ok, err := s.verifySecondFactor(user, input.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.recordLoginFailure(policy, user.Email, clientIP, user)
+		return nil, ErrInvalidMFACode
+	}
+
+	used, err := s.ottRepo.MarkUsed(challenge.ID)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if !used {
+		return nil, fmt.Errorf("invalid or expired MFA token")
+	}
+
+	s.clearLoginFailures(user)
+	return s.issueTokenPair(user)
+}
+
+// EnrollTOTP generates a new secret for the user. It only takes effect after ConfirmTOTP.
+func (s *service) EnrollTOTP(user *User) (*TOTPEnrollmentResponse, error) {
+	if user.IsTOTPEnabled() {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	// Roles set to require it before the rule existed still need a way to comply.
+	if !user.CanManageInventory() && !user.RequiresMFA() {
+		return nil, ErrMFANotAvailable
+	}
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "Inventory API"
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not generate TOTP secret: %w", err)
+	}
+
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return nil, fmt.Errorf("could not render QR code: %w", err)
+	}
+	var qr bytes.Buffer
+	if err := png.Encode(&qr, img); err != nil {
+		return nil, fmt.Errorf("could not encode QR code: %w", err)
+	}
+
+	user.TOTPSecret = key.Secret()
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("could not save TOTP secret: %w", err)
+	}
+
+	return &TOTPEnrollmentResponse{
+		Secret:     key.Secret(),
+		OTPAuthURL: key.URL(),
+		QRCodePNG:  base64.StdEncoding.EncodeToString(qr.Bytes()),
+	}, nil
+}
+
+// ConfirmTOTP turns on two-factor authentication once the user proves their
+// authenticator works, and returns a fresh set of recovery codes.
+func (s *service) ConfirmTOTP(user *User, input MFACodeInput) (*RecoveryCodesResponse, error) {
+	if user.IsTOTPEnabled() {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, fmt.Errorf("start TOTP enrolment before confirming it")
+	}
+
+	ok, err := s.checkTOTPCode(user, input.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("could not enable two-factor authentication: %w", err)
+	}
+
+	return s.replaceRecoveryCodes(user)
+}
+
+func (s *service) DisableTOTP(user *User, input MFACodeInput) error {
+	if !user.IsTOTPEnabled() {
+		return ErrTOTPNotEnabled
+	}
+	if user.RequiresMFA() {
+		return fmt.Errorf("two-factor authentication is required for your role")
+	}
+
+	ok, err := s.verifySecondFactor(user, input.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("could not disable two-factor authentication: %w", err)
+	}
+
+	return s.recoveryRepo.DeleteByUserID(user.ID)
+}
+
+func (s *service) RegenerateRecoveryCodes(user *User, input MFACodeInput) (*RecoveryCodesResponse, error) {
+	if !user.IsTOTPEnabled() {
+		return nil, ErrTOTPNotEnabled
+	}
+
+	ok, err := s.checkTOTPCode(user, input.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	return s.replaceRecoveryCodes(user)
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused recovery code.
+func (s *service) verifySecondFactor(user *User, code string) (bool, error) {
+	ok, err := s.checkTOTPCode(user, code)
+	if err != nil || ok {
+		return ok, err
+	}
+
+	return s.recoveryRepo.Consume(user.ID, hashToken(normalizeRecoveryCode(code)))
+}
+
+// checkTOTPCode validates a code against the current time step, allowing one step of
+// clock drift either way, and rejects codes from steps that were already used.
+func (s *service) checkTOTPCode(user *User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if user.TOTPSecret == "" || len(code) != 6 {
+		return false, nil
+	}
+
+	now := time.Now().Unix() / totpPeriod
+	for _, step := range []int64{now, now - 1, now + 1} {
+		expected, err := totp.GenerateCode(user.TOTPSecret, time.Unix(step*totpPeriod, 0))
+		if err != nil {
+			return false, fmt.Errorf("could not generate TOTP code: %w", err)
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) != 1 {
+			continue
+		}
+
+		if step <= user.TOTPLastStep {
+			return false, nil
+		}
+		user.TOTPLastStep = step
+		if err := s.userRepo.Update(user); err != nil {
+			return false, fmt.Errorf("database error: %w", err)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (s *service) replaceRecoveryCodes(user *User) (*RecoveryCodesResponse, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("could not generate recovery code: %w", err)
+		}
+		encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)[:10]
+		code := encoded[:5] + "-" + encoded[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := s.recoveryRepo.ReplaceForUser(user.ID, hashes); err != nil {
+		return nil, fmt.Errorf("could not save recovery codes: %w", err)
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
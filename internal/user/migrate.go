@@ -0,0 +1,71 @@
+package user
+
+import (
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Migrate creates or updates the tables owned by the user package and seeds the default roles.
+func Migrate(db *gorm.DB) error {
+	// Accounts created before email verification existed are treated as verified,
+	// otherwise every existing user would be locked out after the upgrade.
+	backfillVerification := db.Migrator().HasTable(&User{}) &&
+		!db.Migrator().HasColumn(&User{}, "EmailVerifiedAt")
+	// Likewise, accounts created before roles existed get the default role.
+	backfillRoles := db.Migrator().HasTable(&User{}) && !db.Migrator().HasTable("user_roles")
+
+	if err := db.AutoMigrate(&Role{}, &User{}, &RefreshToken{}, &OneTimeToken{}, &RecoveryCode{}, &LoginThrottle{}, &LockoutEvent{}, &APIKey{}, &UserIdentity{}); err != nil {
+		return err
+	}
+
+	if backfillVerification {
+		err := db.Model(&User{}).
+			Where("email_verified_at IS NULL").
+			Update("email_verified_at", gorm.Expr("created_at")).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, role := range defaultRoles {
+		if err := db.Where(Role{Name: role.Name}).Attrs(role).FirstOrCreate(&Role{}).Error; err != nil {
+			return err
+		}
+	}
+
+	if backfillRoles {
+		err := db.Exec(`
+			INSERT INTO user_roles (user_id, role_id)
+			SELECT users.id, roles.id FROM users, roles WHERE roles.name = ?`,
+			defaultRoleName(),
+		).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Make sure the bootstrap administrator (if configured and registered) holds the admin role.
+	if adminEmail := os.Getenv("ADMIN_EMAIL"); adminEmail != "" {
+		err := db.Exec(`
+			INSERT INTO user_roles (user_id, role_id)
+			SELECT users.id, roles.id FROM users, roles
+			WHERE LOWER(users.email) = ? AND roles.name = ?
+			ON CONFLICT DO NOTHING`,
+			strings.ToLower(adminEmail), RoleAdmin,
+		).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func defaultRoleName() string {
+	if name := os.Getenv("DEFAULT_USER_ROLE"); name != "" {
+		return name
+	}
+	return RoleStaff
+}
@@ -1,10 +1,74 @@
 package user
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
-	Name     string `json:"name"`
-	Email    string `json:"email" gorm:"unique"`
-	Password string `json:"-"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email" gorm:"unique"`
+	Password        string     `json:"-"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	Roles           []Role     `json:"roles" gorm:"many2many:user_roles;"`
+	DeactivatedAt   *time.Time `json:"deactivatedAt"`
+	// IsServiceAccount marks non-human accounts that authenticate with API keys only.
+	IsServiceAccount bool `json:"isServiceAccount" gorm:"not null;default:false"`
+	// PasswordResetRequired blocks password logins until the user resets their password.
+	PasswordResetRequired bool `json:"passwordResetRequired" gorm:"not null;default:false"`
+
+	// TOTPSecret is set during enrolment and only takes effect once TOTPEnabledAt is set.
+	TOTPSecret    string     `json:"-"`
+	TOTPEnabledAt *time.Time `json:"totpEnabledAt"`
+	// TOTPLastStep is the time step of the last accepted code, so a code can't be replayed.
+	TOTPLastStep int64 `json:"-"`
+}
+
+func (User) AuditEntityType() string {
+	return "user"
+}
+
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) IsActive() bool {
+	return u.DeactivatedAt == nil
+}
+
+func (u *User) HasRole(names ...string) bool {
+	for _, role := range u.Roles {
+		for _, name := range names {
+			if role.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CanManageInventory reports whether the user may manage products and suppliers and
+// post stock adjustments.
+func (u *User) CanManageInventory() bool {
+	return u.HasRole(ManagerRoles...)
+}
+
+func (u *User) IsTOTPEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// RequiresMFA reports whether any of the user's roles demands two-factor authentication.
+// Service accounts are exempt because they cannot log in interactively.
+func (u *User) RequiresMFA() bool {
+	if u.IsServiceAccount {
+		return false
+	}
+	for _, role := range u.Roles {
+		if role.RequireMFA {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,33 @@
+package user
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TokenPurpose string
+
+const (
+	PurposePasswordReset     TokenPurpose = "password_reset"
+	PurposeEmailVerification TokenPurpose = "email_verification"
+	PurposeMFAChallenge      TokenPurpose = "mfa_challenge"
+)
+
+// OneTimeToken is a single-use, expiring token sent to a user out of band.
+// Only the SHA-256 hash of the token is stored.
+type OneTimeToken struct {
+	gorm.Model
+	UserID    uint `gorm:"not null;index"`
+	User      User
+	Purpose   TokenPurpose `gorm:"not null"`
+	TokenHash string       `json:"-" gorm:"unique;not null"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	// Attempts counts failed redemptions for tokens that are checked together with a code.
+	Attempts int `gorm:"not null;default:0"`
+}
+
+func (OneTimeToken) AuditEntityType() string {
+	return "one_time_token"
+}
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OneTimeTokenRepository interface {
+	Create(t *OneTimeToken) error
+	FindByHash(tokenHash string, purpose TokenPurpose) (*OneTimeToken, error)
+	MarkUsed(id uint) (bool, error)
+	InvalidateForUser(userID uint, purpose TokenPurpose) error
+	ConsumeAttempt(id uint, maxAttempts int) (bool, error)
+}
+
+type oneTimeTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewOneTimeTokenRepository(db *gorm.DB) OneTimeTokenRepository {
+	return &oneTimeTokenRepository{db: db}
+}
+
+func (r *oneTimeTokenRepository) Create(t *OneTimeToken) error {
+	return r.db.Create(t).Error
+}
+
+func (r *oneTimeTokenRepository) FindByHash(tokenHash string, purpose TokenPurpose) (*OneTimeToken, error) {
+	var token OneTimeToken
+	err := r.db.Where("token_hash = ? AND purpose = ?", tokenHash, purpose).First(&token).Error
+	return &token, err
+}
+
+// MarkUsed consumes the token. It reports false if the token had already been used,
+// so two concurrent requests can never both redeem the same token.
+func (r *oneTimeTokenRepository) MarkUsed(id uint) (bool, error) {
+	result := r.db.Model(&OneTimeToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *oneTimeTokenRepository) InvalidateForUser(userID uint, purpose TokenPurpose) error {
+	return r.db.Model(&OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
+
+// ConsumeAttempt counts an attempt to redeem the token. It reports false once the
+// token has had maxAttempts or has been used; the check and the count are a single
+// statement, so concurrent attempts can't exceed the limit.
+func (r *oneTimeTokenRepository) ConsumeAttempt(id uint, maxAttempts int) (bool, error) {
+	result := r.db.Model(&OneTimeToken{}).
+		Where("id = ? AND used_at IS NULL AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,35 @@
+package user
+
+import "errors"
+
+var ErrNotOrganizationMember = errors.New("you are not a member of this organization")
+
+// OrganizationDirectory answers the organization questions the user package needs
+// when issuing tokens. It is implemented by the organization package.
+type OrganizationDirectory interface {
+	IsMember(orgID, userID uint) (bool, error)
+	// DefaultOrganizationID returns the organization sessions start in, or 0 if the
+	// user belongs to none.
+	DefaultOrganizationID(userID uint) (uint, error)
+	// AddNewUser adds a newly created user to the configured organization, if any.
+	AddNewUser(userID uint) error
+}
+
+// SwitchOrganization starts a new session in another of the user's organizations.
+func (s *service) SwitchOrganization(user *User, orgID uint) (*LoginResponse, error) {
+	if err := s.checkMembership(user.ID, orgID); err != nil {
+		return nil, err
+	}
+	return s.issueTokenPairForOrganization(user, orgID)
+}
+
+func (s *service) checkMembership(userID, orgID uint) error {
+	isMember, err := s.orgs.IsMember(orgID, userID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return ErrNotOrganizationMember
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMe returns the current user's profile.
+// @Summary      Get my profile
+// @Tags         Profile
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  UserResponse
+// @Failure      401  {object}  map[string]interface{}
+// @Router       /me [get]
+func (h *Handler) GetMe(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.svc.GetProfile(user))
+}
+
+// UpdateMe updates the current user's profile.
+// @Summary      Update my profile
+// @Description  Changes the current user's name and email. A changed email must be verified again.
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        profile body UpdateProfileInput true "Profile"
+// @Success      200  {object}  UserResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Router       /me [put]
+func (h *Handler) UpdateMe(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input UpdateProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.svc.UpdateProfile(user, input)
+	if err != nil {
+		if errors.Is(err, ErrEmailInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ChangePassword changes the current user's password.
+// @Summary      Change my password
+// @Description  Requires the current password. All refresh tokens are revoked, so other sessions must log in again.
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        passwords body ChangePasswordInput true "Current and New Password"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]interface{}
+// @Router       /me/password [put]
+func (h *Handler) ChangePassword(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.ChangePassword(user, input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SwitchOrganization starts a session in another organization.
+// @Summary      Switch the active organization
+// @Description  Returns a new token pair bound to another organization the current user belongs to. Products, suppliers and inventory are always those of the active organization.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        organization  body      SwitchOrganizationInput  true  "Organization"
+// @Success      200  {object}  LoginResponse
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /me/organization [put]
+func (h *Handler) SwitchOrganization(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input SwitchOrganizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.svc.SwitchOrganization(user, input.OrganizationID)
+	if err != nil {
+		if errors.Is(err, ErrNotOrganizationMember) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to switch organization"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": tokens})
+}
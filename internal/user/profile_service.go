@@ -0,0 +1,67 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ErrEmailInUse is returned when another account already has the email address.
+var ErrEmailInUse = errors.New("email already in use")
+
+func (s *service) GetProfile(user *User) *UserResponse {
+	response := toUserResponse(*user)
+	return &response
+}
+
+// UpdateProfile changes the current user's name and email. A new email address has
+// to be verified again before the account can use protected endpoints.
+func (s *service) UpdateProfile(user *User, input UpdateProfileInput) (*UserResponse, error) {
+	emailChanged := normalizeEmail(input.Email) != normalizeEmail(user.Email)
+
+	if emailChanged {
+		_, err := s.userRepo.FindByEmail(input.Email)
+		if err == nil {
+			return nil, ErrEmailInUse
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+	}
+
+	user.Name = input.Name
+	user.Email = input.Email
+	if emailChanged {
+		user.EmailVerifiedAt = nil
+	}
+
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("could not update profile: %w", err)
+	}
+
+	if emailChanged {
+		if err := s.sendVerificationEmail(user); err != nil {
+			log.Printf("could not send verification email to user %d: %v", user.ID, err)
+		}
+	}
+
+	response := toUserResponse(*user)
+	return &response, nil
+}
+
+// ChangePassword sets a new password after checking the current one.
+// All refresh tokens are revoked, so other sessions must log in again.
+func (s *service) ChangePassword(user *User, input ChangePasswordInput) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)); err != nil {
+		return fmt.Errorf("current password is incorrect")
+	}
+
+	if err := validatePassword(input.NewPassword); err != nil {
+		return err
+	}
+
+	return s.setPassword(user, input.NewPassword)
+}
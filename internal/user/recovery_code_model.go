@@ -0,0 +1,16 @@
+package user
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode is a single-use backup code for two-factor authentication.
+// Only the SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint   `gorm:"not null;index"`
+	CodeHash string `gorm:"not null"`
+	UsedAt   *time.Time
+}
@@ -0,0 +1,48 @@
+package user
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	ReplaceForUser(userID uint, codeHashes []string) error
+	Consume(userID uint, codeHash string) (bool, error)
+	DeleteByUserID(userID uint) error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+// ReplaceForUser discards any existing codes and stores the new set.
+func (r *recoveryCodeRepository) ReplaceForUser(userID uint, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume marks a matching unused code as used and reports whether one was found.
+func (r *recoveryCodeRepository) Consume(userID uint, codeHash string) (bool, error) {
+	result := r.db.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *recoveryCodeRepository) DeleteByUserID(userID uint) error {
+	return r.db.Unscoped().Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error
+}
@@ -12,4 +12,7 @@ type RefreshToken struct {
 	User      User
 	Token     string `gorm:"unique"`
 	ExpiresAt time.Time
+	// OrganizationID is the organization the session is active in; zero means the
+	// user's default organization.
+	OrganizationID uint `gorm:"not null;default:0"`
 }
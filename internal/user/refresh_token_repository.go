@@ -5,6 +5,7 @@ import "gorm.io/gorm"
 type RefreshTokenRepository interface {
 	Create(rt *RefreshToken) error
 	FindByToken(token string) (*RefreshToken, error)
+	DeleteByUserID(userID uint) error
 }
 
 type refreshTokenRepository struct {
@@ -24,3 +25,7 @@ func (r *refreshTokenRepository) FindByToken(token string) (*RefreshToken, error
 	err := r.db.Where("token = ?", token).First(&refreshToken).Error
 	return &refreshToken, err
 }
+
+func (r *refreshTokenRepository) DeleteByUserID(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&RefreshToken{}).Error
+}
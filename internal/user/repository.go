@@ -1,11 +1,23 @@
 package user
 
-import "gorm.io/gorm"
+import (
+	"sort"
+	"strconv"
+
+	"github.com/RezaBG/Inventory-management-api/internal/audit"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
 type Repository interface {
 	Save(user *User) error
+	Update(user *User) error
 	FindByEmail(email string) (*User, error)
 	FindByID(id uint) (*User, error)
+	Search(term string, offset, limit int) ([]User, int64, error)
+	ReplaceRoles(user *User, roles []Role) error
+	Delete(id uint) error
 }
 
 type repository struct {
@@ -20,16 +32,69 @@ func (r *repository) Save(user *User) error {
 	return r.db.Create(user).Error
 }
 
+func (r *repository) Update(user *User) error {
+	// Role membership is managed explicitly, never as a side effect of saving the user.
+	return r.db.Omit(clause.Associations).Save(user).Error
+}
+
 func (r *repository) FindByEmail(email string) (*User, error) {
 	var user User
 
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.Preload("Roles").Where("email = ?", email).First(&user).Error
 	return &user, err
 }
 
 func (r *repository) FindByID(id uint) (*User, error) {
 	var user User
 
-	err := r.db.First(&user, id).Error
+	err := r.db.Preload("Roles").First(&user, id).Error
 	return &user, err
 }
+
+func (r *repository) Search(term string, offset, limit int) ([]User, int64, error) {
+	db := r.db.Model(&User{})
+	if term != "" {
+		pattern := "%" + query.EscapeLike(term) + "%"
+		db = db.Where("name ILIKE ? OR email ILIKE ?", pattern, pattern)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []User
+	err := db.Preload("Roles").Order("id").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+// ReplaceRoles makes roles the user's only roles. Changes to the join table bypass the
+// audit callbacks, so the change is recorded as an update of the user's roles.
+func (r *repository) ReplaceRoles(user *User, roles []Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var old []Role
+		if err := tx.Model(user).Association("Roles").Find(&old); err != nil {
+			return err
+		}
+		if err := tx.Model(user).Association("Roles").Replace(roles); err != nil {
+			return err
+		}
+		return audit.RecordUpdate(tx, User{}.AuditEntityType(), strconv.FormatUint(uint64(user.ID), 10),
+			map[string]interface{}{"roles": roleNames(old)},
+			map[string]interface{}{"roles": roleNames(roles)})
+	})
+}
+
+// roleNames returns the names of the roles, sorted.
+func roleNames(roles []Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *repository) Delete(id uint) error {
+	return r.db.Delete(&User{}, id).Error
+}
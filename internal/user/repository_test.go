@@ -1 +1,36 @@
 package user
+
+import (
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestSearchEscapesWildcards(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vars []interface{}
+	err = db.Callback().Query().After("gorm:query").Register("test:record", func(tx *gorm.DB) {
+		if vars == nil {
+			vars = tx.Statement.Vars
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &repository{db: db}
+	if _, _, err := r.Search(`100%_a\b`, 0, 20); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	want := `%100\%\_a\\b%`
+	if len(vars) != 2 || vars[0] != want || vars[1] != want {
+		t.Errorf("patterns = %v, want %q for name and email", vars, want)
+	}
+}
@@ -0,0 +1,42 @@
+package user
+
+import "gorm.io/gorm"
+
+const (
+	RoleAdmin   = "admin"
+	RoleManager = "manager"
+	RoleStaff   = "staff"
+)
+
+// ManagerRoles may manage products and suppliers and post stock adjustments. Every role
+// may record stock movements.
+var ManagerRoles = []string{RoleAdmin, RoleManager}
+
+// defaultRoles are created on startup if they don't exist yet.
+var defaultRoles = []Role{
+	{Name: RoleAdmin, Description: "Full access, including user and role administration"},
+	{Name: RoleManager, Description: "Manages products, suppliers and stock adjustments"},
+	{Name: RoleStaff, Description: "Records day-to-day stock movements, except adjustments"},
+}
+
+type Role struct {
+	gorm.Model
+	Name        string `json:"name" gorm:"unique;not null"`
+	Description string `json:"description"`
+	RequireMFA  bool   `json:"requireMfa" gorm:"not null;default:false"`
+}
+
+func (Role) AuditEntityType() string {
+	return "role"
+}
+
+// CanManageInventory reports whether members of the role may manage products and
+// suppliers and post stock adjustments.
+func (r Role) CanManageInventory() bool {
+	for _, name := range ManagerRoles {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package user
+
+import "gorm.io/gorm"
+
+type RoleRepository interface {
+	FindAll() ([]Role, error)
+	FindByName(name string) (*Role, error)
+	FindByNames(names []string) ([]Role, error)
+	Update(role *Role) error
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) FindAll() ([]Role, error) {
+	var roles []Role
+	err := r.db.Order("name").Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) FindByName(name string) (*Role, error) {
+	var role Role
+	err := r.db.Where("name = ?", name).First(&role).Error
+	return &role, err
+}
+
+func (r *roleRepository) FindByNames(names []string) ([]Role, error) {
+	var roles []Role
+	err := r.db.Where("name IN ?", names).Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) Update(role *Role) error {
+	return r.db.Save(role).Error
+}
@@ -5,5 +5,67 @@ import "github.com/gin-gonic/gin"
 func RegisterAuthRoutes(router *gin.Engine, h *Handler) {
 	router.POST("/register", h.CreateUser)
 	router.POST("/login", h.Login)
+	router.POST("/login/mfa", h.VerifyMFA)
 	router.POST("/refresh_token", h.RefreshToken)
+	router.POST("/password/forgot", h.ForgotPassword)
+	router.POST("/password/reset", h.ResetPassword)
+	router.GET("/email/verify", h.VerifyEmail)
+	router.POST("/email/verify", h.VerifyEmail)
+	router.POST("/email/verify/resend", h.ResendVerification)
+}
+
+// RegisterMFARoutes registers the two-factor self-service endpoints. They must stay
+// reachable for users whose role requires 2FA but who haven't enrolled yet.
+func RegisterMFARoutes(router *gin.RouterGroup, h *Handler) {
+	mfaRoutes := router.Group("/mfa")
+	{
+		mfaRoutes.POST("/totp/enroll", h.EnrollTOTP)
+		mfaRoutes.POST("/totp/confirm", h.ConfirmTOTP)
+		mfaRoutes.POST("/totp/disable", h.DisableTOTP)
+		mfaRoutes.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+	}
+}
+
+// RegisterAdminRoutes registers user administration endpoints. The router group is
+// expected to be restricted to administrators.
+func RegisterAdminRoutes(router *gin.RouterGroup, h *Handler) {
+	adminRoutes := router.Group("/admin")
+	{
+		adminRoutes.GET("/roles", h.GetRoles)
+		adminRoutes.PUT("/roles/:name", h.UpdateRole)
+		adminRoutes.GET("/lockouts", h.GetLockouts)
+
+		adminRoutes.GET("/users", h.ListUsers)
+		adminRoutes.GET("/users/:id", h.GetUser)
+		adminRoutes.PUT("/users/:id/roles", h.UpdateUserRoles)
+		adminRoutes.POST("/users/:id/deactivate", h.DeactivateUser)
+		adminRoutes.POST("/users/:id/reactivate", h.ReactivateUser)
+		adminRoutes.POST("/users/:id/password-reset", h.ForcePasswordReset)
+		adminRoutes.POST("/users/:id/unlock", h.UnlockUser)
+		adminRoutes.DELETE("/users/:id", h.DeleteUser)
+		adminRoutes.GET("/users/:id/api-keys", h.ListUserAPIKeys)
+		adminRoutes.POST("/users/:id/api-keys", h.CreateUserAPIKey)
+
+		adminRoutes.POST("/service-accounts", h.CreateServiceAccount)
+		adminRoutes.DELETE("/api-keys/:id", h.RevokeAnyAPIKey)
+	}
+}
+
+// RegisterProfileRoutes registers the current user's self-service endpoints,
+// including management of their own API keys.
+func RegisterProfileRoutes(router *gin.RouterGroup, h *Handler) {
+	profileRoutes := router.Group("/me")
+	{
+		profileRoutes.GET("", h.GetMe)
+		profileRoutes.PUT("", h.UpdateMe)
+		profileRoutes.PUT("/password", h.ChangePassword)
+		profileRoutes.PUT("/organization", h.SwitchOrganization)
+	}
+
+	apiKeyRoutes := router.Group("/api-keys")
+	{
+		apiKeyRoutes.POST("", h.CreateAPIKey)
+		apiKeyRoutes.GET("", h.ListAPIKeys)
+		apiKeyRoutes.DELETE("/:id", h.RevokeAPIKey)
+	}
 }
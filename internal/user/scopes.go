@@ -0,0 +1,82 @@
+package user
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scopes limit what machine credentials can do. Each protected resource has a read
+// scope for safe methods (GET/HEAD) and a write scope for everything else.
+const (
+	ScopeProductsRead     = "products:read"
+	ScopeProductsWrite    = "products:write"
+	ScopeCategoriesRead   = "categories:read"
+	ScopeCategoriesWrite  = "categories:write"
+	ScopeUnitsRead        = "units:read"
+	ScopeUnitsWrite       = "units:write"
+	ScopeRatesRead        = "exchange-rates:read"
+	ScopeRatesWrite       = "exchange-rates:write"
+	ScopeSuppliersRead    = "suppliers:read"
+	ScopeSuppliersWrite   = "suppliers:write"
+	ScopeInventoryRead    = "inventory:read"
+	ScopeInventoryWrite   = "inventory:write"
+	ScopeCustomersRead    = "customers:read"
+	ScopeCustomersWrite   = "customers:write"
+	ScopeGroupsRead       = "customer-groups:read"
+	ScopeGroupsWrite      = "customer-groups:write"
+	ScopePriceListsRead   = "price-lists:read"
+	ScopePriceListsWrite  = "price-lists:write"
+	ScopeAttachmentsRead  = "attachments:read"
+	ScopeAttachmentsWrite = "attachments:write"
+)
+
+var KnownScopes = []string{
+	ScopeProductsRead,
+	ScopeProductsWrite,
+	ScopeCategoriesRead,
+	ScopeCategoriesWrite,
+	ScopeUnitsRead,
+	ScopeUnitsWrite,
+	ScopeRatesRead,
+	ScopeRatesWrite,
+	ScopeSuppliersRead,
+	ScopeSuppliersWrite,
+	ScopeInventoryRead,
+	ScopeInventoryWrite,
+	ScopeCustomersRead,
+	ScopeCustomersWrite,
+	ScopeGroupsRead,
+	ScopeGroupsWrite,
+	ScopePriceListsRead,
+	ScopePriceListsWrite,
+	ScopeAttachmentsRead,
+	ScopeAttachmentsWrite,
+}
+
+// ParseScopes splits a space-separated scope string (the OAuth2 format).
+func ParseScopes(scope string) []string {
+	return strings.Fields(scope)
+}
+
+func FormatScopes(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// ValidateScopes rejects scopes that are not in KnownScopes.
+func ValidateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !HasScope(KnownScopes, scope) {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
+
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
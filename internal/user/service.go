@@ -2,14 +2,20 @@ package user
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/RezaBG/Inventory-management-api/internal/platform/mail"
+	"github.com/RezaBG/Inventory-management-api/internal/platform/token"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -17,27 +23,105 @@ import (
 
 type Service interface {
 	CreateNewUser(input CreateUserInput) (*User, error)
-	Login(input LoginInput) (*LoginResponse, error)
+	Login(input LoginInput, clientIP string) (*LoginResponse, *MFAChallengeResponse, error)
 	FindByID(id uint) (*User, error)
 	RefreshToken(input RefreshTokenInput) (*AccessTokenResponse, error)
+	RequestPasswordReset(input ForgotPasswordInput) error
+	ResetPassword(input ResetPasswordInput) error
+	VerifyEmail(input VerifyEmailInput) error
+	ResendVerificationEmail(input ResendVerificationInput) error
+
+	// Two-factor authentication
+	VerifyMFALogin(input VerifyMFAInput, clientIP string) (*LoginResponse, error)
+	EnrollTOTP(user *User) (*TOTPEnrollmentResponse, error)
+	ConfirmTOTP(user *User, input MFACodeInput) (*RecoveryCodesResponse, error)
+	DisableTOTP(user *User, input MFACodeInput) error
+	RegenerateRecoveryCodes(user *User, input MFACodeInput) (*RecoveryCodesResponse, error)
+
+	// Roles
+	GetAllRoles() ([]RoleResponse, error)
+	UpdateRole(name string, input UpdateRoleInput) (*RoleResponse, error)
+
+	// Login lockouts
+	GetActiveLockouts() ([]LockoutEventResponse, error)
+	UnlockUser(userID uint, adminID uint) error
+
+	// User administration
+	ListUsers(input ListUsersInput) (*UserListResponse, error)
+	GetUser(id uint) (*UserResponse, error)
+	UpdateUserRoles(id uint, input UpdateUserRolesInput, adminID uint) (*UserResponse, error)
+	DeactivateUser(id uint, adminID uint) (*UserResponse, error)
+	ReactivateUser(id uint) (*UserResponse, error)
+	ForcePasswordReset(id uint) error
+	DeleteUser(id uint, adminID uint) error
+
+	// Current user's profile
+	GetProfile(user *User) *UserResponse
+	UpdateProfile(user *User, input UpdateProfileInput) (*UserResponse, error)
+	ChangePassword(user *User, input ChangePasswordInput) error
+
+	// API keys and service accounts
+	CreateAPIKey(owner *User, input CreateAPIKeyInput) (*CreatedAPIKeyResponse, error)
+	CreateAPIKeyForServiceAccount(userID uint, input CreateAPIKeyInput) (*CreatedAPIKeyResponse, error)
+	ListAPIKeys(userID uint) ([]APIKeyResponse, error)
+	RevokeAPIKey(userID uint, keyID uint) error
+	RevokeAnyAPIKey(keyID uint) error
+	AuthenticateAPIKey(rawKey string) (*Principal, error)
+	CreateServiceAccount(input CreateServiceAccountInput) (*UserResponse, error)
+
+	// Organizations
+	SwitchOrganization(user *User, orgID uint) (*LoginResponse, error)
+
+	// External identity providers
+	LoginWithExternalIdentity(identity ExternalIdentity, defaultRole string) (*LoginResponse, *MFAChallengeResponse, error)
 }
 
 type service struct {
-	userRepo Repository
-	rtRepo   RefreshTokenRepository
+	userRepo     Repository
+	rtRepo       RefreshTokenRepository
+	ottRepo      OneTimeTokenRepository
+	roleRepo     RoleRepository
+	recoveryRepo RecoveryCodeRepository
+	throttleRepo LoginThrottleRepository
+	lockoutRepo  LockoutEventRepository
+	apiKeyRepo   APIKeyRepository
+	identityRepo IdentityRepository
+	orgs         OrganizationDirectory
+	mailer       mail.Sender
 }
 
-func NewService(userRepo Repository, rtRepo RefreshTokenRepository) Service {
+func NewService(
+	userRepo Repository,
+	rtRepo RefreshTokenRepository,
+	ottRepo OneTimeTokenRepository,
+	roleRepo RoleRepository,
+	recoveryRepo RecoveryCodeRepository,
+	throttleRepo LoginThrottleRepository,
+	lockoutRepo LockoutEventRepository,
+	apiKeyRepo APIKeyRepository,
+	identityRepo IdentityRepository,
+	orgs OrganizationDirectory,
+	mailer mail.Sender,
+) Service {
 	return &service{
-		userRepo: userRepo,
-		rtRepo:   rtRepo,
+		userRepo:     userRepo,
+		rtRepo:       rtRepo,
+		ottRepo:      ottRepo,
+		roleRepo:     roleRepo,
+		recoveryRepo: recoveryRepo,
+		throttleRepo: throttleRepo,
+		lockoutRepo:  lockoutRepo,
+		apiKeyRepo:   apiKeyRepo,
+		identityRepo: identityRepo,
+		orgs:         orgs,
+		mailer:       mailer,
 	}
 }
 
 func (s *service) CreateNewUser(input CreateUserInput) (*User, error) {
 	_, err := s.userRepo.FindByEmail(input.Email)
 	if err == nil {
-		return nil, fmt.Errorf("email already in use")
+		return nil, ErrEmailInUse
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("database error: %w", err)
@@ -53,31 +137,222 @@ func (s *service) CreateNewUser(input CreateUserInput) (*User, error) {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
+	roles, err := s.rolesForNewUser(input.Email, defaultRoleName())
+	if err != nil {
+		return nil, err
+	}
+
 	newUser := User{
 		Name:     input.Name,
 		Email:    input.Email,
 		Password: string(hashedPassword),
+		Roles:    roles,
 	}
 
 	if err := s.userRepo.Save(&newUser); err != nil {
 		return nil, err
 	}
 
+	if err := s.orgs.AddNewUser(newUser.ID); err != nil {
+		log.Printf("could not add user %d to an organization: %v", newUser.ID, err)
+	}
+
+	// The account exists either way; the user can ask for a new link if this fails.
+	if err := s.sendVerificationEmail(&newUser); err != nil {
+		log.Printf("could not send verification email to user %d: %v", newUser.ID, err)
+	}
+
 	return &newUser, nil
 }
 
-func (s *service) Login(input LoginInput) (*LoginResponse, error) {
+// rolesForNewUser returns the given default role, plus admin for the configured bootstrap administrator.
+func (s *service) rolesForNewUser(email string, roleName string) ([]Role, error) {
+	defaultRole, err := s.roleRepo.FindByName(roleName)
+	if err != nil {
+		return nil, fmt.Errorf("could not load default role: %w", err)
+	}
+	roles := []Role{*defaultRole}
+
+	if adminEmail := os.Getenv("ADMIN_EMAIL"); adminEmail != "" && strings.EqualFold(email, adminEmail) {
+		adminRole, err := s.roleRepo.FindByName(RoleAdmin)
+		if err != nil {
+			return nil, fmt.Errorf("could not load admin role: %w", err)
+		}
+		roles = append(roles, *adminRole)
+	}
+
+	return roles, nil
+}
+
+// VerifyEmail redeems an email verification token and marks the account as verified.
+func (s *service) VerifyEmail(input VerifyEmailInput) error {
+	token, err := s.redeemOneTimeToken(input.Token, PurposeEmailVerification)
+	if err != nil {
+		return fmt.Errorf("invalid or expired verification token")
+	}
+
+	user, err := s.userRepo.FindByID(token.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid or expired verification token")
+	}
+
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("could not verify email: %w", err)
+	}
+
+	return nil
+}
+
+// ResendVerificationEmail sends a fresh verification link to an unverified account.
+// Like RequestPasswordReset it does not reveal whether the email is registered.
+func (s *service) ResendVerificationEmail(input ResendVerificationInput) error {
+	user, err := s.userRepo.FindByEmail(input.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("database error: %w", err)
+	}
+
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	return s.sendVerificationEmail(user)
+}
+
+func (s *service) sendVerificationEmail(user *User) error {
+	if err := s.ottRepo.InvalidateForUser(user.ID, PurposeEmailVerification); err != nil {
+		return fmt.Errorf("could not invalidate previous verification tokens: %w", err)
+	}
+
+	expirationHours, _ := strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_TOKEN_EXPIRATION_HOURS"))
+	if expirationHours == 0 {
+		expirationHours = 24
+	}
+
+	tokenString, err := s.issueOneTimeToken(user.ID, PurposeEmailVerification, time.Hour*time.Duration(expirationHours))
+	if err != nil {
+		return err
+	}
+
+	verifyURL := os.Getenv("EMAIL_VERIFICATION_URL")
+	if verifyURL == "" {
+		verifyURL = "http://localhost:8080/email/verify"
+	}
+
+	err = s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nPlease confirm your email address by opening the link below:\n\n%s?token=%s\n\nThe link expires in %d hours.",
+			user.Name, verifyURL, url.QueryEscape(tokenString), expirationHours,
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("could not send verification email: %w", err)
+	}
+
+	return nil
+}
+
+// Login checks the credentials and returns a token pair. If the user has two-factor
+// authentication enabled, it returns an MFA challenge instead, which must be completed
+// with VerifyMFALogin before any tokens are issued.
+func (s *service) Login(input LoginInput, clientIP string) (*LoginResponse, *MFAChallengeResponse, error) {
+	policy := currentLoginPolicy()
+	if err := s.checkLoginThrottle(policy, input.Email, clientIP); err != nil {
+		return nil, nil, err
+	}
+
 	user, err := s.userRepo.FindByEmail(input.Email)
 	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+		compareDummyPassword(input.Password)
+		s.recordLoginFailure(policy, input.Email, clientIP, nil)
+		return nil, nil, fmt.Errorf("invalid credentials")
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
 	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+		s.recordLoginFailure(policy, input.Email, clientIP, user)
+		return nil, nil, fmt.Errorf("invalid credentials")
+	}
+
+	if !user.IsActive() {
+		return nil, nil, ErrAccountDeactivated
+	}
+	if user.PasswordResetRequired {
+		return nil, nil, ErrPasswordResetRequired
+	}
+
+	// With two-factor authentication, the password alone doesn't prove anything yet:
+	// the account's counter keeps counting until VerifyMFALogin succeeds, so the code
+	// can't be guessed by asking for challenge after challenge.
+	if user.IsTOTPEnabled() {
+		challenge, err := s.issueMFAChallenge(user)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, challenge, nil
+	}
+
+	s.clearLoginFailures(user)
+
+	tokens, err := s.issueTokenPair(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, nil, nil
+}
+
+func (s *service) RefreshToken(input RefreshTokenInput) (*AccessTokenResponse, error) {
+	refreshToken, err := s.rtRepo.FindByToken(input.RefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid refresh token")
 	}
 
-	// Refresh token logic
+	if time.Now().After(refreshToken.ExpiresAt) {
+		return nil, fmt.Errorf("refresh token has expired")
+	}
+
+	user, err := s.userRepo.FindByID(refreshToken.UserID)
+	if err != nil || !user.IsActive() {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	orgID := refreshToken.OrganizationID
+	if orgID == 0 {
+		if orgID, err = s.orgs.DefaultOrganizationID(user.ID); err != nil {
+			return nil, fmt.Errorf("could not load organization: %w", err)
+		}
+	}
+
+	newAccessTokenString, err := newAccessToken(refreshToken.UserID, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("could not create new access token: %w", err)
+	}
+
+	return &AccessTokenResponse{AccessToken: newAccessTokenString}, nil
+}
+
+// issueTokenPair starts a session in the user's default organization.
+func (s *service) issueTokenPair(user *User) (*LoginResponse, error) {
+	orgID, err := s.orgs.DefaultOrganizationID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("could not load organization: %w", err)
+	}
+	return s.issueTokenPairForOrganization(user, orgID)
+}
+
+// issueTokenPairForOrganization stores a new refresh token for the user and returns it
+// with a fresh access token, both bound to the given organization.
+func (s *service) issueTokenPairForOrganization(user *User, orgID uint) (*LoginResponse, error) {
 	refreshTokenString, err := generateSecureRandomToken(32)
 	if err != nil {
 		return nil, fmt.Errorf("could not generate refresh token: %w", err)
@@ -89,30 +364,17 @@ func (s *service) Login(input LoginInput) (*LoginResponse, error) {
 	}
 
 	refreshToken := &RefreshToken{
-		UserID:    user.ID,
-		Token:     refreshTokenString,
-		ExpiresAt: time.Now().Add(time.Hour * time.Duration(rtExpirationHours)),
+		UserID:         user.ID,
+		Token:          refreshTokenString,
+		ExpiresAt:      time.Now().Add(time.Hour * time.Duration(rtExpirationHours)),
+		OrganizationID: orgID,
 	}
 
 	if err := s.rtRepo.Create(refreshToken); err != nil {
 		return nil, fmt.Errorf("could not save refresh token: %w", err)
 	}
 
-	atExpirationMinutes, _ := strconv.Atoi(os.Getenv("ACCESS_TOKEN_EXPIRATION_MINUTES"))
-	if atExpirationMinutes == 0 {
-		atExpirationMinutes = 24
-	}
-
-	claims := jwt.NewWithClaims(
-		jwt.SigningMethodHS256,
-		jwt.RegisteredClaims{
-			Issuer:    os.Getenv("JWT_ISSUER"),
-			Subject:   strconv.FormatUint(uint64(user.ID), 10),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(atExpirationMinutes))),
-		})
-
-	// Sign the token with a secret key (loaded from environment variable)
-	accessTokenString, err := claims.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	accessTokenString, err := newAccessToken(user.ID, orgID)
 	if err != nil {
 		return nil, fmt.Errorf("could not create token: %w", err)
 	}
@@ -121,38 +383,154 @@ func (s *service) Login(input LoginInput) (*LoginResponse, error) {
 		AccessToken:  accessTokenString,
 		RefreshToken: refreshTokenString,
 	}, nil
+}
 
+func newAccessToken(userID uint, orgID uint) (string, error) {
+	return token.Sign(token.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(token.AccessTokenLifetime())),
+		},
+		Org: orgID,
+	})
 }
 
-func (s *service) RefreshToken(input RefreshTokenInput) (*AccessTokenResponse, error) {
-	refreshToken, err := s.rtRepo.FindByToken(input.RefreshToken)
+// RequestPasswordReset emails a single-use reset link to the account owner.
+// It returns nil for unknown emails so the endpoint cannot be used to discover accounts.
+func (s *service) RequestPasswordReset(input ForgotPasswordInput) error {
+	user, err := s.userRepo.FindByEmail(input.Email)
 	if err != nil {
-		return nil, fmt.Errorf("invalid refresh token")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("database error: %w", err)
 	}
 
-	if time.Now().After(refreshToken.ExpiresAt) {
-		return nil, fmt.Errorf("refresh token has expired")
+	return s.sendPasswordResetEmail(user, "We received a request to reset your password.")
+}
+
+func (s *service) sendPasswordResetEmail(user *User, reason string) error {
+	// Only the most recently issued link should work.
+	if err := s.ottRepo.InvalidateForUser(user.ID, PurposePasswordReset); err != nil {
+		return fmt.Errorf("could not invalidate previous reset tokens: %w", err)
+	}
+
+	expirationMinutes, _ := strconv.Atoi(os.Getenv("PASSWORD_RESET_TOKEN_EXPIRATION_MINUTES"))
+	if expirationMinutes == 0 {
+		expirationMinutes = 30
 	}
 
-	atExpirationMinutes, _ := strconv.Atoi(os.Getenv("ACCESS_TOKEN_EXPIRATION_MINUTES"))
-	if atExpirationMinutes == 0 {
-		atExpirationMinutes = 15
+	tokenString, err := s.issueOneTimeToken(user.ID, PurposePasswordReset, time.Minute*time.Duration(expirationMinutes))
+	if err != nil {
+		return err
 	}
 
-	claims := jwt.NewWithClaims(
-		jwt.SigningMethodHS256,
-		jwt.RegisteredClaims{
-			Issuer:    os.Getenv("JWT_ISSUER"),
-			Subject:   strconv.FormatUint(uint64(refreshToken.UserID), 10),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * time.Duration(atExpirationMinutes))),
-		})
+	resetURL := os.Getenv("PASSWORD_RESET_URL")
+	if resetURL == "" {
+		resetURL = "http://localhost:8080/password/reset"
+	}
 
-	newAccessTokenString, err := claims.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	err = s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\n%s Use the link below to choose a new password:\n\n%s?token=%s\n\nThe link expires in %d minutes. If you did not request a reset, you can ignore this email.",
+			user.Name, reason, resetURL, url.QueryEscape(tokenString), expirationMinutes,
+		),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("could not create new access token: %w", err)
+		return fmt.Errorf("could not send password reset email: %w", err)
 	}
 
-	return &AccessTokenResponse{AccessToken: newAccessTokenString}, nil
+	return nil
+}
+
+// ResetPassword redeems a reset token, sets the new password and signs the user out everywhere.
+func (s *service) ResetPassword(input ResetPasswordInput) error {
+	// Validate first so a weak password doesn't burn the token.
+	if err := validatePassword(input.Password); err != nil {
+		return err
+	}
+
+	token, err := s.redeemOneTimeToken(input.Token, PurposePasswordReset)
+	if err != nil {
+		return fmt.Errorf("invalid or expired reset token")
+	}
+
+	user, err := s.userRepo.FindByID(token.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid or expired reset token")
+	}
+
+	return s.setPassword(user, input.Password)
+}
+
+// setPassword stores a new (already validated) password and revokes all refresh tokens.
+func (s *service) setPassword(user *User, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user.Password = string(hashedPassword)
+	user.PasswordResetRequired = false
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("could not update password: %w", err)
+	}
+
+	if err := s.rtRepo.DeleteByUserID(user.ID); err != nil {
+		// The password has already changed, so don't fail the request over this.
+		log.Printf("could not revoke refresh tokens for user %d: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// issueOneTimeToken stores the hash of a new random token and returns the plain token.
+func (s *service) issueOneTimeToken(userID uint, purpose TokenPurpose, ttl time.Duration) (string, error) {
+	tokenString, err := generateSecureRandomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("could not generate token: %w", err)
+	}
+
+	err = s.ottRepo.Create(&OneTimeToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(tokenString),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not save token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// redeemOneTimeToken validates the token and consumes it so it cannot be used again.
+func (s *service) redeemOneTimeToken(tokenString string, purpose TokenPurpose) (*OneTimeToken, error) {
+	token, err := s.ottRepo.FindByHash(hashToken(tokenString), purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, fmt.Errorf("token is no longer valid")
+	}
+
+	used, err := s.ottRepo.MarkUsed(token.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, fmt.Errorf("token is no longer valid")
+	}
+
+	return token, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 func generateSecureRandomToken(length int) (string, error) {
@@ -163,6 +541,48 @@ func generateSecureRandomToken(length int) (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
+func toRoleResponse(role Role) RoleResponse {
+	return RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		RequireMFA:  role.RequireMFA,
+	}
+}
+
+func (s *service) GetAllRoles() ([]RoleResponse, error) {
+	roles, err := s.roleRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []RoleResponse
+	for _, role := range roles {
+		responses = append(responses, toRoleResponse(role))
+	}
+	return responses, nil
+}
+
+// UpdateRole changes whether members of a role must use two-factor authentication.
+func (s *service) UpdateRole(name string, input UpdateRoleInput) (*RoleResponse, error) {
+	role, err := s.roleRepo.FindByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if *input.RequireMFA && !role.CanManageInventory() {
+		return nil, ErrMFANotForRole
+	}
+
+	role.RequireMFA = *input.RequireMFA
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, fmt.Errorf("could not update role: %w", err)
+	}
+
+	response := toRoleResponse(*role)
+	return &response, nil
+}
+
 // FundByID retrieves a user by their ID.
 func (s *service) FindByID(id uint) (*User, error) {
 	return s.userRepo.FindByID(id)
@@ -1 +1,165 @@
 package user
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/RezaBG/Inventory-management-api/internal/platform/mail"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// fakeOneTimeTokens keeps one-time tokens in memory.
+type fakeOneTimeTokens struct {
+	OneTimeTokenRepository
+	tokens []OneTimeToken
+}
+
+func (r *fakeOneTimeTokens) Create(t *OneTimeToken) error {
+	t.ID = uint(len(r.tokens) + 1)
+	r.tokens = append(r.tokens, *t)
+	return nil
+}
+
+func (r *fakeOneTimeTokens) FindByHash(tokenHash string, purpose TokenPurpose) (*OneTimeToken, error) {
+	for i := range r.tokens {
+		if r.tokens[i].TokenHash == tokenHash && r.tokens[i].Purpose == purpose {
+			token := r.tokens[i]
+			return &token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeOneTimeTokens) MarkUsed(id uint) (bool, error) {
+	now := time.Now()
+	for i := range r.tokens {
+		if r.tokens[i].ID == id && r.tokens[i].UsedAt == nil {
+			r.tokens[i].UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeOneTimeTokens) InvalidateForUser(userID uint, purpose TokenPurpose) error {
+	now := time.Now()
+	for i := range r.tokens {
+		if r.tokens[i].UserID == userID && r.tokens[i].Purpose == purpose && r.tokens[i].UsedAt == nil {
+			r.tokens[i].UsedAt = &now
+		}
+	}
+	return nil
+}
+
+// fakeMailer keeps the messages it was asked to send.
+type fakeMailer struct {
+	sent []mail.Message
+}
+
+func (m *fakeMailer) Send(msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var resetLink = regexp.MustCompile(`\?token=(\S+)`)
+
+// requestReset asks for a password reset and returns the token from the email.
+func requestReset(t *testing.T, s *service, mailer *fakeMailer, email string) string {
+	t.Helper()
+	if err := s.RequestPasswordReset(ForgotPasswordInput{Email: email}); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	match := resetLink.FindStringSubmatch(mailer.sent[len(mailer.sent)-1].Body)
+	if match == nil {
+		t.Fatalf("no reset link in %q", mailer.sent[len(mailer.sent)-1].Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func newPasswordResetService() (*service, *fakeUsers, *fakeOneTimeTokens, *fakeRefreshTokens, *fakeMailer) {
+	users := &fakeUsers{users: []User{{Model: gorm.Model{ID: 1}, Email: "ada@example.com", Password: "old hash"}}}
+	tokens := &fakeOneTimeTokens{}
+	refreshTokens := &fakeRefreshTokens{}
+	mailer := &fakeMailer{}
+	return &service{userRepo: users, ottRepo: tokens, rtRepo: refreshTokens, mailer: mailer}, users, tokens, refreshTokens, mailer
+}
+
+func TestResetPasswordIsSingleUse(t *testing.T) {
+	s, users, _, refreshTokens, mailer := newPasswordResetService()
+	token := requestReset(t, s, mailer, "ada@example.com")
+
+	if err := s.ResetPassword(ResetPasswordInput{Token: token, Password: "weak"}); err == nil {
+		t.Fatal("weak password accepted")
+	}
+	// The weak password didn't use up the token.
+	if err := s.ResetPassword(ResetPasswordInput{Token: token, Password: "N3w-Password"}); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	user, _ := users.FindByID(1)
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("N3w-Password")) != nil {
+		t.Errorf("password not changed")
+	}
+	if !slices.Contains(refreshTokens.revoked, 1) {
+		t.Errorf("refresh tokens not revoked")
+	}
+
+	if err := s.ResetPassword(ResetPasswordInput{Token: token, Password: "An0ther-Password"}); err == nil {
+		t.Fatal("token used twice")
+	}
+	user, _ = users.FindByID(1)
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("N3w-Password")) != nil {
+		t.Errorf("password changed by a used token")
+	}
+}
+
+func TestResetPasswordRejectsStaleTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		stale func(t *testing.T, s *service, tokens *fakeOneTimeTokens, mailer *fakeMailer)
+	}{
+		{"expired", func(t *testing.T, s *service, tokens *fakeOneTimeTokens, mailer *fakeMailer) {
+			tokens.tokens[0].ExpiresAt = time.Now().Add(-time.Second)
+		}},
+		{"superseded by a newer link", func(t *testing.T, s *service, tokens *fakeOneTimeTokens, mailer *fakeMailer) {
+			requestReset(t, s, mailer, "ada@example.com")
+		}},
+		{"other purpose", func(t *testing.T, s *service, tokens *fakeOneTimeTokens, mailer *fakeMailer) {
+			tokens.tokens[0].Purpose = PurposeEmailVerification
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, users, tokens, refreshTokens, mailer := newPasswordResetService()
+			token := requestReset(t, s, mailer, "ada@example.com")
+			tt.stale(t, s, tokens, mailer)
+
+			if err := s.ResetPassword(ResetPasswordInput{Token: token, Password: "N3w-Password"}); err == nil {
+				t.Fatal("stale token accepted")
+			}
+			if user, _ := users.FindByID(1); user.Password != "old hash" {
+				t.Errorf("password changed by a stale token")
+			}
+			if len(refreshTokens.revoked) > 0 {
+				t.Errorf("refresh tokens revoked by a stale token")
+			}
+		})
+	}
+}
+
+func TestRequestPasswordResetForUnknownEmail(t *testing.T) {
+	s, _, tokens, _, mailer := newPasswordResetService()
+	if err := s.RequestPasswordReset(ForgotPasswordInput{Email: "nobody@example.com"}); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	if len(tokens.tokens) > 0 || len(mailer.sent) > 0 {
+		t.Errorf("reset link issued for an unknown email")
+	}
+}